@@ -1,17 +1,15 @@
 package controller
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/NutriPocket/ProgressService/service"
 	"github.com/gin-gonic/gin"
-	"github.com/op/go-logging"
 )
 
-var log = logging.MustGetLogger("log")
-
 type AnthropometricController struct {
 	s service.IUserDataService
 }
@@ -20,7 +18,7 @@ func NewAnthropometricController(s service.IUserDataService) (*AnthropometricCon
 	var err error
 
 	if s == nil {
-		s, err = service.NewUserDataService(nil, nil)
+		s, err = service.NewUserDataService(nil, nil, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -37,22 +35,28 @@ func (c *AnthropometricController) PutAnthropometricData(ctx *gin.Context) error
 		return err
 	}
 
-	var data *model.AnthropometricData
+	units, err := getUnits(ctx, c.s, authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	var data model.AnthropometricEntry
 	if err := ctx.ShouldBindJSON(&data); err != nil {
-		return &model.ValidationError{
-			Title:  "Invalid anthropometric user data",
-			Detail: fmt.Sprintf("The anthropometric user data is invalid, %v", err),
-		}
+		return bindingError("anthropometric.invalid", err)
 	}
 
-	log.Debugf("Received anthropometric data: %v", data)
+	slog.DebugContext(ctx.Request.Context(), "Received anthropometric data")
 
+	model.FromUnits(units, &data)
+	data.ID = 0
 	data.UserID = authUser.ID
-	ret, err, created := c.s.PutAnthropometricData(data)
+	ret, err, created := c.s.PutAnthropometricData(ctx.Request.Context(), &data)
 	if err != nil {
 		return err
 	}
 
+	model.ToUnits(units, &ret)
+
 	jsonRet := make(map[string]any)
 	jsonRet["data"] = ret
 
@@ -65,13 +69,49 @@ func (c *AnthropometricController) PutAnthropometricData(ctx *gin.Context) error
 	return nil
 }
 
-func (c *AnthropometricController) GetAnthropometricDataByUser(ctx *gin.Context) error {
+func (c *AnthropometricController) PostAnthropometricEntry(ctx *gin.Context) error {
 	authUser, err := GetAuthUser(ctx)
 	if err != nil {
 		return err
 	}
 
+	units, err := getUnits(ctx, c.s, authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	var data model.AnthropometricEntry
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		return bindingError("anthropometric.invalid", err)
+	}
+
+	model.FromUnits(units, &data)
+	data.ID = 0
+	data.UserID = authUser.ID
+	ret, err := c.s.AddAnthropometricEntry(ctx.Request.Context(), &data)
+	if err != nil {
+		return err
+	}
+
+	model.ToUnits(units, &ret)
+
 	jsonRet := make(map[string]any)
+	jsonRet["data"] = ret
+
+	ctx.JSON(http.StatusCreated, jsonRet)
+	return nil
+}
+
+func (c *AnthropometricController) GetAnthropometricDataByUser(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	units, err := getUnits(ctx, c.s, authUser.ID)
+	if err != nil {
+		return err
+	}
 
 	date := ctx.Query("date")
 
@@ -80,31 +120,154 @@ func (c *AnthropometricController) GetAnthropometricDataByUser(ctx *gin.Context)
 			return err
 		}
 
-		var data model.AnthropometricData
-		data, err = c.s.GetAnthropometricDataByUserAndDay(authUser.ID, date)
-		jsonRet["data"] = data
-	} else {
-		startDate := ctx.Query("startDate")
-		endDate := ctx.Query("endDate")
-		params := &model.GetAnthropometricParams{}
-		if startDate != "" {
-			params.StartDate = &startDate
+		aggregate, err := getAggregate(ctx)
+		if err != nil {
+			return err
 		}
 
-		if endDate != "" {
-			params.EndDate = &endDate
+		data, err := c.s.GetAnthropometricDataByUserAndDay(ctx.Request.Context(), authUser.ID, date, aggregate)
+		if err != nil {
+			return err
 		}
 
-		var data []model.AnthropometricData
-		data, err = c.s.GetAllAnthropometricDataByUser(authUser.ID, params)
+		model.ToUnits(units, &data)
+
+		jsonRet := make(map[string]any)
 		jsonRet["data"] = data
+
+		ctx.JSON(http.StatusOK, jsonRet)
+		return nil
+	}
+
+	page, err := getPageParams(ctx, model.SortDesc)
+	if err != nil {
+		return err
+	}
+
+	startDate := ctx.Query("startDate")
+	endDate := ctx.Query("endDate")
+	params := &model.GetAnthropometricParams{PageParams: page}
+	if startDate != "" {
+		params.StartDate = &startDate
+	}
+
+	if endDate != "" {
+		params.EndDate = &endDate
 	}
 
+	data, err := c.s.GetAllAnthropometricDataByUser(ctx.Request.Context(), authUser.ID, params)
 	if err != nil {
 		return err
 	}
 
+	for i := range data.Items {
+		model.ToUnits(units, &data.Items[i])
+	}
+
+	items, err := selectFields(ctx, data.Items)
+	if err != nil {
+		return err
+	}
+
+	writePage(ctx, items, data.Next)
+	return nil
+}
+
+func (c *AnthropometricController) PatchAnthropometricData(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	date := ctx.Param("date")
+	if err := ValidateDate(date); err != nil {
+		return err
+	}
+
+	id, err := getEntryId(ctx)
+	if err != nil {
+		return err
+	}
+
+	units, err := getUnits(ctx, c.s, authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	var patch model.AnthropometricPatch
+	if err := ctx.ShouldBindJSON(&patch); err != nil {
+		return bindingError("anthropometric.invalid", err)
+	}
+
+	model.FromUnits(units, &patch)
+	ret, err := c.s.PatchAnthropometricData(ctx.Request.Context(), authUser.ID, date, id, &patch)
+	if err != nil {
+		return err
+	}
+
+	model.ToUnits(units, &ret)
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = ret
+
 	ctx.JSON(http.StatusOK, jsonRet)
+	return nil
+}
 
+func (c *AnthropometricController) DeleteAnthropometricData(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	date := ctx.Param("date")
+	if err := ValidateDate(date); err != nil {
+		return err
+	}
+
+	id, err := getEntryId(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.s.DeleteAnthropometricData(ctx.Request.Context(), authUser.ID, date, id)
+	if err != nil {
+		return err
+	}
+
+	ctx.Status(http.StatusNoContent)
 	return nil
 }
+
+// getAggregate returns the aggregate of the measurements of a day requested, the last
+// measurement by default
+func getAggregate(ctx *gin.Context) (model.AnthropometricAggregate, error) {
+	aggregate := model.AnthropometricAggregate(ctx.DefaultQuery("aggregate", string(model.AggregateLast)))
+
+	switch aggregate {
+	case model.AggregateFirst, model.AggregateLast, model.AggregateMin, model.AggregateMean:
+		return aggregate, nil
+	}
+
+	return "", &model.ValidationError{
+		Code: "anthropometric.invalid_aggregate",
+	}
+}
+
+// getEntryId returns the id of the measurement in the path, or nil if the route refers to
+// the whole day
+func getEntryId(ctx *gin.Context) (*uint64, error) {
+	idParam := ctx.Param("id")
+	if idParam == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseUint(idParam, 10, 64)
+	if err != nil {
+		return nil, &model.ValidationError{
+			Code: "anthropometric.invalid_id",
+		}
+	}
+
+	return &id, nil
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/gin-gonic/gin"
+)
+
+// etagOf returns the strong entity tag of a representation: the quoted hex SHA-256 of its JSON,
+// truncated to 128 bits. It returns an empty tag if it can't be encoded.
+func etagOf(representation any) string {
+	content, err := json.Marshal(representation)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// matchesAny returns whether the list of entity tags of a conditional header has etag. The
+// weak ones only match if weak is set, since If-Match uses the strong comparison.
+func matchesAny(header string, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeETag sets the ETag header of the representation sent in the response. It returns true
+// if the If-None-Match header of the request matches it, after responding with 304 Not Modified,
+// so the representation mustn't be sent.
+func writeETag(ctx *gin.Context, representation any) bool {
+	etag := etagOf(representation)
+	if etag == "" {
+		return false
+	}
+
+	ctx.Header("ETag", etag)
+
+	if header := ctx.GetHeader("If-None-Match"); header != "" && matchesAny(header, etag, true) {
+		ctx.Status(http.StatusNotModified)
+		return true
+	}
+
+	return false
+}
+
+// writeTagged responds with status and the data of a single resource along with its ETag, or
+// with 304 Not Modified if the If-None-Match header of the request matches it
+func writeTagged(ctx *gin.Context, status int, data any) {
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = data
+
+	if writeETag(ctx, jsonRet) {
+		return
+	}
+
+	ctx.JSON(status, jsonRet)
+}
+
+// checkIfMatch returns a precondition failed error unless the If-Match header of the request is
+// missing or matches the current data of the resource, as sent by its GET. current is nil if
+// the resource doesn't exist, then only a missing header matches. More than one representation
+// may be given when the GET sends several, e.g. depending on its query parameters.
+func checkIfMatch(ctx *gin.Context, current ...any) error {
+	bodies := make([]any, 0, len(current))
+	for _, data := range current {
+		if data != nil {
+			bodies = append(bodies, map[string]any{"data": data})
+		}
+	}
+
+	return checkIfMatchBody(ctx, bodies...)
+}
+
+// checkIfMatchBody is checkIfMatch for the whole body of the responses of the GET, e.g. the
+// pages of a list
+func checkIfMatchBody(ctx *gin.Context, current ...any) error {
+	header := ctx.GetHeader("If-Match")
+	if header == "" {
+		return nil
+	}
+
+	for _, body := range current {
+		if matchesAny(header, etagOf(body), false) {
+			return nil
+		}
+	}
+
+	return &model.PreconditionFailedError{
+		Code: "request.precondition_failed",
+	}
+}
+
+// ifMatchVersion returns the version of the resource the write must be conditioned on, the one
+// of its data checked by checkIfMatch, so it fails if the resource changes before it's written.
+// It returns nil if the request has no If-Match or it's *, which matches any version.
+func ifMatchVersion(ctx *gin.Context, version uint) *uint {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	return &version
+}
@@ -1,7 +1,7 @@
 package controller
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -38,16 +38,13 @@ func (c *ExerciseController) CreateExercise(ctx *gin.Context) error {
 
 	var data *model.ExerciseDTO
 	if err := ctx.ShouldBindJSON(&data); err != nil {
-		return &model.ValidationError{
-			Title:  "Invalid exercise data",
-			Detail: fmt.Sprintf("The exercise data is invalid, %v", err),
-		}
+		return bindingError("exercise.invalid", err)
 	}
 
-	log.Debugf("Received exercise data: %v", data)
+	slog.DebugContext(ctx.Request.Context(), "Received exercise data", "exercise_name", data.ExerciseName)
 
 	data.UserID = authUser.ID
-	ret, err := c.s.CreateExercise(data)
+	ret, err := c.s.CreateExercise(ctx.Request.Context(), data)
 	if err != nil {
 		return err
 	}
@@ -59,6 +56,58 @@ func (c *ExerciseController) CreateExercise(ctx *gin.Context) error {
 	return nil
 }
 
+// getExerciseId parses the ID of the exercise of the path
+func getExerciseId(ctx *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return 0, &model.ValidationError{
+			Code: "exercise.invalid_id",
+		}
+	}
+
+	return id, nil
+}
+
+// checkExerciseIfMatch fails unless the If-Match header of the request, if any, matches the
+// current exercise. It returns the version of the exercise the write is conditioned on.
+func (c *ExerciseController) checkExerciseIfMatch(ctx *gin.Context, id uint64, userId string) (*uint, error) {
+	if ctx.GetHeader("If-Match") == "" {
+		return nil, nil
+	}
+
+	current, err := c.s.GetExercise(ctx.Request.Context(), id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkIfMatch(ctx, current); err != nil {
+		return nil, err
+	}
+
+	return ifMatchVersion(ctx, current.Version), nil
+}
+
+// GetExercise handles GET requests to retrieve an exercise, along with its ETag
+func (c *ExerciseController) GetExercise(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, err := getExerciseId(ctx)
+	if err != nil {
+		return err
+	}
+
+	ret, err := c.s.GetExercise(ctx.Request.Context(), id, authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	writeTagged(ctx, http.StatusOK, ret)
+	return nil
+}
+
 // GetExercisesByUser handles GET requests to retrieve all exercises for a user
 func (c *ExerciseController) GetExercisesByUser(ctx *gin.Context) error {
 	authUser, err := GetAuthUser(ctx)
@@ -66,17 +115,24 @@ func (c *ExerciseController) GetExercisesByUser(ctx *gin.Context) error {
 		return err
 	}
 
+	page, err := getPageParams(ctx, model.SortAsc)
+	if err != nil {
+		return err
+	}
+
 	// Check if date parameter is provided
 	date := ctx.Query("date")
-	exercises, err := c.s.GetExercisesByUserIdAndDate(authUser.ID, date)
+	exercises, next, err := c.s.GetExercisesByUserIdAndDate(ctx.Request.Context(), authUser.ID, date, page)
 	if err != nil {
 		return err
 	}
 
-	jsonRet := make(map[string]any)
-	jsonRet["data"] = exercises
+	items, err := selectFields(ctx, exercises.Exercises)
+	if err != nil {
+		return err
+	}
 
-	ctx.JSON(http.StatusOK, jsonRet)
+	writePage(ctx, gin.H{"totalBurned": exercises.TotalBurned, "exercises": items}, next)
 	return nil
 }
 
@@ -87,27 +143,25 @@ func (c *ExerciseController) UpdateExercise(ctx *gin.Context) error {
 		return err
 	}
 
-	idParam := ctx.Param("id")
-	id, err := strconv.ParseUint(idParam, 10, 64)
+	id, err := getExerciseId(ctx)
 	if err != nil {
-		return &model.ValidationError{
-			Title:  "Invalid exercise ID",
-			Detail: "Exercise ID must be a positive integer",
-		}
+		return err
 	}
 
 	var data *model.ExerciseDTO
 	if err := ctx.ShouldBindJSON(&data); err != nil {
-		return &model.ValidationError{
-			Title:  "Invalid exercise data",
-			Detail: fmt.Sprintf("The exercise data is invalid, %v", err),
-		}
+		return bindingError("exercise.invalid", err)
 	}
 
 	// Ensure the user ID cannot be changed
 	data.UserID = authUser.ID
 
-	ret, err := c.s.UpdateExercise(id, authUser.ID, data)
+	version, err := c.checkExerciseIfMatch(ctx, id, authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	ret, err := c.s.UpdateExercise(ctx.Request.Context(), id, authUser.ID, data, version)
 	if err != nil {
 		return err
 	}
@@ -126,16 +180,17 @@ func (c *ExerciseController) DeleteExercise(ctx *gin.Context) error {
 		return err
 	}
 
-	idParam := ctx.Param("id")
-	id, err := strconv.ParseUint(idParam, 10, 64)
+	id, err := getExerciseId(ctx)
 	if err != nil {
-		return &model.ValidationError{
-			Title:  "Invalid exercise ID",
-			Detail: "Exercise ID must be a positive integer",
-		}
+		return err
+	}
+
+	version, err := c.checkExerciseIfMatch(ctx, id, authUser.ID)
+	if err != nil {
+		return err
 	}
 
-	err = c.s.DeleteExercise(id, authUser.ID)
+	err = c.s.DeleteExercise(ctx.Request.Context(), id, authUser.ID, version)
 	if err != nil {
 		return err
 	}
@@ -1,7 +1,7 @@
 package controller
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/NutriPocket/ProgressService/model"
@@ -17,7 +17,7 @@ func NewFixedDataController(s service.IUserDataService) (*FixedDataController, e
 	var err error
 
 	if s == nil {
-		s, err = service.NewUserDataService(nil, nil)
+		s, err = service.NewUserDataService(nil, nil, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -36,20 +36,33 @@ func (c *FixedDataController) PutFixedData(ctx *gin.Context) error {
 
 	var data *model.BaseFixedUserData
 	if err := ctx.ShouldBindJSON(&data); err != nil {
-		return &model.ValidationError{
-			Title:  "Invalid fixed user data",
-			Detail: fmt.Sprintf("The fixed user data is invalid, %v", err),
-		}
+		return bindingError("fixed_data.invalid", err)
+	}
+
+	slog.DebugContext(ctx.Request.Context(), "Received fixed user data")
+
+	version, err := c.checkFixedDataIfMatch(ctx, authUser.ID)
+	if err != nil {
+		return err
 	}
 
-	log.Debugf("Received fixed user data: %v", data)
+	// The preference sent is already the one of the values of the request
+	var units model.UnitSystem
+	if data.Units != nil && ctx.Query("units") == "" {
+		units = *data.Units
+	} else if units, err = getUnits(ctx, c.s, authUser.ID); err != nil {
+		return err
+	}
 
+	model.FromUnits(units, data)
 	data.UserID = authUser.ID
-	ret, err, created := c.s.PutFixedData(data)
+	ret, err, created := c.s.PutFixedData(ctx.Request.Context(), data, version)
 	if err != nil {
 		return err
 	}
 
+	model.ToUnits(units, &ret)
+
 	jsonRet := make(map[string]any)
 	jsonRet["data"] = ret
 
@@ -68,25 +81,90 @@ func (c *FixedDataController) GetFixedDataByUser(ctx *gin.Context) error {
 		return err
 	}
 
-	jsonRet := make(map[string]any)
+	units, err := getUnits(ctx, c.s, authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.currentFixedData(ctx, authUser.ID, units, ctx.Query("base") == "true")
+	if err != nil {
+		return err
+	}
+
+	writeTagged(ctx, http.StatusOK, data)
+	return nil
+}
 
-	baseData := ctx.Query("base") == "true"
+// currentFixedData returns the fixed data of the user in units as sent by GetFixedDataByUser,
+// only the base data if base is set
+func (c *FixedDataController) currentFixedData(ctx *gin.Context, userId string, units model.UnitSystem, base bool) (any, error) {
+	if base {
+		data, err := c.s.GetBaseFixedUserDataByUser(ctx.Request.Context(), userId)
+		model.ToUnits(units, &data)
+		return data, err
+	}
 
-	if baseData {
-		var data model.BaseFixedUserData
-		data, err = c.s.GetBaseFixedUserDataByUser(authUser.ID)
-		jsonRet["data"] = data
+	data, err := c.s.GetFixedDataByUser(ctx.Request.Context(), userId)
+	model.ToUnits(units, &data)
+	return data, err
+}
 
-	} else {
-		var data model.FixedUserData
-		data, err = c.s.GetFixedDataByUser(authUser.ID)
-		jsonRet["data"] = data
+// checkFixedDataIfMatch fails unless the If-Match header of the request, if any, matches the
+// current fixed data of the user, either the whole or the base one, in the units its GET uses.
+// It returns the version of the fixed data the write is conditioned on.
+func (c *FixedDataController) checkFixedDataIfMatch(ctx *gin.Context, userId string) (*uint, error) {
+	if ctx.GetHeader("If-Match") == "" {
+		return nil, nil
 	}
 
+	units, err := getUnits(ctx, c.s, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := c.s.GetBaseFixedUserDataByUser(ctx.Request.Context(), userId)
+	if _, ok := err.(*model.NotFoundError); ok {
+		return nil, checkIfMatch(ctx, nil)
+	} else if err != nil {
+		return nil, err
+	}
+	model.ToUnits(units, &base)
+
+	whole, err := c.currentFixedData(ctx, userId, units, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkIfMatch(ctx, whole, base); err != nil {
+		return nil, err
+	}
+
+	return ifMatchVersion(ctx, base.Version), nil
+}
+
+// GetHeightHistory responds with every height the user has registered, from the oldest to the current one
+func (c *FixedDataController) GetHeightHistory(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
 	if err != nil {
 		return err
 	}
 
+	units, err := getUnits(ctx, c.s, authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	history, err := c.s.GetHeightHistoryByUser(ctx.Request.Context(), authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range history {
+		model.ToUnits(units, &history[i])
+	}
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = history
 	ctx.JSON(http.StatusOK, jsonRet)
 
 	return nil
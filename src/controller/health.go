@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds how long the readiness probe waits for its dependencies
+const readinessTimeout = 2 * time.Second
+
+type HealthController struct {
+	s service.IHealthService
+}
+
+func NewHealthController(s service.IHealthService) (*HealthController, error) {
+	var err error
+
+	if s == nil {
+		s, err = service.NewHealthService(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &HealthController{
+		s: s,
+	}, nil
+}
+
+// Liveness handles GET requests that check if the process is up
+func (c *HealthController) Liveness(ctx *gin.Context) error {
+	ctx.JSON(http.StatusOK, c.s.Liveness())
+	return nil
+}
+
+// Readiness handles GET requests that check if the service is able to handle traffic
+func (c *HealthController) Readiness(ctx *gin.Context) error {
+	checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	report := c.s.Readiness(checkCtx)
+
+	if report.Status != model.HealthStatusUp {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return nil
+	}
+
+	ctx.JSON(http.StatusOK, report)
+	return nil
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/gin-gonic/gin"
+)
+
+type JobController struct {
+	s service.IJobService
+}
+
+func NewJobController(s service.IJobService) (*JobController, error) {
+	var err error
+
+	if s == nil {
+		s, err = service.NewJobService(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &JobController{
+		s: s,
+	}, nil
+}
+
+// getAdminUser returns the authenticated user if it's one of the comma-separated ADMIN_USER_IDS
+func getAdminUser(c *gin.Context) (*model.User, error) {
+	authUser, exists := c.Get("authUser")
+	if !exists {
+		return nil, authError
+	}
+
+	user, ok := authUser.(*model.User)
+	if !ok {
+		return nil, authError
+	}
+
+	admins := strings.Split(os.Getenv("ADMIN_USER_IDS"), ",")
+	for i := range admins {
+		admins[i] = strings.TrimSpace(admins[i])
+	}
+
+	if user.ID == "" || !slices.Contains(admins, user.ID) {
+		return nil, &model.ForbiddenError{
+			Code: "auth.not_admin",
+		}
+	}
+
+	return user, nil
+}
+
+func getJobId(ctx *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(ctx.Param("jobId"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, &model.ValidationError{
+			Code: "job.invalid_id",
+		}
+	}
+
+	return id, nil
+}
+
+func (c *JobController) GetJobs(ctx *gin.Context) error {
+	if _, err := getAdminUser(ctx); err != nil {
+		return err
+	}
+
+	page, err := getPageParams(ctx, model.SortDesc)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.s.GetJobs(ctx.Request.Context(), page)
+	if err != nil {
+		return err
+	}
+
+	items, err := selectFields(ctx, data.Items)
+	if err != nil {
+		return err
+	}
+
+	writePage(ctx, items, data.Next)
+	return nil
+}
+
+func (c *JobController) GetJob(ctx *gin.Context) error {
+	if _, err := getAdminUser(ctx); err != nil {
+		return err
+	}
+
+	id, err := getJobId(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.s.GetJob(ctx.Request.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = data
+
+	ctx.JSON(http.StatusOK, jsonRet)
+
+	return nil
+}
+
+func (c *JobController) GetJobRuns(ctx *gin.Context) error {
+	if _, err := getAdminUser(ctx); err != nil {
+		return err
+	}
+
+	id, err := getJobId(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := getPageParams(ctx, model.SortDesc)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.s.GetJobRuns(ctx.Request.Context(), id, page)
+	if err != nil {
+		return err
+	}
+
+	items, err := selectFields(ctx, data.Items)
+	if err != nil {
+		return err
+	}
+
+	writePage(ctx, items, data.Next)
+	return nil
+}
@@ -1,30 +1,41 @@
 package controller
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
 
+	"github.com/NutriPocket/ProgressService/i18n"
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/NutriPocket/ProgressService/service"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 type ObjectiveController struct {
-	s service.IObjectiveService
+	s  service.IObjectiveService
+	us service.IUserDataService
 }
 
-func NewObjectiveController(s service.IObjectiveService) (*ObjectiveController, error) {
+func NewObjectiveController(s service.IObjectiveService, us service.IUserDataService) (*ObjectiveController, error) {
 	var err error
 
 	if s == nil {
-		s, err = service.NewObjectiveService(nil)
+		s, err = service.NewObjectiveService(nil, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if us == nil {
+		us, err = service.NewUserDataService(nil, nil, nil)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	return &ObjectiveController{
-		s: s,
+		s:  s,
+		us: us,
 	}, nil
 }
 
@@ -34,26 +45,43 @@ func (c *ObjectiveController) PutObjective(ctx *gin.Context) error {
 		return err
 	}
 
-	var data *model.ObjectiveData
-	if err := ctx.ShouldBindJSON(&data); err != nil {
-		return &model.ValidationError{
-			Title:  "Invalid user objective data",
-			Detail: fmt.Sprintf("The user objective data is invalid, %v", err),
-		}
+	data, err := bindObjective(ctx)
+	if err != nil {
+		return err
 	}
 
 	if err := ValidateDeadline(data.Deadline); err != nil {
 		return err
 	}
 
-	log.Debugf("Received user objective data: %v", data)
+	slog.DebugContext(ctx.Request.Context(), "Received user objective data")
+
+	units, err := getUnits(ctx, c.us, authUser.ID)
+	if err != nil {
+		return err
+	}
 
+	version, err := c.checkObjectiveIfMatch(ctx, authUser.ID, units)
+	if err != nil {
+		return err
+	}
+
+	model.FromUnits(units, data)
 	data.UserID = authUser.ID
-	ret, err, created := c.s.PutObjective(data)
+	ret, err, created := c.s.PutObjective(ctx.Request.Context(), data, version)
 	if err != nil {
 		return err
 	}
 
+	model.ToUnits(units, &ret)
+
+	if ret.Feasibility != nil {
+		lang := i18n.Match(ctx.GetHeader("Accept-Language"))
+		for i, warning := range ret.Feasibility.Warnings {
+			ret.Feasibility.Warnings[i].Message = i18n.Warning(lang, warning.Code)
+		}
+	}
+
 	jsonRet := make(map[string]any)
 	jsonRet["data"] = ret
 
@@ -66,22 +94,138 @@ func (c *ObjectiveController) PutObjective(ctx *gin.Context) error {
 	return nil
 }
 
+// objectiveKind is the field of an objective that decides how the rest of it is bound
+type objectiveKind struct {
+	Type model.ObjectiveType `json:"type" binding:"omitempty,oneof=body_composition calories exercise_frequency weight_logging"`
+}
+
+// bindObjective binds the request body with the fields required by the type of the objective
+func bindObjective(ctx *gin.Context) (*model.ObjectiveData, error) {
+	var kind objectiveKind
+	if err := ctx.ShouldBindBodyWith(&kind, binding.JSON); err != nil {
+		return nil, bindingError("objective.invalid", err)
+	}
+
+	if kind.Type == "" || kind.Type == model.ObjectiveBodyComposition {
+		var data model.ObjectiveData
+		if err := ctx.ShouldBindBodyWith(&data, binding.JSON); err != nil {
+			return nil, bindingError("objective.invalid", err)
+		}
+
+		return &data, nil
+	}
+
+	var dto model.ActivityObjectiveDTO
+	if err := ctx.ShouldBindBodyWith(&dto, binding.JSON); err != nil {
+		return nil, bindingError("objective.invalid", err)
+	}
+
+	data := dto.Objective()
+	return &data, nil
+}
+
 func (c *ObjectiveController) GetObjectiveByUser(ctx *gin.Context) error {
 	authUser, err := GetAuthUser(ctx)
 	if err != nil {
 		return err
 	}
 
-	jsonRet := make(map[string]any)
+	units, err := getUnits(ctx, c.us, authUser.ID)
+	if err != nil {
+		return err
+	}
 
-	var data model.ObjectiveData
-	data, err = c.s.GetObjectiveByUser(authUser.ID)
-	jsonRet["data"] = data
+	data, err := c.s.GetObjectiveByUser(ctx.Request.Context(), authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	model.ToUnits(units, &data)
+
+	writeTagged(ctx, http.StatusOK, data)
+	return nil
+}
+
+// checkObjectiveIfMatch fails unless the If-Match header of the request, if any, matches the
+// current objective of the user in units. It returns the version of the objective the write is
+// conditioned on.
+func (c *ObjectiveController) checkObjectiveIfMatch(ctx *gin.Context, userId string, units model.UnitSystem) (*uint, error) {
+	if ctx.GetHeader("If-Match") == "" {
+		return nil, nil
+	}
 
+	current, err := c.s.GetObjectiveByUser(ctx.Request.Context(), userId)
+	if _, ok := err.(*model.NotFoundError); ok {
+		return nil, checkIfMatch(ctx, nil)
+	} else if err != nil {
+		return nil, err
+	}
+
+	model.ToUnits(units, &current)
+	if err := checkIfMatch(ctx, current); err != nil {
+		return nil, err
+	}
+
+	return ifMatchVersion(ctx, current.Version), nil
+}
+
+func (c *ObjectiveController) GetObjectiveHistoryByUser(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := getPageParams(ctx, model.SortDesc)
+	if err != nil {
+		return err
+	}
+
+	units, err := getUnits(ctx, c.us, authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.s.GetObjectiveHistoryByUser(ctx.Request.Context(), authUser.ID, page)
+	if err != nil {
+		return err
+	}
+
+	for i := range data.Items {
+		model.ToUnits(units, &data.Items[i])
+	}
+
+	items, err := selectFields(ctx, data.Items)
+	if err != nil {
+		return err
+	}
+
+	writePage(ctx, items, data.Next)
+	return nil
+}
+
+func (c *ObjectiveController) GetMilestonesByUser(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
 	if err != nil {
 		return err
 	}
 
+	units, err := getUnits(ctx, c.us, authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.s.GetMilestonesByUser(ctx.Request.Context(), authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range data {
+		model.ToUnits(units, &data[i])
+	}
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = data
+
 	ctx.JSON(http.StatusOK, jsonRet)
 
 	return nil
@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/gin-gonic/gin"
+)
+
+// encodeCursor returns the opaque representation of a cursor sent to the clients
+func encodeCursor(cursor *model.Cursor) string {
+	content, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+// decodeCursor parses a cursor received from a client
+// It returns an error if the cursor wasn't returned by encodeCursor
+func decodeCursor(value string) (*model.Cursor, error) {
+	invalid := &model.ValidationError{Code: "pagination.invalid_cursor"}
+
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var cursor model.Cursor
+	if err := json.Unmarshal(content, &cursor); err != nil || len(cursor.Keys) == 0 {
+		return nil, invalid
+	}
+
+	if cursor.Sort != model.SortAsc && cursor.Sort != model.SortDesc {
+		return nil, invalid
+	}
+
+	return &cursor, nil
+}
+
+// getPageParams parses the limit, sort and cursor query parameters of a list request. Without
+// a limit the whole list is returned. defaultSort is the order of the list if the client doesn't send one. The sort of a cursor
+// takes precedence over the sort parameter, so every page of a list has the same order.
+func getPageParams(ctx *gin.Context, defaultSort model.SortOrder) (model.PageParams, error) {
+	params := model.PageParams{
+		Limit: model.NoPageLimit,
+		Sort:  defaultSort,
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > model.MaxPageLimit {
+			return model.PageParams{}, &model.ValidationError{
+				Code:   "pagination.invalid_limit",
+				Params: map[string]string{"max": strconv.Itoa(model.MaxPageLimit)},
+			}
+		}
+		params.Limit = parsed
+	}
+
+	if sort := ctx.Query("sort"); sort != "" {
+		if sort != string(model.SortAsc) && sort != string(model.SortDesc) {
+			return model.PageParams{}, &model.ValidationError{Code: "pagination.invalid_sort"}
+		}
+		params.Sort = model.SortOrder(sort)
+	}
+
+	if value := ctx.Query("cursor"); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil {
+			return model.PageParams{}, err
+		}
+		params.After = cursor
+		params.Sort = cursor.Sort
+	}
+
+	return params, nil
+}
+
+// selectFields returns the items with only the JSON fields requested in the fields query
+// parameter (e.g. fields=weight,created_at), or the items untouched if it's empty.
+// It returns an error if a requested field isn't a field of T.
+func selectFields[T any](ctx *gin.Context, items []T) (any, error) {
+	param := ctx.Query("fields")
+	if param == "" {
+		return items, nil
+	}
+
+	known := jsonFields(reflect.TypeFor[T]())
+
+	fields := strings.Split(param, ",")
+	for _, field := range fields {
+		if !known[field] {
+			return nil, &model.ValidationError{
+				Code:   "request.invalid_fields",
+				Params: map[string]string{"field": field},
+			}
+		}
+	}
+
+	selected := make([]map[string]any, len(items))
+	for i, item := range items {
+		object, err := toJSONObject(item)
+		if err != nil {
+			return nil, err
+		}
+
+		selected[i] = make(map[string]any, len(fields))
+		for _, field := range fields {
+			selected[i][field] = object[field]
+		}
+	}
+
+	return selected, nil
+}
+
+// jsonFields returns the names of the JSON fields of the struct t, including the ones of its
+// embedded structs and the ones omitted when empty
+func jsonFields(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	fields := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// The fields of embedded structs without a name are encoded as fields of t
+		embedded := field.Type
+		for embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && name == "" && embedded.Kind() == reflect.Struct {
+			for inner := range jsonFields(embedded) {
+				fields[inner] = true
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields[name] = true
+	}
+
+	return fields
+}
+
+func toJSONObject(value any) (map[string]any, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]any
+	err = json.Unmarshal(content, &object)
+
+	return object, err
+}
+
+// pageBody returns the body of the response with a page of a list, as sent by writePage
+func pageBody(data any, next *model.Cursor) map[string]any {
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = data
+	jsonRet["next"] = nil
+
+	if next != nil {
+		jsonRet["next"] = encodeCursor(next)
+	}
+
+	return jsonRet
+}
+
+// writePage responds with a page of a list: data holds the items and next the cursor of the
+// next page, or null if it's the last one. The URL of the next page is also sent in a Link header,
+// and the ETag of the page, so it's answered with 304 Not Modified if it matches If-None-Match.
+func writePage(ctx *gin.Context, data any, next *model.Cursor) {
+	jsonRet := pageBody(data, next)
+
+	if next != nil {
+		cursor := encodeCursor(next)
+
+		nextURL := *ctx.Request.URL
+		query := nextURL.Query()
+		query.Set("cursor", cursor)
+		query.Del("sort")
+		nextURL.RawQuery = query.Encode()
+
+		ctx.Header("Link", "<"+nextURL.RequestURI()+`>; rel="next"`)
+	}
+
+	if writeETag(ctx, jsonRet) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, jsonRet)
+}
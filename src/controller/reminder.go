@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ReminderController struct {
+	s service.IReminderService
+}
+
+func NewReminderController(s service.IReminderService) (*ReminderController, error) {
+	var err error
+
+	if s == nil {
+		s, err = service.NewReminderService(nil, nil, nil, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ReminderController{
+		s: s,
+	}, nil
+}
+
+func getReminderId(ctx *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, &model.ValidationError{
+			Code: "reminder.invalid_id",
+		}
+	}
+
+	return id, nil
+}
+
+// PostReminder handles POST requests to create a reminder rule
+func (c *ReminderController) PostReminder(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	var data *model.ReminderDTO
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		return bindingError("reminder.invalid", err)
+	}
+
+	ret, err := c.s.CreateReminder(ctx.Request.Context(), authUser.ID, data)
+	if err != nil {
+		return err
+	}
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = ret
+
+	ctx.JSON(http.StatusCreated, jsonRet)
+	return nil
+}
+
+// GetReminders handles GET requests to list the reminder rules of a user
+func (c *ReminderController) GetReminders(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := getPageParams(ctx, model.SortAsc)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.s.GetReminders(ctx.Request.Context(), authUser.ID, page)
+	if err != nil {
+		return err
+	}
+
+	items, err := selectFields(ctx, data.Items)
+	if err != nil {
+		return err
+	}
+
+	writePage(ctx, items, data.Next)
+	return nil
+}
+
+// GetReminder handles GET requests to retrieve a reminder rule
+func (c *ReminderController) GetReminder(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, err := getReminderId(ctx)
+	if err != nil {
+		return err
+	}
+
+	ret, err := c.s.GetReminder(ctx.Request.Context(), id, authUser.ID)
+	if err != nil {
+		return err
+	}
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = ret
+
+	ctx.JSON(http.StatusOK, jsonRet)
+	return nil
+}
+
+// PutReminder handles PUT requests to replace a reminder rule
+func (c *ReminderController) PutReminder(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, err := getReminderId(ctx)
+	if err != nil {
+		return err
+	}
+
+	var data *model.ReminderDTO
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		return bindingError("reminder.invalid", err)
+	}
+
+	ret, err := c.s.UpdateReminder(ctx.Request.Context(), id, authUser.ID, data)
+	if err != nil {
+		return err
+	}
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = ret
+
+	ctx.JSON(http.StatusOK, jsonRet)
+	return nil
+}
+
+// DeleteReminder handles DELETE requests to remove a reminder rule
+func (c *ReminderController) DeleteReminder(ctx *gin.Context) error {
+	authUser, err := GetAuthUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, err := getReminderId(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.s.DeleteReminder(ctx.Request.Context(), id, authUser.ID); err != nil {
+		return err
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
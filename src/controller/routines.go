@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/NutriPocket/ProgressService/model"
@@ -36,14 +35,11 @@ func (c *RoutineController) PostRoutine(ctx *gin.Context) error {
 
 	var data *model.RoutineDTO
 	if err := ctx.ShouldBindJSON(&data); err != nil {
-		return &model.ValidationError{
-			Title:  "Invalid routine data",
-			Detail: fmt.Sprintf("The routine data is invalid, %v", err),
-		}
+		return bindingError("routine.invalid", err)
 	}
 
 	data.UserID = authUser.ID
-	ret, err := c.s.CreateRoutine(data)
+	ret, err := c.s.CreateRoutine(ctx.Request.Context(), data)
 	if err != nil {
 		return err
 	}
@@ -61,23 +57,29 @@ func (c *RoutineController) GetRoutinesByUser(ctx *gin.Context) error {
 		return err
 	}
 
-	var data []model.RoutineData
-	err = c.s.GetRoutinesByUser(authUser.ID, &data)
+	page, err := getPageParams(ctx, model.SortAsc)
 	if err != nil {
 		return err
 	}
 
-	jsonRet := make(map[string]any)
-	jsonRet["data"] = data
+	data, err := c.s.GetRoutinesByUser(ctx.Request.Context(), authUser.ID, page)
+	if err != nil {
+		return err
+	}
 
-	ctx.JSON(http.StatusOK, jsonRet)
+	items, err := selectFields(ctx, data.Items)
+	if err != nil {
+		return err
+	}
+
+	writePage(ctx, items, data.Next)
 	return nil
 }
 
 func (c *RoutineController) GetFreeSchedules(ctx *gin.Context) error {
 	users := ctx.QueryArray("users")
 
-	data, err := c.s.GetFreeSchedules(users)
+	data, err := c.s.GetFreeSchedules(ctx.Request.Context(), users)
 	if err != nil {
 		return err
 	}
@@ -97,13 +99,15 @@ func (c *RoutineController) DeleteRoutineBySchedule(ctx *gin.Context) error {
 
 	var data *model.Schedule
 	if err := ctx.ShouldBindJSON(&data); err != nil {
-		return &model.ValidationError{
-			Title:  "Invalid routine data",
-			Detail: fmt.Sprintf("The routine data is invalid, %v", err),
-		}
+		return bindingError("routine.invalid", err)
 	}
 
-	ret, err := c.s.DeleteRutineBySchedule(authUser.ID, data)
+	page, check, err := routinesIfMatch(ctx)
+	if err != nil {
+		return err
+	}
+
+	ret, err := c.s.DeleteRutineBySchedule(ctx.Request.Context(), authUser.ID, data, page, check)
 	if err != nil {
 		return err
 	}
@@ -114,3 +118,21 @@ func (c *RoutineController) DeleteRoutineBySchedule(ctx *gin.Context) error {
 	ctx.JSON(http.StatusOK, jsonRet)
 	return nil
 }
+
+// routinesIfMatch returns the check of the If-Match header of the request, if any, against the
+// current routines of the user, as sent by their GET with the same page parameters, along with
+// them. The deletion runs it on the routines it locks, so they can't change after matching.
+func routinesIfMatch(ctx *gin.Context) (model.PageParams, func(current model.Page[model.RoutineData]) error, error) {
+	if ctx.GetHeader("If-Match") == "" {
+		return model.PageParams{}, nil, nil
+	}
+
+	page, err := getPageParams(ctx, model.SortAsc)
+	if err != nil {
+		return model.PageParams{}, nil, err
+	}
+
+	return page, func(current model.Page[model.RoutineData]) error {
+		return checkIfMatchBody(ctx, pageBody(current.Items, current.Next))
+	}, nil
+}
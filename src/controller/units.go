@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/gin-gonic/gin"
+)
+
+// getUnits returns the unit system of the values of a request of the user: the one in the units
+// query parameter, or the one the user prefers
+func getUnits(ctx *gin.Context, s service.IUserDataService, userId string) (model.UnitSystem, error) {
+	if units := ctx.Query("units"); units != "" {
+		return parseUnits(units)
+	}
+
+	return s.GetUnitsByUser(ctx.Request.Context(), userId)
+}
+
+func parseUnits(units string) (model.UnitSystem, error) {
+	switch model.UnitSystem(units) {
+	case model.UnitsMetric, model.UnitsImperial:
+		return model.UnitSystem(units), nil
+	}
+
+	return "", &model.ValidationError{
+		Code: "request.invalid_units",
+	}
+}
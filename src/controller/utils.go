@@ -8,8 +8,7 @@ import (
 )
 
 var authError = &model.AuthenticationError{
-	Title:  "Unauthorized user",
-	Detail: "The user isn't authorized to access this endpoint",
+	Code: "auth.unauthorized_user",
 }
 
 func GetAuthUser(c *gin.Context) (*model.User, error) {
@@ -35,8 +34,7 @@ func GetAuthUser(c *gin.Context) (*model.User, error) {
 func ValidateDate(date string) error {
 	if _, err := time.Parse("2006-01-02", date); err != nil {
 		return &model.ValidationError{
-			Title:  "Invalid date",
-			Detail: "The format of the date is invalid, expected format: YYYY-MM-DD",
+			Code: "request.invalid_date",
 		}
 	}
 
@@ -47,15 +45,13 @@ func ValidateDeadline(date string) error {
 	parsed, err := time.Parse("2006-01-02", date)
 	if err != nil {
 		return &model.ValidationError{
-			Title:  "Invalid date",
-			Detail: "The format of the date is invalid, expected format: YYYY-MM-DD",
+			Code: "request.invalid_date",
 		}
 	}
 
 	if parsed.Before(time.Now()) {
 		return &model.ValidationError{
-			Title:  "Invalid deadline",
-			Detail: "The deadline must be in the future",
+			Code: "objective.past_deadline",
 		}
 	}
 
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// embeddedField is the name given to embedded structs without a json tag. Their fields are
+// promoted to the parent object in JSON, so the segment is dropped from the JSON pointers.
+const embeddedField = "~"
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+		v.RegisterCustomTypeFunc(nullableValue, model.Nullable[float32]{})
+	}
+}
+
+// nullableValue returns the value of a Nullable field to validate, nil if it's missing or null,
+// so its rules apply to the value sent
+func nullableValue(field reflect.Value) any {
+	nullable, ok := field.Interface().(model.Nullable[float32])
+	if !ok || nullable.Value == nil {
+		return nil
+	}
+
+	return *nullable.Value
+}
+
+// jsonFieldName returns the name of a struct field in its JSON representation,
+// so validation errors refer to the fields as the clients send them.
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+
+	switch {
+	case name == "-":
+		return ""
+	case name == "" && field.Anonymous:
+		return embeddedField
+	default:
+		return name
+	}
+}
+
+// jsonPointer converts the namespace of a validator error (e.g. "ObjectiveData.~.weight")
+// into the JSON pointer of the field in the request body (e.g. "/weight")
+func jsonPointer(namespace string) string {
+	segments := strings.Split(namespace, ".")[1:]
+
+	var pointer strings.Builder
+	for _, segment := range segments {
+		if segment == embeddedField {
+			continue
+		}
+
+		// Elements of slices and maps are reported as field[index]
+		segment = strings.NewReplacer("[", "/", "]", "").Replace(segment)
+		pointer.WriteString("/" + segment)
+	}
+
+	return pointer.String()
+}
+
+// fieldErrors returns one FieldError per invalid field of a binding error. Their messages are
+// left empty, they are localized when the error is rendered.
+// It returns nil if err wasn't caused by the value of a specific field, e.g. malformed JSON.
+func fieldErrors(err error) []model.FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]model.FieldError, 0, len(validationErrs))
+		for _, e := range validationErrs {
+			fields = append(fields, model.FieldError{
+				Pointer: jsonPointer(e.Namespace()),
+				Rule:    e.Tag(),
+				Param:   e.Param(),
+			})
+		}
+
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []model.FieldError{{
+			Pointer: "/" + strings.ReplaceAll(typeErr.Field, ".", "/"),
+			Rule:    "type",
+			Param:   typeErr.Type.Kind().String(),
+		}}
+	}
+
+	return nil
+}
+
+// bindingError returns the validation error of an invalid request body
+// code is the error code used if some fields are invalid, e.g. "exercise.invalid"
+// err is the error returned when binding the body
+func bindingError(code string, err error) error {
+	fields := fieldErrors(err)
+	if fields == nil {
+		return &model.ValidationError{
+			Code: "request.malformed_body",
+		}
+	}
+
+	return &model.ValidationError{
+		Code:   code,
+		Errors: fields,
+	}
+}
@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	s service.IWebhookService
+}
+
+func NewWebhookController(s service.IWebhookService) (*WebhookController, error) {
+	var err error
+
+	if s == nil {
+		s, err = service.NewWebhookService(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &WebhookController{
+		s: s,
+	}, nil
+}
+
+func getWebhookId(ctx *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(ctx.Param("webhookId"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, &model.ValidationError{
+			Code: "webhook.invalid_id",
+		}
+	}
+
+	return id, nil
+}
+
+func getDeliveryId(ctx *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(ctx.Param("deliveryId"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, &model.ValidationError{
+			Code: "webhook.invalid_delivery_id",
+		}
+	}
+
+	return id, nil
+}
+
+func (c *WebhookController) PostWebhook(ctx *gin.Context) error {
+	if _, err := getAdminUser(ctx); err != nil {
+		return err
+	}
+
+	var data *model.WebhookDTO
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		return bindingError("webhook.invalid", err)
+	}
+
+	ret, err := c.s.CreateWebhook(ctx.Request.Context(), data)
+	if err != nil {
+		return err
+	}
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = ret
+
+	ctx.JSON(http.StatusCreated, jsonRet)
+	return nil
+}
+
+func (c *WebhookController) GetWebhooks(ctx *gin.Context) error {
+	if _, err := getAdminUser(ctx); err != nil {
+		return err
+	}
+
+	page, err := getPageParams(ctx, model.SortAsc)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.s.GetWebhooks(ctx.Request.Context(), page)
+	if err != nil {
+		return err
+	}
+
+	items, err := selectFields(ctx, data.Items)
+	if err != nil {
+		return err
+	}
+
+	writePage(ctx, items, data.Next)
+	return nil
+}
+
+func (c *WebhookController) GetWebhook(ctx *gin.Context) error {
+	if _, err := getAdminUser(ctx); err != nil {
+		return err
+	}
+
+	id, err := getWebhookId(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.s.GetWebhook(ctx.Request.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = data
+
+	ctx.JSON(http.StatusOK, jsonRet)
+	return nil
+}
+
+func (c *WebhookController) PutWebhook(ctx *gin.Context) error {
+	if _, err := getAdminUser(ctx); err != nil {
+		return err
+	}
+
+	id, err := getWebhookId(ctx)
+	if err != nil {
+		return err
+	}
+
+	var data *model.WebhookDTO
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		return bindingError("webhook.invalid", err)
+	}
+
+	ret, err := c.s.UpdateWebhook(ctx.Request.Context(), id, data)
+	if err != nil {
+		return err
+	}
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = ret
+
+	ctx.JSON(http.StatusOK, jsonRet)
+	return nil
+}
+
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) error {
+	if _, err := getAdminUser(ctx); err != nil {
+		return err
+	}
+
+	id, err := getWebhookId(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.s.DeleteWebhook(ctx.Request.Context(), id); err != nil {
+		return err
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// GetDeliveries lists the deliveries of a webhook, only the ones with the status query
+// parameter if it's set, e.g. status=dead for the dead letters
+func (c *WebhookController) GetDeliveries(ctx *gin.Context) error {
+	if _, err := getAdminUser(ctx); err != nil {
+		return err
+	}
+
+	id, err := getWebhookId(ctx)
+	if err != nil {
+		return err
+	}
+
+	var status *model.DeliveryStatus
+	if value := ctx.Query("status"); value != "" {
+		parsed := model.DeliveryStatus(value)
+		if parsed != model.DeliveryPending && parsed != model.DeliverySending && parsed != model.DeliveryDelivered && parsed != model.DeliveryDead {
+			return &model.ValidationError{
+				Code: "webhook.invalid_delivery_status",
+			}
+		}
+		status = &parsed
+	}
+
+	page, err := getPageParams(ctx, model.SortDesc)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.s.GetDeliveries(ctx.Request.Context(), id, status, page)
+	if err != nil {
+		return err
+	}
+
+	items, err := selectFields(ctx, data.Items)
+	if err != nil {
+		return err
+	}
+
+	writePage(ctx, items, data.Next)
+	return nil
+}
+
+// RetryDelivery sends a dead letter again
+func (c *WebhookController) RetryDelivery(ctx *gin.Context) error {
+	if _, err := getAdminUser(ctx); err != nil {
+		return err
+	}
+
+	webhookId, err := getWebhookId(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, err := getDeliveryId(ctx)
+	if err != nil {
+		return err
+	}
+
+	ret, err := c.s.RetryDelivery(ctx.Request.Context(), webhookId, id)
+	if err != nil {
+		return err
+	}
+
+	jsonRet := make(map[string]any)
+	jsonRet["data"] = ret
+
+	ctx.JSON(http.StatusAccepted, jsonRet)
+	return nil
+}
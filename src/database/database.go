@@ -2,17 +2,20 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"time"
 
-	"github.com/op/go-logging"
+	"github.com/NutriPocket/ProgressService/metrics"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-var log = logging.MustGetLogger("log")
 var db *sql.DB
 
 // ConnectDB connects to the database.
@@ -40,7 +43,7 @@ func ConnectDB() {
 
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC", db_user, db_password, db_host, db_port, db_name)
 
-	log.Infof("DSN: %s\n", dsn)
+	slog.Info("Connecting to database", "host", db_host, "port", db_port, "database", db_name, "user", db_user)
 
 	var try uint
 	var err error
@@ -49,7 +52,7 @@ func ConnectDB() {
 		db, err = sql.Open("mysql", dsn)
 
 		if err != nil {
-			log.Infof("Failed to connect to database, trying again. Try number: %d\n. Err: %v", try, err)
+			slog.Warn("Failed to connect to database, trying again", "try", try, "error", err)
 			time.Sleep(2 * time.Second)
 			try++
 			continue
@@ -59,21 +62,28 @@ func ConnectDB() {
 		db.SetMaxOpenConns(100)
 		db.SetConnMaxLifetime(time.Hour)
 
-		log.Info("Connected to database")
+		if err := metrics.RegisterDBStats(db, db_name); err != nil {
+			slog.Warn("Failed to register database pool metrics", "error", err)
+		}
+
+		slog.Info("Connected to database")
 		return
 	}
 
-	log.Panicf("Failed to connect to database, %s", err)
+	slog.Error("Failed to connect to database", "error", err)
+	panic(err)
 }
 
+// GetPoolConnection returns a gorm session over the connection pool.
+// The gorm logger is silenced because the statements it prints hold the health data of the users.
 func GetPoolConnection() (*gorm.DB, error) {
 	gormDB, err := gorm.Open(
 		mysql.New(mysql.Config{Conn: db}),
-		&gorm.Config{},
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)},
 	)
 
 	if err != nil {
-		log.Errorf("Failed to initialize gorm: %v", err)
+		slog.Error("Failed to initialize gorm", "error", err)
 		return nil, err
 	}
 
@@ -84,10 +94,20 @@ func Close() {
 	if db != nil {
 		err := db.Close()
 		if err != nil {
-			log.Errorf("Failed to close database connection: %s\n", err)
+			slog.Error("Failed to close database connection", "error", err)
 			return
 		}
 	}
 
-	log.Infof("Database connection closed")
+	slog.Info("Database connection closed")
+}
+
+// Ping checks that the database connection pool is initialized and that the database is reachable.
+// It returns an error if the pool wasn't initialized or if the database doesn't answer before ctx is done.
+func Ping(ctx context.Context) error {
+	if db == nil {
+		return errors.New("database connection pool is not initialized")
+	}
+
+	return db.PingContext(ctx)
 }
@@ -0,0 +1,124 @@
+// Package events dispatches the domain events of the service to the handlers subscribed to them
+// within the process.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Types of the domain events
+const (
+	// AnthropometricRecorded is published when a measurement is added or replaced
+	AnthropometricRecorded = "anthropometric.recorded"
+	// ExerciseCreated, ExerciseUpdated and ExerciseDeleted are published when an exercise changes
+	ExerciseCreated = "exercise.created"
+	ExerciseUpdated = "exercise.updated"
+	ExerciseDeleted = "exercise.deleted"
+	// RoutineCreated and RoutineDeleted are published when a routine changes
+	RoutineCreated = "routine.created"
+	RoutineDeleted = "routine.deleted"
+	// ObjectiveAchieved is published when the progress of an active objective reaches its target
+	ObjectiveAchieved = "objective.achieved"
+	// ObjectiveExpired is published when the deadline of an active objective passes without reaching its target
+	ObjectiveExpired = "objective.expired"
+)
+
+// Types are every type of domain event
+var Types = []string{
+	AnthropometricRecorded,
+	ExerciseCreated,
+	ExerciseUpdated,
+	ExerciseDeleted,
+	RoutineCreated,
+	RoutineDeleted,
+	ObjectiveAchieved,
+	ObjectiveExpired,
+}
+
+// Event is something that happened to the data of a user
+type Event struct {
+	// ID identifies the event, so its consumers can tell when they receive it twice. Publish
+	// sets one if it's empty.
+	ID         string
+	Type       string
+	UserID     string
+	OccurredAt time.Time
+	// Payload is the data the event is about, e.g. the model.ObjectiveData of objective events
+	Payload any
+}
+
+// Handler reacts to an event. It runs in the goroutine that published the event, so it
+// shouldn't block for long.
+type Handler func(ctx context.Context, event Event)
+
+// Bus dispatches every published event to the handlers subscribed to its type
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe adds a handler of the events of type eventType
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish calls the handlers of the type of the event in the order they subscribed. A handler
+// that panics is logged and doesn't prevent the rest of them from running.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if event.ID == "" {
+		event.ID = NewID()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	slog.DebugContext(ctx, "Publishing event", "type", event.Type, "user_id", event.UserID, "handlers", len(handlers))
+
+	for _, handler := range handlers {
+		dispatch(ctx, handler, event)
+	}
+}
+
+// NewID returns a random 128 bits event ID encoded as hex
+func NewID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+
+	return hex.EncodeToString(bytes)
+}
+
+func dispatch(ctx context.Context, handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "Event handler panicked", "type", event.Type, "user_id", event.UserID, "panic", r)
+		}
+	}()
+
+	handler(ctx, event)
+}
+
+// defaultBus is the bus shared by the whole service
+var defaultBus = NewBus()
+
+// Subscribe adds a handler of the events of type eventType to the bus of the service
+func Subscribe(eventType string, handler Handler) {
+	defaultBus.Subscribe(eventType, handler)
+}
+
+// Publish dispatches the event to the handlers subscribed to the bus of the service
+func Publish(ctx context.Context, event Event) {
+	defaultBus.Publish(ctx, event)
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	t.Run("Handlers receive the events of their type in the order they subscribed", func(t *testing.T) {
+		bus := NewBus()
+
+		var received []string
+		bus.Subscribe(ObjectiveAchieved, func(ctx context.Context, event Event) {
+			received = append(received, "first "+event.UserID)
+		})
+		bus.Subscribe(ObjectiveAchieved, func(ctx context.Context, event Event) {
+			received = append(received, "second "+event.UserID)
+		})
+		bus.Subscribe(ObjectiveExpired, func(ctx context.Context, event Event) {
+			received = append(received, "expired "+event.UserID)
+		})
+
+		bus.Publish(context.Background(), Event{Type: ObjectiveAchieved, UserID: "1"})
+
+		if len(received) != 2 || received[0] != "first 1" || received[1] != "second 1" {
+			t.Errorf("received should be [first 1 second 1], got %v", received)
+		}
+	})
+
+	t.Run("A handler that panics doesn't stop the rest", func(t *testing.T) {
+		bus := NewBus()
+
+		called := false
+		bus.Subscribe(ObjectiveExpired, func(ctx context.Context, event Event) {
+			panic("handler failed")
+		})
+		bus.Subscribe(ObjectiveExpired, func(ctx context.Context, event Event) {
+			called = true
+		})
+
+		bus.Publish(context.Background(), Event{Type: ObjectiveExpired, UserID: "1"})
+
+		if !called {
+			t.Errorf("the second handler should be called")
+		}
+	})
+	t.Run("Events without an ID get one, events with one keep it", func(t *testing.T) {
+		bus := NewBus()
+
+		var ids []string
+		bus.Subscribe(ExerciseCreated, func(ctx context.Context, event Event) {
+			ids = append(ids, event.ID)
+		})
+
+		bus.Publish(context.Background(), Event{Type: ExerciseCreated, UserID: "1"})
+		bus.Publish(context.Background(), Event{ID: "abc", Type: ExerciseCreated, UserID: "1"})
+
+		if len(ids) != 2 || len(ids[0]) != 32 || ids[1] != "abc" {
+			t.Errorf("ids should be a generated one and abc, got %v", ids)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+// Package i18n provides the localized texts of the errors and warnings returned by the API.
+// Texts are loaded from the embedded locales/<language>.json catalogs and keyed by a stable
+// error code, so clients can rely on the code while users read the text in their language.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLanguage is used when the client doesn't accept any of the supported languages
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Message is the localized title and detail of an error
+type Message struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// catalog holds the texts of a single language
+type catalog struct {
+	Errors   map[string]Message `json:"errors"`
+	Rules    map[string]string  `json:"rules"`
+	Warnings map[string]string  `json:"warnings"`
+}
+
+// supported are the languages with a catalog, the first one is the default
+var supported = []language.Tag{language.English, language.Spanish}
+
+var matcher = language.NewMatcher(supported)
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]catalog {
+	catalogs := make(map[string]catalog, len(supported))
+
+	for _, tag := range supported {
+		lang := tag.String()
+
+		content, err := locales.ReadFile("locales/" + lang + ".json")
+		if err != nil {
+			panic("missing catalog for language " + lang)
+		}
+
+		var c catalog
+		if err := json.Unmarshal(content, &c); err != nil {
+			panic("invalid catalog for language " + lang + ": " + err.Error())
+		}
+
+		catalogs[lang] = c
+	}
+
+	return catalogs
+}
+
+// Match returns the supported language that best matches an Accept-Language header,
+// or DefaultLanguage if none does
+func Match(acceptLanguage string) string {
+	tag, _ := language.MatchStrings(matcher, acceptLanguage)
+	base, _ := tag.Base()
+
+	if _, ok := catalogs[base.String()]; !ok {
+		return DefaultLanguage
+	}
+
+	return base.String()
+}
+
+// format replaces every {name} placeholder of text with the value of params[name]
+func format(text string, params map[string]string) string {
+	if len(params) == 0 {
+		return text
+	}
+
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Error returns the message of the error code in lang, with its placeholders replaced by params.
+// It falls back to DefaultLanguage if lang has no text for the code, and returns false if no
+// catalog has it.
+func Error(lang string, code string, params map[string]string) (Message, bool) {
+	message, ok := catalogs[lang].Errors[code]
+	if !ok {
+		message, ok = catalogs[DefaultLanguage].Errors[code]
+	}
+
+	if !ok {
+		return Message{}, false
+	}
+
+	return Message{
+		Title:  format(message.Title, params),
+		Detail: format(message.Detail, params),
+	}, true
+}
+
+// Rule returns the message explaining in lang that a field failed the validation rule,
+// e.g. "must be greater than 0" for the rule gt with param 0
+func Rule(lang string, rule string, param string) string {
+	params := map[string]string{"rule": rule, "param": param}
+
+	for _, l := range []string{lang, DefaultLanguage} {
+		if text, ok := catalogs[l].Rules[rule]; ok {
+			return format(text, params)
+		}
+	}
+
+	return format(catalogs[DefaultLanguage].Rules["default"], params)
+}
+
+// Warning returns the message of the warning code in lang, falling back to DefaultLanguage.
+// It returns the code itself if no catalog has it.
+func Warning(lang string, code string) string {
+	for _, l := range []string{lang, DefaultLanguage} {
+		if text, ok := catalogs[l].Warnings[code]; ok {
+			return text
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,91 @@
+package i18n
+
+import "testing"
+
+func TestCatalogs(t *testing.T) {
+	t.Run("Every catalog has the same error codes and rules as the default one", func(t *testing.T) {
+		base := catalogs[DefaultLanguage]
+
+		for lang, c := range catalogs {
+			for code := range base.Errors {
+				if _, ok := c.Errors[code]; !ok {
+					t.Errorf("catalog '%s' should have the error code '%s'", lang, code)
+				}
+			}
+
+			for rule := range base.Rules {
+				if _, ok := c.Rules[rule]; !ok {
+					t.Errorf("catalog '%s' should have the rule '%s'", lang, rule)
+				}
+			}
+
+			for code := range base.Warnings {
+				if _, ok := c.Warnings[code]; !ok {
+					t.Errorf("catalog '%s' should have the warning '%s'", lang, code)
+				}
+			}
+
+			if len(c.Errors) != len(base.Errors) || len(c.Rules) != len(base.Rules) || len(c.Warnings) != len(base.Warnings) {
+				t.Errorf("catalog '%s' shouldn't have texts missing from '%s'", lang, DefaultLanguage)
+			}
+		}
+	})
+}
+
+func TestMatch(t *testing.T) {
+	cases := map[string]string{
+		"":                          "en",
+		"es":                        "es",
+		"es-AR,es;q=0.9,en;q=0.8":   "es",
+		"en-US,en;q=0.9,es;q=0.8":   "en",
+		"fr-FR,fr;q=0.9":            "en",
+		"fr-FR,fr;q=0.9,es;q=0.5":   "es",
+		"not a language header ;;;": "en",
+	}
+
+	for header, expected := range cases {
+		if result := Match(header); result != expected {
+			t.Errorf("Match('%s') should be '%s', got '%s'", header, expected, result)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	t.Run("The placeholders of the message are replaced by the params", func(t *testing.T) {
+		message, ok := Error("es", "fixed_data.not_found", map[string]string{"userId": "1"})
+
+		if !ok {
+			t.Fatalf("fixed_data.not_found should be in the catalogs")
+		}
+
+		if message.Detail != "No se encontraron datos fijos del usuario 1" {
+			t.Errorf("detail should be 'No se encontraron datos fijos del usuario 1', got '%s'", message.Detail)
+		}
+	})
+
+	t.Run("An unknown code isn't found", func(t *testing.T) {
+		if _, ok := Error("en", "unknown.code", nil); ok {
+			t.Errorf("unknown.code shouldn't be in the catalogs")
+		}
+	})
+}
+
+func TestRule(t *testing.T) {
+	if result := Rule("es", "gt", "0"); result != "debe ser mayor a 0" {
+		t.Errorf("rule should be 'debe ser mayor a 0', got '%s'", result)
+	}
+
+	if result := Rule("en", "uuid", ""); result != "failed on the 'uuid' rule" {
+		t.Errorf("rule should be \"failed on the 'uuid' rule\", got '%s'", result)
+	}
+}
+
+func TestWarning(t *testing.T) {
+	if result := Warning("es", "objective.underweight_target"); result != "El peso objetivo está por debajo de un IMC saludable de 18,5" {
+		t.Errorf("warning should be 'El peso objetivo está por debajo de un IMC saludable de 18,5', got '%s'", result)
+	}
+
+	if result := Warning("es", "unknown.code"); result != "unknown.code" {
+		t.Errorf("an unknown warning should be its code, got '%s'", result)
+	}
+}
@@ -0,0 +1,50 @@
+// Package jobs defines the kinds of background jobs the service runs, their schedules and how
+// failed runs are retried. Jobs are persisted and run by the job service, see service.JobService.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Handler runs a job with its payload, the JSON it was enqueued with. A returned error makes
+// the run fail and the job be retried.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+var (
+	mu       sync.RWMutex
+	handlers = make(map[string]Handler)
+)
+
+// Register sets the handler of the jobs named name, replacing the previous one
+func Register(name string, handler Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	handlers[name] = handler
+}
+
+// Lookup returns the handler of the jobs named name
+func Lookup(name string) (Handler, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	handler, ok := handlers[name]
+	return handler, ok
+}
+
+// maxBackoff caps the delay between the retries of a job
+const maxBackoff = time.Hour
+
+// Backoff returns the delay before retrying a job that failed attempt times in a row, base
+// doubled after every attempt
+func Backoff(attempt uint, base time.Duration) time.Duration {
+	delay := base
+	for i := uint(1); i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxBackoff)
+}
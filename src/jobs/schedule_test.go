@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2026, time.March, 14, 10, 17, 30, 0, time.UTC)
+
+	cases := map[string]time.Time{
+		"@every 5m":       from.Add(5 * time.Minute),
+		"*/15 * * * *":    time.Date(2026, time.March, 14, 10, 30, 0, 0, time.UTC),
+		"0 9 * * *":       time.Date(2026, time.March, 15, 9, 0, 0, 0, time.UTC),
+		"@hourly":         time.Date(2026, time.March, 14, 11, 0, 0, 0, time.UTC),
+		"@monthly":        time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+		"30 8 * * 1-5":    time.Date(2026, time.March, 16, 8, 30, 0, 0, time.UTC),
+		"0 0 1,15 * *":    time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC),
+		"0 12 31 * *":     time.Date(2026, time.March, 31, 12, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":      time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"0 0 20 * 0":      time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC),
+		"17 10 14 3 *":    time.Date(2027, time.March, 14, 10, 17, 0, 0, time.UTC),
+		"0-10/5 11 * * *": time.Date(2026, time.March, 14, 11, 0, 0, 0, time.UTC),
+	}
+
+	for expr, expected := range cases {
+		schedule, err := ParseSchedule(expr)
+		if err != nil {
+			t.Errorf("'%s' should be valid, got %v", expr, err)
+			continue
+		}
+
+		if next := schedule.Next(from); !next.Equal(expected) {
+			t.Errorf("'%s' should run next at %s, got %s", expr, expected, next)
+		}
+	}
+}
+
+func TestParseInvalidSchedule(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@every 1ms", "@every soon", "@yearly"} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("'%s' should be invalid", expr)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[uint]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		4:  4 * time.Minute,
+		20: time.Hour,
+	}
+
+	for attempt, expected := range cases {
+		if delay := Backoff(attempt, 30*time.Second); delay != expected {
+			t.Errorf("attempt %d should wait %s, got %s", attempt, expected, delay)
+		}
+	}
+}
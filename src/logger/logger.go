@@ -0,0 +1,86 @@
+// Package logger configures the structured JSON logger of the service. Records carry the
+// request ID, user ID and route of the request in their context, and the attributes holding
+// credentials or health data are redacted.
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// levels maps the accepted LOG_LEVEL values to slog levels
+var levels = map[string]slog.Level{
+	"DEBUG":   slog.LevelDebug,
+	"INFO":    slog.LevelInfo,
+	"NOTICE":  slog.LevelInfo,
+	"WARN":    slog.LevelWarn,
+	"WARNING": slog.LevelWarn,
+	"ERROR":   slog.LevelError,
+}
+
+// Init sets a JSON logger writing to stdout as the default slog logger.
+// logLevel is one of DEBUG, INFO, WARNING or ERROR (case insensitive).
+// It returns an error if the level isn't valid, leaving the default logger untouched.
+func Init(logLevel string) error {
+	level, ok := levels[strings.ToUpper(logLevel)]
+	if !ok {
+		return fmt.Errorf("invalid log level %q", logLevel)
+	}
+
+	slog.SetDefault(New(os.Stdout, level))
+	slog.Info("Log level set", "level", level.String())
+
+	return nil
+}
+
+// New returns a JSON logger writing to w records of at least the given level
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})
+
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// contextHandler adds the fields of the request and the trace ID stored in the context to every
+// record. Attributes explicitly set on the record take precedence, so keys are never duplicated.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	present := make(map[string]bool, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		present[attr.Key] = true
+		return true
+	})
+
+	if fields := requestFieldsFrom(ctx); fields != nil {
+		for _, attr := range fields.attrs() {
+			if !present[attr.Key] {
+				record.AddAttrs(attr)
+			}
+		}
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() && !present["trace_id"] {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func logRecord(t *testing.T, ctx context.Context, msg string, args ...any) map[string]any {
+	var buffer bytes.Buffer
+	New(&buffer, slog.LevelDebug).InfoContext(ctx, msg, args...)
+
+	var record map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("The record should be valid JSON: %v", err)
+	}
+
+	return record
+}
+
+func TestRedaction(t *testing.T) {
+	t.Run("Credentials should be redacted", func(t *testing.T) {
+		record := logRecord(t, context.Background(), "Connecting", "password", "hunter2", "Authorization", "Bearer abc")
+
+		if record["password"] != redactedValue || record["Authorization"] != redactedValue {
+			t.Errorf("Credentials should be redacted, got %v", record)
+		}
+	})
+
+	t.Run("Health data should be redacted", func(t *testing.T) {
+		record := logRecord(t, context.Background(), "Received data", "weight", 70.5, "fat_mass", 12.1, "birthday", "1990-01-01")
+
+		for _, key := range []string{"weight", "fat_mass", "birthday"} {
+			if record[key] != redactedValue {
+				t.Errorf("%s should be redacted, got %v", key, record[key])
+			}
+		}
+	})
+
+	t.Run("Other attributes should be kept", func(t *testing.T) {
+		record := logRecord(t, context.Background(), "Created exercise", "exercise_id", 12)
+
+		if record["exercise_id"] != float64(12) {
+			t.Errorf("exercise_id should be kept, got %v", record["exercise_id"])
+		}
+	})
+}
+
+func TestRequestFields(t *testing.T) {
+	t.Run("Records should carry the request fields of the context", func(t *testing.T) {
+		ctx := WithRequest(context.Background(), "request-1", "/users/:userId/exercises/")
+		SetUserID(ctx, "user-1")
+
+		record := logRecord(t, ctx, "Handling request")
+
+		if record["request_id"] != "request-1" || record["route"] != "/users/:userId/exercises/" || record["user_id"] != "user-1" {
+			t.Errorf("The record should carry the request fields, got %v", record)
+		}
+	})
+
+	t.Run("Explicit attributes should take precedence over the request fields", func(t *testing.T) {
+		ctx := WithRequest(context.Background(), "request-1", "/")
+		SetUserID(ctx, "user-1")
+
+		var buffer bytes.Buffer
+		New(&buffer, slog.LevelDebug).InfoContext(ctx, "Evaluating", "user_id", "user-2")
+
+		if bytes.Count(buffer.Bytes(), []byte(`"user_id"`)) != 1 {
+			t.Errorf("user_id should appear once, got %s", buffer.String())
+		}
+
+		var record map[string]any
+		json.Unmarshal(buffer.Bytes(), &record)
+		if record["user_id"] != "user-2" {
+			t.Errorf("user_id should be user-2, got %v", record["user_id"])
+		}
+	})
+
+	t.Run("Records without a request shouldn't carry request fields", func(t *testing.T) {
+		record := logRecord(t, context.Background(), "Starting")
+
+		if _, ok := record["request_id"]; ok {
+			t.Errorf("The record shouldn't have a request_id, got %v", record)
+		}
+	})
+}
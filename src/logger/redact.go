@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// fieldPolicy tells what kind of sensitive data an attribute holds
+type fieldPolicy int
+
+const (
+	// credential attributes grant access to the service or its dependencies
+	credential fieldPolicy = iota + 1
+	// healthData attributes are personal health information of the users
+	healthData
+)
+
+// redactedValue replaces the value of every attribute with a redaction policy
+const redactedValue = "[REDACTED]"
+
+// fieldPolicies are the attribute keys that must never reach the logs in clear text.
+// Keys are matched case insensitively and regardless of the group they belong to.
+var fieldPolicies = map[string]fieldPolicy{
+	"authorization": credential,
+	"dsn":           credential,
+	"password":      credential,
+	"secret":        credential,
+	"token":         credential,
+
+	"age":            healthData,
+	"birthday":       healthData,
+	"bone_mass":      healthData,
+	"circumferences": healthData,
+	"fat_mass":       healthData,
+	"height":         healthData,
+	"indicators":     healthData,
+	"muscle_mass":    healthData,
+	"sex":            healthData,
+	"skinfolds":      healthData,
+	"weight":         healthData,
+}
+
+// redact is the slog ReplaceAttr function that hides the values of the attributes with a policy
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if _, ok := fieldPolicies[strings.ToLower(attr.Key)]; ok {
+		return slog.String(attr.Key, redactedValue)
+	}
+
+	return attr
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type requestFieldsKey struct{}
+
+// requestFields are the fields of a request added to every record logged with its context.
+// The user ID is only known once the request is authenticated, so the fields are shared by
+// pointer and completed along the middleware chain.
+type requestFields struct {
+	mu        sync.RWMutex
+	requestID string
+	userID    string
+	route     string
+}
+
+func (f *requestFields) attrs() []slog.Attr {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	attrs := []slog.Attr{slog.String("request_id", f.requestID)}
+
+	if f.route != "" {
+		attrs = append(attrs, slog.String("route", f.route))
+	}
+
+	if f.userID != "" {
+		attrs = append(attrs, slog.String("user_id", f.userID))
+	}
+
+	return attrs
+}
+
+func requestFieldsFrom(ctx context.Context) *requestFields {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(requestFieldsKey{}).(*requestFields)
+	return fields
+}
+
+// WithRequest returns a copy of ctx whose records carry the given request ID and route
+func WithRequest(ctx context.Context, requestID string, route string) context.Context {
+	return context.WithValue(ctx, requestFieldsKey{}, &requestFields{
+		requestID: requestID,
+		route:     route,
+	})
+}
+
+// SetUserID adds the ID of the authenticated user to the records logged with ctx.
+// It does nothing if ctx wasn't created with WithRequest.
+func SetUserID(ctx context.Context, userID string) {
+	fields := requestFieldsFrom(ctx)
+	if fields == nil {
+		return
+	}
+
+	fields.mu.Lock()
+	defer fields.mu.Unlock()
+
+	fields.userID = userID
+}
+
+// RequestID returns the ID of the request of ctx, or an empty string if there isn't one
+func RequestID(ctx context.Context) string {
+	fields := requestFieldsFrom(ctx)
+	if fields == nil {
+		return ""
+	}
+
+	fields.mu.RLock()
+	defer fields.mu.RUnlock()
+
+	return fields.requestID
+}
@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	// The runtime image has no time zone database, users' time zones are resolved with this copy
+	_ "time/tzdata"
 
 	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/logger"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/NutriPocket/ProgressService/tracing"
 	"github.com/NutriPocket/ProgressService/utils"
 	"github.com/joho/godotenv"
-	"github.com/op/go-logging"
 )
 
-var log = logging.MustGetLogger("log")
-
 func loadEnv() {
 	envPath := os.Getenv("ENV_PATH")
 	if envPath == "" {
@@ -19,31 +29,64 @@ func loadEnv() {
 
 	err := godotenv.Load(envPath)
 	if err != nil {
-		log.Fatalf("Error loading .env file")
+		slog.Error("Error loading .env file", "path", envPath, "error", err)
+		os.Exit(1)
 	}
 }
 
-// InitLogger Receives the log level to be set in go-logging as a string. This method
-// parses the string and set the level to the logger. If the level string is not
-// valid an error is returned
-func InitLogger(logLevel string) error {
-	baseBackend := logging.NewLogBackend(os.Stdout, "", 0)
-	format := logging.MustStringFormatter(
-		`%{time:2006-01-02 15:04:05} %{level:.5s}     %{message}`,
-	)
-	backendFormatter := logging.NewBackendFormatter(baseBackend, format)
-
-	backendLeveled := logging.AddModuleLevel(backendFormatter)
-	logLevelCode, err := logging.LogLevel(logLevel)
+// workers are the background workers that run alongside the HTTP server. Each one must
+// return once its context is cancelled, which happens after the server stopped serving requests.
+var workers = []func(ctx context.Context){
+	runJobs,
+	runOutboxRelay,
+}
+
+// getDurationEnv parses the environment variable key as a time.Duration (e.g. "15s").
+// It returns def if the variable isn't set or can't be parsed.
+func getDurationEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := time.ParseDuration(value)
 	if err != nil {
-		return err
+		slog.Warn("Invalid duration, using the default", "key", key, "value", value, "default", def.String())
+		return def
+	}
+
+	return parsed
+}
+
+// shutdownDeadline is cancelled once the shutdown deadline passes. The work the background
+// workers let finish after they're stopped is cancelled then, before the database pool is closed.
+var shutdownDeadline, passShutdownDeadline = context.WithCancel(context.Background())
+
+// untilShutdownDeadline returns a context with the values of ctx that isn't cancelled along
+// with it, only once the shutdown deadline passes. release must be called when it's no longer used.
+func untilShutdownDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(shutdownDeadline, cancel)
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// startWorkers starts every background worker and returns a WaitGroup that is done once all of them returned.
+func startWorkers(ctx context.Context) *sync.WaitGroup {
+	var wg sync.WaitGroup
+
+	for _, worker := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker(ctx)
+		}()
 	}
-	backendLeveled.SetLevel(logLevelCode, "")
 
-	// Set the backends to be used.
-	logging.SetBackend(backendLeveled)
-	log.Infof("Log level set to %s", logLevel)
-	return nil
+	return &wg
 }
 
 func main() {
@@ -54,10 +97,18 @@ func main() {
 		logLevel = "DEBUG"
 	}
 
-	InitLogger(logLevel)
+	if err := logger.Init(logLevel); err != nil {
+		logger.Init("INFO")
+		slog.Warn("Invalid LOG_LEVEL, using INFO", "error", err)
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		slog.Error("Failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
 
 	database.ConnectDB()
-	defer database.Close()
 
 	router := utils.SetupRouter()
 
@@ -71,8 +122,84 @@ func main() {
 		port = "8082"
 	}
 
-	addr := host + ":" + port
+	server := &http.Server{
+		Addr:         host + ":" + port,
+		Handler:      router,
+		ReadTimeout:  getDurationEnv("SERVER_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout: getDurationEnv("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:  getDurationEnv("SERVER_IDLE_TIMEOUT", 60*time.Second),
+	}
+
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	runningWorkers := startWorkers(workersCtx)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Starting server", "address", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case <-signalCtx.Done():
+		slog.Info("Received termination signal, shutting down")
+	case err := <-serverErr:
+		slog.Error("Server stopped unexpectedly", "error", err)
+	}
+	stop()
+
+	shutdown(server, stopWorkers, runningWorkers, shutdownTracing)
+}
+
+// shutdown gracefully stops the service. It flips readiness to failing, stops accepting
+// connections and waits for in-flight requests, then stops the background workers and
+// finally flushes the pending spans and closes the database pool. Everything must happen
+// before SHUTDOWN_TIMEOUT, the work of the workers still running then is cancelled.
+func shutdown(
+	server *http.Server,
+	stopWorkers context.CancelFunc,
+	runningWorkers *sync.WaitGroup,
+	shutdownTracing func(context.Context) error,
+) {
+	service.MarkShuttingDown()
+
+	// Give load balancers time to notice the failing readiness probe before closing the listener
+	time.Sleep(getDurationEnv("SHUTDOWN_READINESS_DELAY", 0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), getDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second))
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Failed to drain in-flight requests, closing remaining connections", "error", err)
+		server.Close()
+	} else {
+		slog.Info("Drained in-flight requests")
+	}
+
+	stopWorkers()
+
+	workersDone := make(chan struct{})
+	go func() {
+		runningWorkers.Wait()
+		close(workersDone)
+	}()
+
+	select {
+	case <-workersDone:
+		slog.Info("Stopped background workers")
+	case <-ctx.Done():
+		slog.Error("Background workers didn't stop before the shutdown deadline, cancelling their work")
+		passShutdownDeadline()
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush pending spans", "error", err)
+	}
 
-	log.Infof("Starting server on %s", addr)
-	router.Run(addr)
+	database.Close()
 }
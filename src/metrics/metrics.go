@@ -0,0 +1,113 @@
+// Package metrics defines the Prometheus collectors exposed by the service in /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "progress"
+
+var (
+	// HTTPRequests counts the handled HTTP requests by method, route and status code
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes the latency of the HTTP requests by method, route and status code
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the handled HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DBQueryDuration observes the latency of every repository method
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of the repository methods querying the database.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"repository", "method"})
+
+	// MeasurementsLogged counts the anthropometric measurements stored by the users
+	MeasurementsLogged = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "measurements_logged_total",
+		Help:      "Number of anthropometric measurements logged.",
+	})
+
+	// ExercisesCreated counts the exercises registered by the users
+	ExercisesCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "exercises_created_total",
+		Help:      "Number of exercises created.",
+	})
+
+	// RoutineConflicts counts the routines rejected because they overlap with an existing one
+	RoutineConflicts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "routine_conflicts_total",
+		Help:      "Number of routines rejected because of a schedule conflict.",
+	})
+
+	// ObjectiveAchievements counts the objectives reached by the users
+	ObjectiveAchievements = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "objective_achievements_total",
+		Help:      "Number of objectives achieved.",
+	})
+
+	// RemindersSent counts the reminders delivered to the users by code
+	RemindersSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reminders_sent_total",
+		Help:      "Number of reminders delivered.",
+	}, []string{"code"})
+
+	// WebhookDeliveries counts the attempts to deliver an event to a webhook by event type and outcome
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Number of attempts to deliver an event to a webhook.",
+	}, []string{"event_type", "status"})
+
+	// OutboxEventsPublished counts the events relayed from the outbox to the publishers by type
+	OutboxEventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_events_published_total",
+		Help:      "Number of events relayed from the outbox.",
+	}, []string{"event_type"})
+
+	// JobRuns counts the runs of the background jobs by name and outcome
+	JobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Number of runs of the background jobs.",
+	}, []string{"name", "status"})
+
+	// JobRunDuration observes the latency of the runs of the background jobs by name
+	JobRunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_run_duration_seconds",
+		Help:      "Latency of the runs of the background jobs.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"name"})
+)
+
+// ObserveQuery records the latency of a repository method that started at start.
+func ObserveQuery(repository string, method string, start time.Time) {
+	DBQueryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
+
+// RegisterDBStats exports the connection pool statistics of db (open, in use and idle
+// connections, wait count and duration...) labeled with dbName.
+func RegisterDBStats(db *sql.DB, dbName string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, dbName))
+}
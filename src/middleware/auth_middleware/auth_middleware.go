@@ -4,11 +4,29 @@ package middleware
 import (
 	"strings"
 
+	"github.com/NutriPocket/ProgressService/logger"
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/NutriPocket/ProgressService/service"
+	"github.com/NutriPocket/ProgressService/tracing"
 	"github.com/gin-gonic/gin"
 )
 
+// publicRoots are the root paths that can be accessed without authorization.
+// Health probes and metrics scrapes are issued by load balancers, orchestrators and Prometheus,
+// which don't carry user tokens.
+var publicRoots = map[string]bool{
+	"auth":    true,
+	"healthz": true,
+	"readyz":  true,
+	"metrics": true,
+}
+
+// isPublicPath returns true if the URL path can be accessed without authorization
+// @param urlPath string - The URL path
+func isPublicPath(urlPath string) bool {
+	return publicRoots[getRootPath(urlPath)]
+}
+
 // getRootPath returns the root path of a URL
 // @param urlPath string - The URL path
 func getRootPath(urlPath string) string {
@@ -32,8 +50,7 @@ func getRootPath(urlPath string) string {
 func getToken(authHeader string) (token string, err error) {
 	if authHeader == "" {
 		return "", &model.AuthenticationError{
-			Title:  "Unauthorized user",
-			Detail: "The user isn't authorized because no Authorization header is provided",
+			Code: "auth.missing_header",
 		}
 	}
 
@@ -41,8 +58,7 @@ func getToken(authHeader string) (token string, err error) {
 
 	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
 		return "", &model.AuthenticationError{
-			Title:  "Invalid authorization",
-			Detail: "The Authorization header provided has an unknown format... Try: Bearer <token>",
+			Code: "auth.invalid_header",
 		}
 	}
 
@@ -52,12 +68,12 @@ func getToken(authHeader string) (token string, err error) {
 }
 
 // AuthMiddleware is a middleware that checks if the user is authorized to access the endpoint
-// Only the endpoints that start with /auth are allowed to be accessed without authorization
+// Only the endpoints under publicRoots (/auth, the health probes and /metrics) are allowed to be accessed without authorization
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		urlPath := c.Request.URL.Path
 
-		if getRootPath(urlPath) == "auth" {
+		if isPublicPath(urlPath) {
 			c.Next()
 
 			return
@@ -79,7 +95,9 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		_, span := tracing.StartSpan(c.Request.Context(), "auth.DecodeJWT")
 		decoded, err := jwtService.Decode(token)
+		span.End()
 
 		if err != nil {
 			c.Error(err)
@@ -89,6 +107,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		c.Set("authUser", &decoded.Payload)
+		logger.SetUserID(c.Request.Context(), decoded.Payload.ID)
 
 		c.Next()
 	}
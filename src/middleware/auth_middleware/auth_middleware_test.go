@@ -72,8 +72,7 @@ func TestGetToken(t *testing.T) {
 	t.Run("It should return an authentication error if the authHeader is an empty string", func(t *testing.T) {
 		authHeader := ""
 		expected := &model.AuthenticationError{
-			Title:  "Unauthorized user",
-			Detail: "The user isn't authorized because no Authorization header is provided",
+			Code: "auth.missing_header",
 		}
 
 		token, err := getToken(authHeader)
@@ -90,8 +89,7 @@ func TestGetToken(t *testing.T) {
 	t.Run("It should return an authentication error if the authHeader has an invalid format (no spaces)", func(t *testing.T) {
 		authHeader := "Bearerthis-is-a-token"
 		expected := &model.AuthenticationError{
-			Title:  "Invalid authorization",
-			Detail: "The Authorization header provided has an unknown format... Try: Bearer <token>",
+			Code: "auth.invalid_header",
 		}
 
 		token, err := getToken(authHeader)
@@ -108,8 +106,7 @@ func TestGetToken(t *testing.T) {
 	t.Run("It should return an authentication error if the authHeader has an invalid format (more than one space)", func(t *testing.T) {
 		authHeader := "Bearer  this-is-a-token"
 		expected := &model.AuthenticationError{
-			Title:  "Invalid authorization",
-			Detail: "The Authorization header provided has an unknown format... Try: Bearer <token>",
+			Code: "auth.invalid_header",
 		}
 
 		token, err := getToken(authHeader)
@@ -126,8 +123,7 @@ func TestGetToken(t *testing.T) {
 	t.Run("It should return an authentication error if the authHeader has an invalid format (missing Bearer)", func(t *testing.T) {
 		authHeader := " this-is-a-token"
 		expected := &model.AuthenticationError{
-			Title:  "Invalid authorization",
-			Detail: "The Authorization header provided has an unknown format... Try: Bearer <token>",
+			Code: "auth.invalid_header",
 		}
 
 		token, err := getToken(authHeader)
@@ -144,8 +140,7 @@ func TestGetToken(t *testing.T) {
 	t.Run("It should return an authentication error if the authHeader has an invalid format (Bearer typo)", func(t *testing.T) {
 		authHeader := "Biarer this-is-a-token"
 		expected := &model.AuthenticationError{
-			Title:  "Invalid authorization",
-			Detail: "The Authorization header provided has an unknown format... Try: Bearer <token>",
+			Code: "auth.invalid_header",
 		}
 
 		token, err := getToken(authHeader)
@@ -159,3 +154,45 @@ func TestGetToken(t *testing.T) {
 		}
 	})
 }
+
+func TestIsPublicPath(t *testing.T) {
+	t.Run("Health probes should be public", func(t *testing.T) {
+		for _, url := range []string{"/healthz", "/readyz"} {
+			if !isPublicPath(url) {
+				t.Errorf("%s should be a public path", url)
+			}
+		}
+	})
+
+	t.Run("Metrics should be public", func(t *testing.T) {
+		url := "/metrics"
+
+		if !isPublicPath(url) {
+			t.Errorf("%s should be a public path", url)
+		}
+	})
+
+	t.Run("Auth endpoints should be public", func(t *testing.T) {
+		url := "/auth/login"
+
+		if !isPublicPath(url) {
+			t.Errorf("%s should be a public path", url)
+		}
+	})
+
+	t.Run("User endpoints should require authorization", func(t *testing.T) {
+		url := "/users/testUser/anthropometrics/"
+
+		if isPublicPath(url) {
+			t.Errorf("%s shouldn't be a public path", url)
+		}
+	})
+
+	t.Run("Paths that only start like a public root should require authorization", func(t *testing.T) {
+		url := "/healthzz"
+
+		if isPublicPath(url) {
+			t.Errorf("%s shouldn't be a public path", url)
+		}
+	})
+}
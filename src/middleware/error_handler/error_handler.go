@@ -2,56 +2,121 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 
+	"github.com/NutriPocket/ProgressService/i18n"
+	"github.com/NutriPocket/ProgressService/logger"
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/gin-gonic/gin"
-	"github.com/op/go-logging"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var log = logging.MustGetLogger("log")
-
 // parseError parses an error and returns an error in the RFC 9457 format
 // err is the error to parse
 // urlPath is the URL path of the request
+// lang is the language of the title, detail and field messages, one of the i18n catalogs
 // It returns an error in the RFC 9457 format
-func parseError(err error, urlPath string) model.ErrorRfc9457 {
+func parseError(err error, urlPath string, lang string) model.ErrorRfc9457 {
 	var status int
+	var problemType string
+	var code string
+	var params map[string]string
 	var detail string
 	var title string
+	var fields []model.FieldError
 
 	switch e := err.(type) {
 	case *model.ValidationError:
 		status = http.StatusBadRequest
-		detail = e.Detail
-		title = e.Title
+		problemType = model.ProblemTypeValidation
+		code, params, title, detail = e.Code, e.Params, e.Title, e.Detail
+		fields = localizeFields(e.Errors, lang)
 	case *model.AuthenticationError:
 		status = http.StatusUnauthorized
-		detail = e.Detail
-		title = e.Title
+		problemType = model.ProblemTypeAuthentication
+		code, params, title, detail = e.Code, e.Params, e.Title, e.Detail
+	case *model.ForbiddenError:
+		status = http.StatusForbidden
+		problemType = model.ProblemTypeForbidden
+		code, params, title, detail = e.Code, e.Params, e.Title, e.Detail
 	case *model.NotFoundError:
 		status = http.StatusNotFound
-		detail = e.Detail
-		title = e.Title
+		problemType = model.ProblemTypeNotFound
+		code, params, title, detail = e.Code, e.Params, e.Title, e.Detail
 	case *model.ConflictError:
 		status = http.StatusConflict
-		detail = e.Detail
-		title = e.Title
+		problemType = model.ProblemTypeConflict
+		code, params, title, detail = e.Code, e.Params, e.Title, e.Detail
+	case *model.UnprocessableError:
+		status = http.StatusUnprocessableEntity
+		problemType = model.ProblemTypeUnprocessable
+		code, params, title, detail = e.Code, e.Params, e.Title, e.Detail
+	case *model.PreconditionFailedError:
+		status = http.StatusPreconditionFailed
+		problemType = model.ProblemTypePrecondition
+		code, params, title, detail = e.Code, e.Params, e.Title, e.Detail
 	default:
-		status = http.StatusInternalServerError
-		detail = "An unknown error has occurred"
-		title = "Internal Server Error"
+		status, problemType, code = parseUnknownError(err)
+	}
+
+	if message, ok := i18n.Error(lang, code, params); ok {
+		title = message.Title
+		detail = message.Detail
 	}
 
 	return model.ErrorRfc9457{
-		Type:     "about:blank",
+		Type:     problemType,
+		Code:     code,
 		Title:    title,
 		Status:   status,
 		Detail:   detail,
 		Instance: urlPath,
+		Errors:   fields,
+	}
+}
+
+// localizeFields returns a copy of the invalid fields with their messages in lang
+func localizeFields(fields []model.FieldError, lang string) []model.FieldError {
+	if fields == nil {
+		return nil
+	}
+
+	localized := make([]model.FieldError, len(fields))
+	for i, field := range fields {
+		field.Message = i18n.Rule(lang, field.Rule, field.Param)
+		localized[i] = field
+	}
+
+	return localized
+}
+
+// parseUnknownError returns the status, problem type and code of an error that isn't one of the
+// model errors. Errors caused by the request deadline being exceeded or by the client going away
+// are reported as 504 and 503 respectively, anything else is an internal server error.
+func parseUnknownError(err error) (status int, problemType string, code string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, model.ProblemTypeTimeout, "request.timeout"
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable, model.ProblemTypeCancelled, "request.cancelled"
+	default:
+		return http.StatusInternalServerError, model.ProblemTypeInternal, "internal"
 	}
 }
 
+// traceID returns the ID that correlates a request with its traces and logs: the trace ID if the
+// request is traced, or its request ID otherwise
+func traceID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		return spanContext.TraceID().String()
+	}
+
+	return logger.RequestID(ctx)
+}
+
 // ErrorHandler is a middleware that handles errors and returns them in the RFC 9457 format
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -60,9 +125,20 @@ func ErrorHandler() gin.HandlerFunc {
 		err := c.Errors.Last()
 
 		if err != nil {
-			rfcError := parseError(err.Err, c.Request.URL.String())
-			log.Errorf("Error: %s", rfcError)
+			lang := i18n.Match(c.GetHeader("Accept-Language"))
+			rfcError := parseError(err.Err, c.Request.URL.String(), lang)
+			rfcError.TraceID = traceID(c.Request.Context())
+
+			level := slog.LevelWarn
+			if rfcError.Status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			slog.Log(c.Request.Context(), level, "Request failed",
+				"status", rfcError.Status, "code", rfcError.Code, "error", err.Err)
 
+			c.Header("Content-Type", model.ProblemContentType)
+			c.Header("Content-Language", lang)
+			c.Header("Vary", "Accept-Language")
 			c.JSON(rfcError.Status, rfcError)
 
 			c.Abort()
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
 	"testing"
@@ -17,13 +19,56 @@ func TestParseError(t *testing.T) {
 			Title:    "Internal Server Error",
 			Detail:   "An unknown error has occurred",
 			Status:   http.StatusInternalServerError,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeInternal,
+			Code:     "internal",
 			Instance: "/",
 		}
 
 		err := errors.New("Unknown error :)")
 
-		result := parseError(err, urlPath)
+		result := parseError(err, urlPath, "en")
+
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("The parsed error isn't equal to the expected one")
+		}
+	})
+
+	t.Run("An exceeded deadline is parsed as a gateway timeout with status code 504", func(t *testing.T) {
+		urlPath := "/"
+
+		expected := model.ErrorRfc9457{
+			Title:    "Request timeout",
+			Detail:   "The request couldn't be completed before its deadline",
+			Status:   http.StatusGatewayTimeout,
+			Type:     model.ProblemTypeTimeout,
+			Code:     "request.timeout",
+			Instance: "/",
+		}
+
+		err := fmt.Errorf("query failed: %w", context.DeadlineExceeded)
+
+		result := parseError(err, urlPath, "en")
+
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("The parsed error isn't equal to the expected one")
+		}
+	})
+
+	t.Run("A cancelled request is parsed as service unavailable with status code 503", func(t *testing.T) {
+		urlPath := "/"
+
+		expected := model.ErrorRfc9457{
+			Title:    "Request cancelled",
+			Detail:   "The request was cancelled before it could be completed",
+			Status:   http.StatusServiceUnavailable,
+			Type:     model.ProblemTypeCancelled,
+			Code:     "request.cancelled",
+			Instance: "/",
+		}
+
+		err := context.Canceled
+
+		result := parseError(err, urlPath, "en")
 
 		if !reflect.DeepEqual(expected, result) {
 			t.Errorf("The parsed error isn't equal to the expected one")
@@ -40,7 +85,7 @@ func TestParseError(t *testing.T) {
 			Title:    title,
 			Detail:   detail,
 			Status:   http.StatusBadRequest,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeValidation,
 			Instance: "/",
 		}
 
@@ -49,7 +94,60 @@ func TestParseError(t *testing.T) {
 			Detail: detail,
 		}
 
-		result := parseError(err, urlPath)
+		result := parseError(err, urlPath, "en")
+
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("The parsed error isn't equal to the expected one")
+		}
+	})
+
+	t.Run("The invalid fields of a validation error are kept", func(t *testing.T) {
+		urlPath := "/"
+
+		fields := []model.FieldError{
+			{Pointer: "/weight", Rule: "required", Message: "is required"},
+		}
+
+		expected := model.ErrorRfc9457{
+			Title:    "Invalid anthropometric user data",
+			Detail:   "One or more fields of the request body are invalid",
+			Status:   http.StatusBadRequest,
+			Type:     model.ProblemTypeValidation,
+			Instance: "/",
+			Errors:   fields,
+		}
+
+		err := &model.ValidationError{
+			Title:  "Invalid anthropometric user data",
+			Detail: "One or more fields of the request body are invalid",
+			Errors: fields,
+		}
+
+		result := parseError(err, urlPath, "en")
+
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("The parsed error isn't equal to the expected one")
+		}
+	})
+
+	t.Run("An error with a code is parsed with the texts of the requested language", func(t *testing.T) {
+		urlPath := "/users/1/fixedData/"
+
+		expected := model.ErrorRfc9457{
+			Title:    "Datos fijos no encontrados",
+			Detail:   "No se encontraron datos fijos del usuario 1",
+			Status:   http.StatusNotFound,
+			Type:     model.ProblemTypeNotFound,
+			Code:     "fixed_data.not_found",
+			Instance: urlPath,
+		}
+
+		err := &model.NotFoundError{
+			Code:   "fixed_data.not_found",
+			Params: map[string]string{"userId": "1"},
+		}
+
+		result := parseError(err, urlPath, "es")
 
 		if !reflect.DeepEqual(expected, result) {
 			t.Errorf("The parsed error isn't equal to the expected one")
@@ -66,7 +164,7 @@ func TestParseError(t *testing.T) {
 			Title:    title,
 			Detail:   detail,
 			Status:   http.StatusUnauthorized,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeAuthentication,
 			Instance: "/",
 		}
 
@@ -75,7 +173,7 @@ func TestParseError(t *testing.T) {
 			Detail: detail,
 		}
 
-		result := parseError(err, urlPath)
+		result := parseError(err, urlPath, "en")
 
 		if !reflect.DeepEqual(expected, result) {
 			t.Errorf("The parsed error isn't equal to the expected one")
@@ -92,7 +190,7 @@ func TestParseError(t *testing.T) {
 			Title:    title,
 			Detail:   detail,
 			Status:   http.StatusNotFound,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeNotFound,
 			Instance: "/",
 		}
 
@@ -101,7 +199,7 @@ func TestParseError(t *testing.T) {
 			Detail: detail,
 		}
 
-		result := parseError(err, urlPath)
+		result := parseError(err, urlPath, "en")
 
 		if !reflect.DeepEqual(expected, result) {
 			t.Errorf("The parsed error isn't equal to the expected one")
@@ -118,7 +216,7 @@ func TestParseError(t *testing.T) {
 			Title:    title,
 			Detail:   detail,
 			Status:   http.StatusConflict,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeConflict,
 			Instance: "/",
 		}
 
@@ -127,10 +225,79 @@ func TestParseError(t *testing.T) {
 			Detail: detail,
 		}
 
-		result := parseError(err, urlPath)
+		result := parseError(err, urlPath, "en")
 
 		if !reflect.DeepEqual(expected, result) {
 			t.Errorf("The parsed error isn't equal to the expected one")
 		}
 	})
+
+	t.Run("A forbidden error is parsed with status code 403", func(t *testing.T) {
+		urlPath := "/admin/jobs/"
+
+		expected := model.ErrorRfc9457{
+			Title:    "Forbidden",
+			Detail:   "The user isn't an administrator of the service",
+			Code:     "auth.not_admin",
+			Status:   http.StatusForbidden,
+			Type:     model.ProblemTypeForbidden,
+			Instance: urlPath,
+		}
+
+		err := &model.ForbiddenError{
+			Code: "auth.not_admin",
+		}
+
+		result := parseError(err, urlPath, "en")
+
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("The parsed error isn't equal to the expected one: %+v", result)
+		}
+	})
+
+	t.Run("An unprocessable request error is parsed with status code 422", func(t *testing.T) {
+		urlPath := "/users/1/exercises/"
+
+		expected := model.ErrorRfc9457{
+			Title:    "Idempotency key reused",
+			Detail:   "The idempotency key was already used with a different request",
+			Code:     "idempotency.key_reused",
+			Status:   http.StatusUnprocessableEntity,
+			Type:     model.ProblemTypeUnprocessable,
+			Instance: urlPath,
+		}
+
+		err := &model.UnprocessableError{
+			Code: "idempotency.key_reused",
+		}
+
+		result := parseError(err, urlPath, "en")
+
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("The parsed error isn't equal to the expected one: %+v", result)
+		}
+	})
+
+	t.Run("A precondition failed error is parsed with status code 412", func(t *testing.T) {
+		urlPath := "/users/1/exercises/1"
+
+		expected := model.ErrorRfc9457{
+			Title:    "Precondition failed",
+			Detail:   "The resource changed since it was read, get it again and retry with its new ETag",
+			Code:     "request.precondition_failed",
+			Status:   http.StatusPreconditionFailed,
+			Type:     model.ProblemTypePrecondition,
+			Instance: urlPath,
+		}
+
+		err := &model.PreconditionFailedError{
+			Code: "request.precondition_failed",
+		}
+
+		result := parseError(err, urlPath, "en")
+
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("The parsed error isn't equal to the expected one: %+v", result)
+		}
+	})
 }
@@ -0,0 +1,142 @@
+// Package middleware provides custom middlewares for the API
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Headers of the idempotent requests
+const (
+	// KeyHeader is the header with the key of the request, chosen by the client
+	KeyHeader = "Idempotency-Key"
+	// ReplayedHeader is set to true in the responses replayed from a previous request
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxKeyLength is the length of the longest key accepted
+const maxKeyLength = 255
+
+// fingerprint returns the hex SHA-256 of the method, path and body of a request
+func fingerprint(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// recorder is a gin.ResponseWriter that keeps a copy of the body written
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *recorder) WriteString(data string) (int, error) {
+	r.body.WriteString(data)
+	return r.ResponseWriter.WriteString(data)
+}
+
+// IdempotencyMiddleware makes the requests with an Idempotency-Key header run once per key and
+// user. Their successful responses are stored and replayed to the retries of the request,
+// while the ones that fail release the key so it can be retried. A key reused with a different
+// method, path or body is rejected with 422. s is the service storing the keys, a new one is
+// created for every request if it's nil.
+func IdempotencyMiddleware(s service.IIdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(KeyHeader)
+		authUser, exists := c.Get("authUser")
+		user, ok := authUser.(*model.User)
+
+		if key == "" || !exists || !ok {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			c.Error(&model.ValidationError{
+				Code:   "idempotency.invalid_key",
+				Params: map[string]string{"max": strconv.Itoa(maxKeyLength)},
+			})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		keys := s
+		if keys == nil {
+			keys, err = service.NewIdempotencyService(nil)
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+		}
+
+		stored, err := keys.Begin(c.Request.Context(), user.ID, key, fingerprint(c.Request.Method, c.Request.URL.Path, body))
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		if stored != nil {
+			c.Header(ReplayedHeader, "true")
+
+			contentType := ""
+			if stored.ContentType != nil {
+				contentType = *stored.ContentType
+			}
+
+			response := ""
+			if stored.Response != nil {
+				response = *stored.Response
+			}
+
+			c.Data(*stored.StatusCode, contentType, []byte(response))
+			c.Abort()
+			return
+		}
+
+		writer := &recorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		// The outcome is stored even if the request was cancelled, it already happened
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := c.Writer.Status()
+
+		if len(c.Errors) > 0 || status >= http.StatusInternalServerError {
+			err = keys.Release(ctx, user.ID, key)
+		} else {
+			err = keys.Complete(ctx, user.ID, key, status, c.Writer.Header().Get("Content-Type"), writer.body.String())
+		}
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to store the outcome of an idempotent request", "error", err)
+		}
+	}
+}
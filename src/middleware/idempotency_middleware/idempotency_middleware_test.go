@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryKeys is an IIdempotencyService that keeps the keys in memory
+type memoryKeys struct {
+	records map[string]*model.IdempotencyRecord
+}
+
+func (m *memoryKeys) Begin(ctx context.Context, userId string, key string, fingerprint string) (*model.IdempotencyRecord, error) {
+	stored, ok := m.records[userId+key]
+	if !ok {
+		m.records[userId+key] = &model.IdempotencyRecord{UserID: userId, Key: key, Fingerprint: fingerprint}
+		return nil, nil
+	}
+
+	if stored.Fingerprint != fingerprint {
+		return nil, &model.UnprocessableError{Code: "idempotency.key_reused"}
+	}
+
+	if stored.StatusCode == nil {
+		return nil, &model.ConflictError{Code: "idempotency.request_in_progress"}
+	}
+
+	return stored, nil
+}
+
+func (m *memoryKeys) Complete(ctx context.Context, userId string, key string, statusCode int, contentType string, response string) error {
+	stored := m.records[userId+key]
+	stored.StatusCode, stored.ContentType, stored.Response = &statusCode, &contentType, &response
+
+	return nil
+}
+
+func (m *memoryKeys) Release(ctx context.Context, userId string, key string) error {
+	delete(m.records, userId+key)
+	return nil
+}
+
+func (m *memoryKeys) Prune(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// newRouter returns a router whose POST /items/ creates an item, or fails if fail is set
+	newRouter := func(keys *memoryKeys, fail *bool) (*gin.Engine, *int) {
+		created := 0
+
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set("authUser", &model.User{ID: "1"})
+		})
+		router.POST("/items/", IdempotencyMiddleware(keys), func(c *gin.Context) {
+			if *fail {
+				c.Error(&model.ConflictError{Code: "item.conflict"})
+				return
+			}
+
+			created++
+			c.JSON(http.StatusCreated, gin.H{"id": created})
+		})
+
+		return router, &created
+	}
+
+	post := func(router *gin.Engine, key string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/items/", bytes.NewBufferString(body))
+		if key != "" {
+			req.Header.Set(KeyHeader, key)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	t.Run("A retry with the same key replays the response", func(t *testing.T) {
+		fail := false
+		router, created := newRouter(&memoryKeys{records: map[string]*model.IdempotencyRecord{}}, &fail)
+
+		first := post(router, "abc", `{"name": "a"}`)
+		retry := post(router, "abc", `{"name": "a"}`)
+
+		assert.Equal(t, 1, *created)
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, "application/json; charset=utf-8", retry.Header().Get("Content-Type"))
+		assert.Equal(t, "true", retry.Header().Get(ReplayedHeader))
+		assert.Empty(t, first.Header().Get(ReplayedHeader))
+	})
+
+	t.Run("A key reused with a different body is rejected", func(t *testing.T) {
+		fail := false
+		router, created := newRouter(&memoryKeys{records: map[string]*model.IdempotencyRecord{}}, &fail)
+
+		post(router, "abc", `{"name": "a"}`)
+		post(router, "abc", `{"name": "b"}`)
+
+		assert.Equal(t, 1, *created)
+	})
+
+	t.Run("A failed request releases its key", func(t *testing.T) {
+		fail := true
+		keys := &memoryKeys{records: map[string]*model.IdempotencyRecord{}}
+		router, created := newRouter(keys, &fail)
+
+		post(router, "abc", `{"name": "a"}`)
+		assert.Empty(t, keys.records)
+
+		fail = false
+		w := post(router, "abc", `{"name": "a"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 1, *created)
+	})
+
+	t.Run("Requests without a key aren't deduplicated", func(t *testing.T) {
+		fail := false
+		router, created := newRouter(&memoryKeys{records: map[string]*model.IdempotencyRecord{}}, &fail)
+
+		post(router, "", `{"name": "a"}`)
+		post(router, "", `{"name": "a"}`)
+
+		assert.Equal(t, 2, *created)
+	})
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Run("Depends on the method, path and body", func(t *testing.T) {
+		base := fingerprint(http.MethodPost, "/users/1/exercises/", []byte(`{}`))
+
+		assert.Len(t, base, 64)
+		assert.Equal(t, base, fingerprint(http.MethodPost, "/users/1/exercises/", []byte(`{}`)))
+		assert.NotEqual(t, base, fingerprint(http.MethodPost, "/users/1/routines/", []byte(`{}`)))
+		assert.NotEqual(t, base, fingerprint(http.MethodPost, "/users/1/exercises/", []byte(`{"a":1}`)))
+	})
+}
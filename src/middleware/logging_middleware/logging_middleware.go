@@ -0,0 +1,77 @@
+// Package middleware provides custom middlewares for the API
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader is the header used to receive and return the ID of a request
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDRegex restricts the request IDs accepted from clients, so they can't inject
+// arbitrary content in the logs
+var requestIDRegex = regexp.MustCompile(`^[a-zA-Z0-9._:-]{1,128}$`)
+
+// newRequestID returns a random 128 bits request ID encoded as hex
+func newRequestID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+
+	return hex.EncodeToString(bytes)
+}
+
+// requestIDFrom returns the request ID received in the X-Request-ID header
+// header is the value of the header
+// It returns a new request ID if the header is empty or has an invalid format
+func requestIDFrom(header string) string {
+	if requestIDRegex.MatchString(header) {
+		return header
+	}
+
+	return newRequestID()
+}
+
+// levelFor returns the level of the access log of a request with the given status code
+func levelFor(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LoggingMiddleware is a middleware that propagates the X-Request-ID header (generating one if
+// the client didn't send it), stores the request fields in the request context so every record
+// logged while handling it carries them, and logs the request once it's completed.
+func LoggingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := requestIDFrom(c.GetHeader(RequestIDHeader))
+		c.Header(RequestIDHeader, requestID)
+
+		ctx := logger.WithRequest(c.Request.Context(), requestID, c.FullPath())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		slog.Log(ctx, levelFor(status), "Request completed",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+		)
+	}
+}
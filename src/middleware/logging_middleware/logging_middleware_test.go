@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDFrom(t *testing.T) {
+	t.Run("A valid request ID should be propagated", func(t *testing.T) {
+		header := "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+
+		result := requestIDFrom(header)
+
+		if header != result {
+			t.Errorf("requestIDFrom should be '%s'", header)
+		}
+	})
+
+	t.Run("An empty header should generate a new request ID", func(t *testing.T) {
+		result := requestIDFrom("")
+
+		if len(result) != 32 {
+			t.Errorf("requestIDFrom should generate a 32 characters ID, got '%s'", result)
+		}
+	})
+
+	t.Run("A request ID with invalid characters should be replaced", func(t *testing.T) {
+		header := "id\n{\"level\":\"ERROR\"}"
+
+		result := requestIDFrom(header)
+
+		if result == header || strings.ContainsAny(result, "\n{}\"") {
+			t.Errorf("requestIDFrom shouldn't propagate '%s'", header)
+		}
+	})
+
+	t.Run("A too long request ID should be replaced", func(t *testing.T) {
+		header := strings.Repeat("a", 129)
+
+		result := requestIDFrom(header)
+
+		if result == header {
+			t.Errorf("requestIDFrom shouldn't propagate IDs longer than 128 characters")
+		}
+	})
+}
+
+func TestLevelFor(t *testing.T) {
+	cases := map[int]slog.Level{
+		http.StatusOK:                  slog.LevelInfo,
+		http.StatusNoContent:           slog.LevelInfo,
+		http.StatusBadRequest:          slog.LevelWarn,
+		http.StatusNotFound:            slog.LevelWarn,
+		http.StatusInternalServerError: slog.LevelError,
+		http.StatusGatewayTimeout:      slog.LevelError,
+	}
+
+	for status, expected := range cases {
+		if result := levelFor(status); result != expected {
+			t.Errorf("levelFor(%d) should be %s, got %s", status, expected, result)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Package middleware provides custom middlewares for the API
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels the requests that don't match any route, so that random paths
+// don't blow up the cardinality of the metrics
+const unmatchedRoute = "unmatched"
+
+// routeLabel returns the route template of the request (e.g. /users/:userId/exercises/:id)
+// fullPath is the matched route, empty if the request didn't match any
+func routeLabel(fullPath string) string {
+	if fullPath == "" {
+		return unmatchedRoute
+	}
+
+	return fullPath
+}
+
+// MetricsMiddleware is a middleware that records the number and latency of the HTTP requests
+// by method, route and status code. It must be registered before the ErrorHandler so the
+// recorded status is the one written by it.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		labels := []string{
+			c.Request.Method,
+			routeLabel(c.FullPath()),
+			strconv.Itoa(c.Writer.Status()),
+		}
+
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import "testing"
+
+func TestRouteLabel(t *testing.T) {
+	t.Run("A matched route should be labeled with its template", func(t *testing.T) {
+		fullPath := "/users/:userId/exercises/:id"
+		expected := "/users/:userId/exercises/:id"
+
+		result := routeLabel(fullPath)
+
+		if expected != result {
+			t.Errorf("routeLabel should be '%s'", expected)
+		}
+	})
+
+	t.Run("An unmatched route should be labeled as unmatched", func(t *testing.T) {
+		fullPath := ""
+		expected := "unmatched"
+
+		result := routeLabel(fullPath)
+
+		if expected != result {
+			t.Errorf("routeLabel should be '%s'", expected)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+// Package middleware provides custom middlewares for the API
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultTimeout is the deadline of the routes without an entry in ROUTE_TIMEOUTS when REQUEST_TIMEOUT isn't set
+const defaultTimeout = 10 * time.Second
+
+// parseRouteTimeouts parses a comma separated list of "<METHOD> <route>=<duration>" entries,
+// e.g. "GET /users/freeSchedules/=20s,PUT /users/:userId/anthropometrics/=3s".
+// value is the list to parse
+// It returns the timeouts indexed by "<METHOD> <route>", invalid entries are logged and ignored
+func parseRouteTimeouts(value string) map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		idx := strings.LastIndex(entry, "=")
+		if idx == -1 {
+			slog.Warn("Ignoring route timeout, expected format: <METHOD> <route>=<duration>", "entry", entry)
+			continue
+		}
+
+		timeout, err := time.ParseDuration(entry[idx+1:])
+		if err != nil {
+			slog.Warn("Ignoring route timeout, invalid duration", "entry", entry, "error", err)
+			continue
+		}
+
+		route := strings.Join(strings.Fields(entry[:idx]), " ")
+		timeouts[route] = timeout
+	}
+
+	return timeouts
+}
+
+// requestTimeout returns the deadline configured for REQUEST_TIMEOUT or defaultTimeout
+func requestTimeout() time.Duration {
+	value := os.Getenv("REQUEST_TIMEOUT")
+	if value == "" {
+		return defaultTimeout
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Warn("Invalid REQUEST_TIMEOUT, using the default", "value", value, "default", defaultTimeout.String())
+		return defaultTimeout
+	}
+
+	return timeout
+}
+
+// TimeoutMiddleware is a middleware that sets a deadline on the request context, so the
+// database queries of the request are cancelled once it's exceeded. The deadline is
+// REQUEST_TIMEOUT (10s by default) and can be overridden per route with ROUTE_TIMEOUTS.
+// A zero or negative timeout disables the deadline of the route.
+func TimeoutMiddleware() gin.HandlerFunc {
+	fallback := requestTimeout()
+	routeTimeouts := parseRouteTimeouts(os.Getenv("ROUTE_TIMEOUTS"))
+
+	return func(c *gin.Context) {
+		timeout, ok := routeTimeouts[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			timeout = fallback
+		}
+
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
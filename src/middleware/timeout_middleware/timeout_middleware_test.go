@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRouteTimeouts(t *testing.T) {
+	t.Run("An empty value should return no timeouts", func(t *testing.T) {
+		result := parseRouteTimeouts("")
+
+		if len(result) != 0 {
+			t.Errorf("parseRouteTimeouts should return no timeouts")
+		}
+	})
+
+	t.Run("Valid entries should be indexed by method and route", func(t *testing.T) {
+		value := "GET /users/freeSchedules/=20s, PUT  /users/:userId/anthropometrics/=3s"
+		expected := map[string]time.Duration{
+			"GET /users/freeSchedules/":           20 * time.Second,
+			"PUT /users/:userId/anthropometrics/": 3 * time.Second,
+		}
+
+		result := parseRouteTimeouts(value)
+
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("parseRouteTimeouts should be %v, got %v", expected, result)
+		}
+	})
+
+	t.Run("Invalid entries should be ignored", func(t *testing.T) {
+		value := "GET /users/freeSchedules/,GET /users/:userId/routines/=soon,DELETE /users/:userId/routines/=1m"
+		expected := map[string]time.Duration{
+			"DELETE /users/:userId/routines/": time.Minute,
+		}
+
+		result := parseRouteTimeouts(value)
+
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("parseRouteTimeouts should be %v, got %v", expected, result)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+// Package middleware provides custom middlewares for the API
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/NutriPocket/ProgressService/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanName returns the name of the server span of a request, e.g. "GET /users/:userId/exercises/"
+// method is the HTTP method and fullPath the matched route, empty if the request didn't match any
+func spanName(method string, fullPath string) string {
+	if fullPath == "" {
+		return method
+	}
+
+	return method + " " + fullPath
+}
+
+// TracingMiddleware is a middleware that extracts the W3C traceparent header of the request,
+// starts a server span for it and stores the span in the request context so the controllers,
+// services and repositories can create child spans.
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		ctx, span := tracing.Tracer().Start(
+			ctx,
+			spanName(c.Request.Method, c.FullPath()),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", c.FullPath()),
+				attribute.String("url.path", c.Request.URL.Path),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+	}
+}
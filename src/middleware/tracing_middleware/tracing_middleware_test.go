@@ -0,0 +1,25 @@
+package middleware
+
+import "testing"
+
+func TestSpanName(t *testing.T) {
+	t.Run("A matched route should be named with the method and its template", func(t *testing.T) {
+		expected := "GET /users/:userId/anthropometrics/"
+
+		result := spanName("GET", "/users/:userId/anthropometrics/")
+
+		if expected != result {
+			t.Errorf("spanName should be '%s'", expected)
+		}
+	})
+
+	t.Run("An unmatched route should be named only with the method", func(t *testing.T) {
+		expected := "POST"
+
+		result := spanName("POST", "")
+
+		if expected != result {
+			t.Errorf("spanName should be '%s'", expected)
+		}
+	})
+}
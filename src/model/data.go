@@ -8,16 +8,39 @@ type User struct {
 	Email    string `json:"email"`
 }
 
+// Sexes of the users, used by the estimates that depend on it
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+)
+
 type BaseFixedUserData struct {
-	UserID   string `json:"user_id"`
-	Height   uint   `json:"height" binding:"required"`
-	Birthday string `json:"birthday" binding:"required"`
+	UserID   string  `json:"user_id"`
+	Height   uint    `json:"height" binding:"required"`
+	Birthday string  `json:"birthday" binding:"required"`
+	Sex      *string `json:"sex" binding:"omitempty,oneof=male female"`
+	// Units is the unit system the user prefers, metric if it's nil
+	Units *UnitSystem `json:"units" binding:"omitempty,oneof=metric imperial"`
+	// TimeZone is the IANA time zone of the user, e.g. America/Argentina/Buenos_Aires. Dates such
+	// as birthdays are calendar dates of this zone, UTC if it's nil.
+	TimeZone *string `json:"time_zone" binding:"omitempty,timezone"`
+	// Version is increased on every change of the fixed data
+	Version uint `json:"-" binding:"-"`
+}
+
+// HeightRecord is the height of a user from a date until the date of the next record
+type HeightRecord struct {
+	Height    uint   `json:"height"`
+	ValidFrom string `json:"valid_from"`
 }
 
 type FixedUserData struct {
-	UserID string `json:"user_id"`
-	Height uint   `json:"height"`
-	Age    uint   `json:"age"`
+	UserID   string      `json:"user_id"`
+	Height   uint        `json:"height"`
+	Age      uint        `json:"age"`
+	Sex      *string     `json:"sex"`
+	Units    *UnitSystem `json:"units"`
+	TimeZone *string     `json:"time_zone"`
 }
 
 type AnthropometricData struct {
@@ -29,9 +52,98 @@ type AnthropometricData struct {
 	CreatedAt  string   `json:"created_at"`
 }
 
-type ObjectiveData struct {
+// Tags of the circumstances in which an anthropometric measurement was taken
+const (
+	ContextFasted      = "fasted"
+	ContextPreWorkout  = "pre_workout"
+	ContextPostWorkout = "post_workout"
+)
+
+// Circumferences of the body, in centimeters
+type Circumferences struct {
+	Waist *float32 `json:"waist" binding:"omitempty,gt=0"`
+	Hip   *float32 `json:"hip" binding:"omitempty,gt=0"`
+	Neck  *float32 `json:"neck" binding:"omitempty,gt=0"`
+	Chest *float32 `json:"chest" binding:"omitempty,gt=0"`
+	Arm   *float32 `json:"arm" binding:"omitempty,gt=0"`
+	Thigh *float32 `json:"thigh" binding:"omitempty,gt=0"`
+}
+
+// Fields returns the addresses of every circumference, to process them all alike
+func (c *Circumferences) Fields() []**float32 {
+	return []**float32{&c.Waist, &c.Hip, &c.Neck, &c.Chest, &c.Arm, &c.Thigh}
+}
+
+// Skinfolds thicknesses, in millimeters
+type Skinfolds struct {
+	Triceps     *float32 `json:"triceps" binding:"omitempty,gt=0"`
+	Biceps      *float32 `json:"biceps" binding:"omitempty,gt=0"`
+	Subscapular *float32 `json:"subscapular" binding:"omitempty,gt=0"`
+	Suprailiac  *float32 `json:"suprailiac" binding:"omitempty,gt=0"`
+	Abdominal   *float32 `json:"abdominal" binding:"omitempty,gt=0"`
+	Thigh       *float32 `json:"thigh" binding:"omitempty,gt=0"`
+}
+
+// Fields returns the addresses of every skinfold, to process them all alike
+func (s *Skinfolds) Fields() []**float32 {
+	return []**float32{&s.Triceps, &s.Biceps, &s.Subscapular, &s.Suprailiac, &s.Abdominal, &s.Thigh}
+}
+
+// BodyMeasurements are the optional measurements of the body taken along with the weight
+type BodyMeasurements struct {
+	Circumferences *Circumferences `json:"circumferences"`
+	Skinfolds      *Skinfolds      `json:"skinfolds"`
+}
+
+// BodyIndicators are derived from the measurements and the fixed data the user had at the
+// date of the measurements. Each of them is nil if a measurement it needs is missing.
+type BodyIndicators struct {
+	WaistToHip    *float32 `json:"waist_to_hip"`
+	WaistToHeight *float32 `json:"waist_to_height"`
+	// NavyBodyFat is the body fat percentage estimated with the US Navy method
+	NavyBodyFat *float32 `json:"navy_body_fat"`
+	// BMR is the basal metabolic rate in kcal per day, estimated with the Mifflin-St Jeor equation
+	BMR *float32 `json:"bmr"`
+}
+
+// AnthropometricEntry is one of the measurements taken by a user in a day
+type AnthropometricEntry struct {
+	ID uint64 `json:"id"`
 	AnthropometricData
-	Deadline string `json:"deadline" binding:"required"`
+	Context *string `json:"context" binding:"omitempty,oneof=fasted pre_workout post_workout"`
+	BodyMeasurements
+	Indicators *BodyIndicators `json:"indicators" binding:"-"`
+}
+
+// AnthropometricAggregate is the way the measurements of a day are combined into one
+type AnthropometricAggregate string
+
+const (
+	AggregateFirst AnthropometricAggregate = "first"
+	AggregateLast  AnthropometricAggregate = "last"
+	AggregateMin   AnthropometricAggregate = "min"
+	AggregateMean  AnthropometricAggregate = "mean"
+)
+
+// DailyAnthropometricData is the aggregate of the measurements of a day along with the measurements
+type DailyAnthropometricData struct {
+	AnthropometricData
+	BodyMeasurements
+	Indicators *BodyIndicators         `json:"indicators"`
+	Aggregate  AnthropometricAggregate `json:"aggregate"`
+	Entries    []AnthropometricEntry   `json:"entries"`
+}
+
+// AnthropometricPatch is a partial update of the anthropometric data of a day.
+// Weight can't be cleared, so a null weight is ignored like a missing one.
+type AnthropometricPatch struct {
+	Weight     *float32          `json:"weight" binding:"omitempty,gt=0"`
+	MuscleMass Nullable[float32] `json:"muscle_mass" binding:"omitempty,gt=0"`
+	FatMass    Nullable[float32] `json:"fat_mass" binding:"omitempty,gt=0"`
+	BoneMass   Nullable[float32] `json:"bone_mass" binding:"omitempty,gt=0"`
+	// Circumferences and Skinfolds replace the stored group as a whole
+	Circumferences Nullable[Circumferences] `json:"circumferences"`
+	Skinfolds      Nullable[Skinfolds]      `json:"skinfolds"`
 }
 
 type Schedule struct {
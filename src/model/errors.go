@@ -3,38 +3,103 @@ package model
 
 import "fmt"
 
+// describe returns the text of a model error. Errors with a code are described by it and
+// its params, since their title and detail are looked up in the i18n catalogs when rendered.
+func describe(code string, params map[string]string, title string, detail string) string {
+	if code != "" {
+		return fmt.Sprintf("%s %v", code, params)
+	}
+
+	return fmt.Sprintf("%s, %s", title, detail)
+}
+
+// ValidationError is returned when the request is invalid.
+// Code is the key of its texts in the i18n catalogs and Params are the values of their
+// placeholders. Title and Detail are only used for errors without a code.
 type ValidationError struct {
+	Code   string
+	Params map[string]string
 	Detail string
 	Title  string
+	// Errors lists the invalid fields, if the error was caused by an invalid request body
+	Errors []FieldError
 }
 
 func (e *ValidationError) Error() string {
-	return fmt.Sprintf("Validation error: %s, %s", e.Title, e.Detail)
+	return "Validation error: " + describe(e.Code, e.Params, e.Title, e.Detail)
 }
 
+// AuthenticationError is returned when the user isn't authenticated or authorized, see ValidationError
 type AuthenticationError struct {
+	Code   string
+	Params map[string]string
 	Detail string
 	Title  string
 }
 
 func (e *AuthenticationError) Error() string {
-	return fmt.Sprintf("%s, %s", e.Title, e.Detail)
+	return describe(e.Code, e.Params, e.Title, e.Detail)
 }
 
+// ForbiddenError is returned when the authenticated user isn't allowed to do the request,
+// e.g. a user that isn't an administrator, see ValidationError
+type ForbiddenError struct {
+	Code   string
+	Params map[string]string
+	Detail string
+	Title  string
+}
+
+func (e *ForbiddenError) Error() string {
+	return describe(e.Code, e.Params, e.Title, e.Detail)
+}
+
+// NotFoundError is returned when the requested entity doesn't exist, see ValidationError
 type NotFoundError struct {
+	Code   string
+	Params map[string]string
 	Detail string
 	Title  string
 }
 
 func (e *NotFoundError) Error() string {
-	return fmt.Sprintf("%s, %s", e.Title, e.Detail)
+	return describe(e.Code, e.Params, e.Title, e.Detail)
 }
 
+// ConflictError is returned when the request conflicts with the stored entities, see ValidationError
 type ConflictError struct {
+	Code   string
+	Params map[string]string
 	Detail string
 	Title  string
 }
 
 func (e *ConflictError) Error() string {
-	return fmt.Sprintf("%s, %s", e.Title, e.Detail)
+	return describe(e.Code, e.Params, e.Title, e.Detail)
+}
+
+// UnprocessableError is returned when the request is well formed but can't be processed, e.g.
+// an idempotency key reused with a different request, see ValidationError
+type UnprocessableError struct {
+	Code   string
+	Params map[string]string
+	Detail string
+	Title  string
+}
+
+func (e *UnprocessableError) Error() string {
+	return describe(e.Code, e.Params, e.Title, e.Detail)
+}
+
+// PreconditionFailedError is returned when the If-Match header of a request doesn't match the
+// current version of the resource, see ValidationError
+type PreconditionFailedError struct {
+	Code   string
+	Params map[string]string
+	Detail string
+	Title  string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return describe(e.Code, e.Params, e.Title, e.Detail)
 }
@@ -10,6 +10,8 @@ type ExerciseDTO struct {
 type ExerciseData struct {
 	ID        uint64 `json:"id"`
 	CreatedAt string `json:"createdAt"`
+	// Version is increased on every change of the exercise
+	Version uint `json:"-"`
 	ExerciseDTO
 }
 
@@ -17,3 +19,9 @@ type AllExercisesInDay struct {
 	TotalBurned float64        `json:"totalBurned"`
 	Exercises   []ExerciseData `json:"exercises"`
 }
+
+// ExerciseTotals summarizes the exercises of a user in a range of time
+type ExerciseTotals struct {
+	Calories float64
+	Count    int64
+}
@@ -0,0 +1,19 @@
+package model
+
+// IdempotencyRecord is a request made with an Idempotency-Key header, along with its response
+// once it's completed, so the retries of the request are answered with it
+type IdempotencyRecord struct {
+	UserID string `json:"user_id"`
+	Key    string `json:"key" gorm:"column:idempotency_key"`
+	// Fingerprint is the hash of the method, path and body of the request
+	Fingerprint string `json:"fingerprint"`
+	// StatusCode, ContentType and Response are nil until the request is completed
+	StatusCode  *int    `json:"status_code"`
+	ContentType *string `json:"content_type"`
+	Response    *string `json:"response"`
+	// LockedUntil is when a request that isn't completed is considered abandoned, so a retry
+	// takes it over
+	LockedUntil string `json:"locked_until"`
+	ExpiresAt   string `json:"expires_at"`
+	CreatedAt   string `json:"created_at"`
+}
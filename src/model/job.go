@@ -0,0 +1,50 @@
+package model
+
+// JobStatus is the state of a background job
+type JobStatus string
+
+const (
+	// JobPending jobs run once their run_at passes
+	JobPending JobStatus = "pending"
+	// JobRunning jobs are being run by the worker that locked them
+	JobRunning JobStatus = "running"
+	// JobSucceeded and JobFailed are the final states of one-off jobs. Recurring jobs go back
+	// to pending after every run.
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is a background job, either recurring on a schedule or run once at RunAt
+type Job struct {
+	ID uint64 `json:"id"`
+	// Name is the kind of job, it tells which handler runs it
+	Name string `json:"name"`
+	// Key identifies a job so it's only stored once, recurring jobs use their name
+	Key *string `json:"key" gorm:"column:job_key"`
+	// Schedule is nil for one-off jobs
+	Schedule *string `json:"schedule"`
+	// Payload is the JSON the handler receives
+	Payload     *string   `json:"payload"`
+	Status      JobStatus `json:"status"`
+	RunAt       string    `json:"run_at"`
+	Attempts    uint      `json:"attempts"`
+	MaxAttempts uint      `json:"max_attempts"`
+	// LockedBy is the worker running the job until LockedUntil, when another worker can take it over
+	LockedBy    *string `json:"locked_by"`
+	LockedUntil *string `json:"locked_until"`
+	LastRunAt   *string `json:"last_run_at"`
+	LastError   *string `json:"last_error"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+// JobRun is an attempt to run a job
+type JobRun struct {
+	ID         uint64    `json:"id"`
+	JobID      uint64    `json:"job_id"`
+	Worker     string    `json:"worker"`
+	Attempt    uint      `json:"attempt"`
+	Status     JobStatus `json:"status"`
+	Error      *string   `json:"error"`
+	StartedAt  string    `json:"started_at"`
+	FinishedAt *string   `json:"finished_at"`
+}
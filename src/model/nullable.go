@@ -0,0 +1,38 @@
+package model
+
+import "encoding/json"
+
+// Nullable is a field of a partial update that tells apart a field missing from the request,
+// which keeps the stored value, from an explicit null, which clears it
+type Nullable[T any] struct {
+	// Set is true if the field was present in the request, even if it was null
+	Set   bool
+	Value *T
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	n.Value = &value
+	return nil
+}
+
+// Apply returns the value stored updated with the field: the stored value if the field wasn't
+// sent, its value otherwise
+func (n Nullable[T]) Apply(stored *T) *T {
+	if !n.Set {
+		return stored
+	}
+
+	return n.Value
+}
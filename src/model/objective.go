@@ -0,0 +1,126 @@
+package model
+
+// ObjectiveType is the kind of goal of an objective
+type ObjectiveType string
+
+const (
+	// ObjectiveBodyComposition targets a weight and optionally muscle, fat and bone masses
+	ObjectiveBodyComposition ObjectiveType = "body_composition"
+	// ObjectiveCalories targets the kcal burned exercising per period
+	ObjectiveCalories ObjectiveType = "calories"
+	// ObjectiveExerciseFrequency targets the exercises done per period
+	ObjectiveExerciseFrequency ObjectiveType = "exercise_frequency"
+	// ObjectiveWeightLogging targets the days with a weight measurement per period
+	ObjectiveWeightLogging ObjectiveType = "weight_logging"
+)
+
+// ObjectivePeriod is the calendar period the target of an activity objective is counted in,
+// in the time zone of the user. Weeks start on Monday.
+type ObjectivePeriod string
+
+const (
+	PeriodDay   ObjectivePeriod = "day"
+	PeriodWeek  ObjectivePeriod = "week"
+	PeriodMonth ObjectivePeriod = "month"
+)
+
+// ObjectiveStatus is the state of an objective, it's active until it's achieved or its deadline passes
+type ObjectiveStatus string
+
+const (
+	ObjectiveActive   ObjectiveStatus = "active"
+	ObjectiveAchieved ObjectiveStatus = "achieved"
+	ObjectiveExpired  ObjectiveStatus = "expired"
+)
+
+// ObjectiveData is the objective of a user. Body composition objectives use the fields of
+// AnthropometricData as their target, the rest of them use Target and Period.
+type ObjectiveData struct {
+	Type ObjectiveType `json:"type"`
+	AnthropometricData
+	Target   *float32         `json:"target,omitempty"`
+	Period   *ObjectivePeriod `json:"period,omitempty"`
+	Deadline string           `json:"deadline" binding:"required"`
+	// Status, ClosedAt and FinalValue are set by the service, the versions of the history have none
+	Status ObjectiveStatus `json:"status,omitempty" binding:"-"`
+	// ClosedAt is when the objective was found achieved or expired
+	ClosedAt *string `json:"closed_at,omitempty" binding:"-"`
+	// FinalValue is the current value of the progress when the objective was closed
+	FinalValue *float32 `json:"final_value,omitempty" binding:"-"`
+	// Version is increased on every change of the objective
+	Version uint `json:"-" binding:"-"`
+	// Progress is nil if the objective can't be evaluated yet, e.g. a body composition
+	// objective of a user without measurements
+	Progress *ObjectiveProgress `json:"progress,omitempty" binding:"-" gorm:"-"`
+	// Feasibility is only returned when a body composition objective is set, if the user has
+	// measured the weight
+	Feasibility *ObjectiveFeasibility `json:"feasibility,omitempty" binding:"-" gorm:"-"`
+}
+
+// ActivityObjectiveDTO is the request body of the objectives that aren't about the body composition
+type ActivityObjectiveDTO struct {
+	Type     ObjectiveType   `json:"type" binding:"required,oneof=calories exercise_frequency weight_logging"`
+	Target   float32         `json:"target" binding:"required,gt=0"`
+	Period   ObjectivePeriod `json:"period" binding:"required,oneof=day week month"`
+	Deadline string          `json:"deadline" binding:"required"`
+}
+
+// Objective returns the objective the request body describes
+func (d *ActivityObjectiveDTO) Objective() ObjectiveData {
+	return ObjectiveData{
+		Type:     d.Type,
+		Target:   &d.Target,
+		Period:   &d.Period,
+		Deadline: d.Deadline,
+	}
+}
+
+// ObjectiveProgress is the evaluation of an objective when it's requested
+type ObjectiveProgress struct {
+	// Start is the weight when a body composition objective was set
+	Start *float32 `json:"start,omitempty"`
+	// Current is the last weight measured for body composition objectives, and the kcal,
+	// exercises or days with a weight measurement of the current period for the rest
+	Current float32 `json:"current"`
+	Target  float32 `json:"target"`
+	// Percentage of the way from the start to the target, or of the target reached in the
+	// current period, between 0 and 100
+	Percentage float32 `json:"percentage"`
+	Achieved   bool    `json:"achieved"`
+	// PeriodStart and PeriodEnd bound the current period of activity objectives
+	PeriodStart *string `json:"period_start,omitempty"`
+	PeriodEnd   *string `json:"period_end,omitempty"`
+}
+
+// Warning is a notice about an accepted request, its message is localized for the client
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ObjectiveFeasibility is the pace a body composition objective requires from the last
+// measurement of the user until its deadline
+type ObjectiveFeasibility struct {
+	// WeeklyRate is the change of weight per week, negative to lose weight
+	WeeklyRate float32 `json:"weekly_rate"`
+	// MuscleWeeklyRate is the change of muscle mass per week, if the objective has a muscle
+	// mass and the last measurement too
+	MuscleWeeklyRate *float32  `json:"muscle_weekly_rate,omitempty"`
+	Warnings         []Warning `json:"warnings"`
+	// SuggestedDeadline is the earliest deadline that keeps a recommended pace, if the
+	// deadline requires a faster one
+	SuggestedDeadline *string `json:"suggested_deadline,omitempty"`
+}
+
+// Milestone is an intermediate checkpoint of a body composition objective, from the weight of
+// the user when the objective was set to its target
+type Milestone struct {
+	Sequence uint    `json:"sequence"`
+	Weight   float32 `json:"weight"`
+	// StartWeight is the weight the objective started from, it tells whether the milestone is
+	// reached by losing or gaining weight
+	StartWeight float32 `json:"-"`
+	DueDate     string  `json:"due_date"`
+	// AchievedAt is when the first measurement that reached the milestone was taken
+	AchievedAt *string `json:"achieved_at"`
+}
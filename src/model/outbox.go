@@ -0,0 +1,15 @@
+package model
+
+// OutboxEvent is a domain event stored in the same transaction as the change it's about,
+// waiting to be relayed to the publishers
+type OutboxEvent struct {
+	ID uint64 `json:"id"`
+	// EventID identifies the event, its consumers use it to discard the events delivered twice
+	EventID   string `json:"event_id"`
+	EventType string `json:"event_type"`
+	UserID    string `json:"user_id"`
+	// Payload is the JSON of the data the event is about
+	Payload     string  `json:"payload"`
+	OccurredAt  string  `json:"occurred_at"`
+	PublishedAt *string `json:"published_at"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "math"
+
+// SortOrder is the order of a list by date
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+const (
+	// NoPageLimit is the limit of the lists requested without one, which are sent whole in a
+	// single page, so pagination is opt-in
+	NoPageLimit = math.MaxInt32
+	// MaxPageLimit is the maximum number of items of a page
+	MaxPageLimit = 100
+)
+
+// Cursor points right after the last item of a page. Keys are the values of the sort columns
+// of that item, the date first and then the columns that break ties between items of the same date.
+type Cursor struct {
+	Sort SortOrder `json:"s"`
+	Keys []string  `json:"k"`
+}
+
+// PageParams are the pagination parameters of a list request
+type PageParams struct {
+	Limit int
+	Sort  SortOrder
+	// After is the cursor returned with the previous page, nil for the first page
+	After *Cursor
+}
+
+// Page is a page of a list, Next is nil if it's the last page
+type Page[T any] struct {
+	Items []T
+	Next  *Cursor
+}
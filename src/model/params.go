@@ -3,4 +3,5 @@ package model
 type GetAnthropometricParams struct {
 	StartDate *string
 	EndDate   *string
+	PageParams
 }
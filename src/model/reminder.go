@@ -0,0 +1,43 @@
+package model
+
+// ReminderType is the situation a reminder rule nudges the user about
+type ReminderType string
+
+const (
+	// ReminderMissedWeighIn reminds the user to measure the weight after Threshold days without doing it
+	ReminderMissedWeighIn ReminderType = "missed_weigh_in"
+	// ReminderUpcomingRoutine reminds the user of a routine Threshold minutes before it starts
+	ReminderUpcomingRoutine ReminderType = "upcoming_routine"
+)
+
+// ReminderDTO is the request body of a reminder rule
+type ReminderDTO struct {
+	Type ReminderType `json:"type" binding:"required,oneof=missed_weigh_in upcoming_routine"`
+	// Threshold is in days for missed weigh-ins and in minutes for upcoming routines
+	Threshold uint `json:"threshold" binding:"required,gt=0"`
+	// QuietStart and QuietEnd bound the hours of the day, "HH:MM" in the time zone of the user,
+	// in which no reminder is sent. The range wraps around midnight if QuietEnd is earlier.
+	// Either both of them are set or none.
+	QuietStart *string `json:"quiet_start" binding:"omitempty,datetime=15:04"`
+	QuietEnd   *string `json:"quiet_end" binding:"omitempty,datetime=15:04"`
+}
+
+// Reminder is a reminder rule of a user
+type Reminder struct {
+	ID     uint64 `json:"id"`
+	UserID string `json:"user_id"`
+	ReminderDTO
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// Notification is a message for a user. Code and Params describe it, so the delivery service
+// can localize it.
+type Notification struct {
+	UserID string            `json:"user_id"`
+	Code   string            `json:"code"`
+	Params map[string]string `json:"params"`
+	// Key identifies the notification, the same one may be delivered twice if a delivery fails
+	// after it's sent
+	Key string `json:"key"`
+}
@@ -1,10 +1,45 @@
 package model
 
+// Problem type URIs, one per kind of error. They are stable identifiers clients can match on,
+// unlike titles and details which are meant for humans and may change.
+const (
+	ProblemTypeValidation     = "urn:nutripocket:problems:validation"
+	ProblemTypeAuthentication = "urn:nutripocket:problems:authentication"
+	ProblemTypeForbidden      = "urn:nutripocket:problems:forbidden"
+	ProblemTypeNotFound       = "urn:nutripocket:problems:not-found"
+	ProblemTypeConflict       = "urn:nutripocket:problems:conflict"
+	ProblemTypeUnprocessable  = "urn:nutripocket:problems:unprocessable"
+	ProblemTypePrecondition   = "urn:nutripocket:problems:precondition-failed"
+	ProblemTypeTimeout        = "urn:nutripocket:problems:timeout"
+	ProblemTypeCancelled      = "urn:nutripocket:problems:cancelled"
+	ProblemTypeInternal       = "urn:nutripocket:problems:internal"
+)
+
+// ProblemContentType is the media type of the RFC 9457 problem details responses
+const ProblemContentType = "application/problem+json"
+
+// FieldError describes why a single field of the request body is invalid
+type FieldError struct {
+	// Pointer is the JSON pointer (RFC 6901) of the field in the request body, e.g. "/weight"
+	Pointer string `json:"pointer"`
+	// Rule is the validation rule the field failed, e.g. "required"
+	Rule string `json:"rule"`
+	// Param is the parameter of the rule, e.g. "0" for gt=0
+	Param   string `json:"-"`
+	Message string `json:"message"`
+}
+
 // ErrorRfc9457 is a struct that will be used to return errors in the RFC 9457 format
 type ErrorRfc9457 struct {
-	Type     string `json:"type"`
+	Type string `json:"type"`
+	// Code is the stable machine-readable code of the error, the key of its texts in the i18n catalogs
+	Code     string `json:"code,omitempty"`
 	Title    string `json:"title"`
 	Status   int    `json:"status"`
 	Detail   string `json:"detail"`
 	Instance string `json:"instance"`
+	// Errors is the list of invalid fields of a validation problem
+	Errors []FieldError `json:"errors,omitempty"`
+	// TraceID identifies the request in the traces and logs of the service
+	TraceID string `json:"traceId,omitempty"`
 }
@@ -0,0 +1,160 @@
+package model
+
+import "math"
+
+// UnitSystem is the system of units of the values exchanged with a client.
+// Values are always stored in the metric system.
+type UnitSystem string
+
+const (
+	UnitsMetric   UnitSystem = "metric"
+	UnitsImperial UnitSystem = "imperial"
+)
+
+const (
+	poundsPerKilogram  = 2.2046226218
+	centimetersPerInch = 2.54
+)
+
+// UnitConverter converts values between the metric system and another unit system, in place.
+// Masses are converted between kilograms and pounds, lengths between centimeters and inches.
+// Skinfolds are measured in millimeters in every system, so they are never converted.
+type UnitConverter struct {
+	units    UnitSystem
+	toMetric bool
+}
+
+// UnitConvertible is implemented by the data whose values depend on the unit system
+type UnitConvertible interface {
+	ConvertUnits(c UnitConverter)
+}
+
+// ToUnits converts values stored in the metric system into the unit system
+func ToUnits(units UnitSystem, values ...UnitConvertible) {
+	convert(UnitConverter{units: units}, values)
+}
+
+// FromUnits converts values received in the unit system into the metric system
+func FromUnits(units UnitSystem, values ...UnitConvertible) {
+	convert(UnitConverter{units: units, toMetric: true}, values)
+}
+
+func convert(c UnitConverter, values []UnitConvertible) {
+	if c.units != UnitsImperial {
+		return
+	}
+
+	for _, value := range values {
+		value.ConvertUnits(c)
+	}
+}
+
+func (c UnitConverter) scale(value *float32, factor float64) {
+	if value == nil {
+		return
+	}
+
+	if c.toMetric {
+		factor = 1 / factor
+	}
+
+	*value = float32(math.Round(float64(*value)*factor*100) / 100)
+}
+
+// Mass converts a mass, if it isn't nil
+func (c UnitConverter) Mass(value *float32) {
+	c.scale(value, poundsPerKilogram)
+}
+
+// Length converts a length, if it isn't nil
+func (c UnitConverter) Length(value *float32) {
+	c.scale(value, 1/centimetersPerInch)
+}
+
+// WholeLength converts a length stored as a whole number, rounding the result
+func (c UnitConverter) WholeLength(value *uint) {
+	length := float32(*value)
+	c.Length(&length)
+	*value = uint(math.Round(float64(length)))
+}
+
+func (d *AnthropometricData) ConvertUnits(c UnitConverter) {
+	c.Mass(&d.Weight)
+	c.Mass(d.MuscleMass)
+	c.Mass(d.FatMass)
+	c.Mass(d.BoneMass)
+}
+
+func (m *BodyMeasurements) ConvertUnits(c UnitConverter) {
+	if m.Circumferences == nil {
+		return
+	}
+
+	for _, field := range m.Circumferences.Fields() {
+		c.Length(*field)
+	}
+}
+
+func (e *AnthropometricEntry) ConvertUnits(c UnitConverter) {
+	e.AnthropometricData.ConvertUnits(c)
+	e.BodyMeasurements.ConvertUnits(c)
+}
+
+func (d *DailyAnthropometricData) ConvertUnits(c UnitConverter) {
+	d.AnthropometricData.ConvertUnits(c)
+	d.BodyMeasurements.ConvertUnits(c)
+
+	for i := range d.Entries {
+		d.Entries[i].ConvertUnits(c)
+	}
+}
+
+func (p *AnthropometricPatch) ConvertUnits(c UnitConverter) {
+	c.Mass(p.Weight)
+	c.Mass(p.MuscleMass.Value)
+	c.Mass(p.FatMass.Value)
+	c.Mass(p.BoneMass.Value)
+
+	if p.Circumferences.Value != nil {
+		for _, field := range p.Circumferences.Value.Fields() {
+			c.Length(*field)
+		}
+	}
+}
+
+func (d *BaseFixedUserData) ConvertUnits(c UnitConverter) {
+	c.WholeLength(&d.Height)
+}
+
+func (h *HeightRecord) ConvertUnits(c UnitConverter) {
+	c.WholeLength(&h.Height)
+}
+
+func (d *FixedUserData) ConvertUnits(c UnitConverter) {
+	c.WholeLength(&d.Height)
+}
+
+// ConvertUnits converts the masses of body composition objectives, the targets of the rest of
+// them don't depend on the unit system
+func (d *ObjectiveData) ConvertUnits(c UnitConverter) {
+	d.AnthropometricData.ConvertUnits(c)
+
+	if d.Type == ObjectiveBodyComposition {
+		c.Mass(d.FinalValue)
+
+		if d.Progress != nil {
+			c.Mass(d.Progress.Start)
+			c.Mass(&d.Progress.Current)
+			c.Mass(&d.Progress.Target)
+		}
+	}
+
+	if d.Feasibility != nil {
+		c.Mass(&d.Feasibility.WeeklyRate)
+		c.Mass(d.Feasibility.MuscleWeeklyRate)
+	}
+}
+
+func (m *Milestone) ConvertUnits(c UnitConverter) {
+	c.Mass(&m.Weight)
+}
@@ -0,0 +1,71 @@
+package model
+
+// WebhookDTO is the request body of a webhook subscription
+type WebhookDTO struct {
+	URL string `json:"url" binding:"required,url,max=2048"`
+	// Secret signs the deliveries of the subscription, it's never returned
+	Secret     string   `json:"secret,omitempty" binding:"required,min=16,max=255"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,oneof=anthropometric.recorded exercise.created exercise.updated exercise.deleted routine.created routine.deleted objective.achieved objective.expired"`
+}
+
+// Webhook is a subscription of another service to the events of this one
+type Webhook struct {
+	ID  uint64 `json:"id"`
+	URL string `json:"url"`
+	// Secret is only used to sign the deliveries, the API never returns it
+	Secret     string   `json:"-"`
+	EventTypes []string `json:"event_types" gorm:"-"`
+	// EventTypesList is EventTypes as stored, separated by commas
+	EventTypesList string `json:"-" gorm:"column:event_types"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
+
+// DeliveryStatus is the state of the delivery of an event to a webhook
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are sent once their next_attempt_at passes
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySending deliveries are being sent by a worker, they are taken over by another one
+	// if their next_attempt_at passes before the attempt finishes
+	DeliverySending DeliveryStatus = "sending"
+	// DeliveryDelivered deliveries were answered with a 2xx status
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead deliveries ran out of attempts, they are only sent again if retried manually
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// WebhookDelivery is the delivery of an event to a webhook
+type WebhookDelivery struct {
+	ID        uint64 `json:"id"`
+	WebhookID uint64 `json:"webhook_id"`
+	EventID   string `json:"event_id"`
+	EventType string `json:"event_type"`
+	// Payload is the body posted to the webhook
+	Payload       string         `json:"payload"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      uint           `json:"attempts"`
+	NextAttemptAt string         `json:"next_attempt_at"`
+	// LastStatusCode is the status the webhook answered the last attempt with, nil if it didn't answer
+	LastStatusCode *int    `json:"last_status_code"`
+	LastError      *string `json:"last_error"`
+	CreatedAt      string  `json:"created_at"`
+	DeliveredAt    *string `json:"delivered_at"`
+}
+
+// PendingDelivery is a delivery due to be sent, with the URL and secret of its webhook
+type PendingDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// WebhookEvent is the body posted to the webhooks
+type WebhookEvent struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	UserID     string `json:"user_id"`
+	OccurredAt string `json:"occurred_at"`
+	Data       any    `json:"data"`
+}
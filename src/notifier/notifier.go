@@ -0,0 +1,105 @@
+// Package notifier delivers notifications to the users. The service only builds them, the
+// delivery is done by an implementation of Notifier chosen with the NOTIFIER environment variable.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+)
+
+// Notifier sends a notification to its user. A returned error makes the delivery be retried,
+// so the same notification, identified by its key, may be sent more than once.
+type Notifier interface {
+	Notify(ctx context.Context, notification model.Notification) error
+}
+
+// LogNotifier writes the notifications to the log instead of sending them
+type LogNotifier struct{}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	slog.InfoContext(
+		ctx, "Notification",
+		"user_id", notification.UserID, "code", notification.Code, "params", notification.Params, "key", notification.Key,
+	)
+
+	return nil
+}
+
+// WebhookNotifier posts the notifications as JSON to a URL, e.g. the one of the push service
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Notify fails unless the webhook answers with a 2xx status
+func (n *WebhookNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", notification.Key)
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("the webhook answered with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// defaultWebhookTimeout bounds the requests of the webhook notifier if NOTIFIER_WEBHOOK_TIMEOUT isn't set
+const defaultWebhookTimeout = 10 * time.Second
+
+// FromEnv returns the notifier set with NOTIFIER: "log", the default, or "webhook", which
+// posts to NOTIFIER_WEBHOOK_URL with a timeout of NOTIFIER_WEBHOOK_TIMEOUT.
+func FromEnv() (Notifier, error) {
+	switch kind := os.Getenv("NOTIFIER"); kind {
+	case "", "log":
+		return &LogNotifier{}, nil
+	case "webhook":
+		url := os.Getenv("NOTIFIER_WEBHOOK_URL")
+		if url == "" {
+			return nil, fmt.Errorf("NOTIFIER_WEBHOOK_URL must be set to use the webhook notifier")
+		}
+
+		timeout := defaultWebhookTimeout
+		if value := os.Getenv("NOTIFIER_WEBHOOK_TIMEOUT"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid NOTIFIER_WEBHOOK_TIMEOUT %q", value)
+			}
+			timeout = parsed
+		}
+
+		return NewWebhookNotifier(url, timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q, it must be log or webhook", kind)
+	}
+}
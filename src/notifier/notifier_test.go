@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	notification := model.Notification{
+		UserID: "1",
+		Code:   "reminder.missed_weigh_in",
+		Params: map[string]string{"days": "3"},
+		Key:    "reminder-1-0",
+	}
+
+	t.Run("Posts the notification as JSON", func(t *testing.T) {
+		var received model.Notification
+		var key string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key = r.Header.Get("Idempotency-Key")
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		err := NewWebhookNotifier(server.URL, time.Second).Notify(context.Background(), notification)
+		assert.NoError(t, err)
+		assert.Equal(t, notification, received)
+		assert.Equal(t, notification.Key, key)
+	})
+
+	t.Run("Fails if the webhook doesn't answer with a 2xx status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		err := NewWebhookNotifier(server.URL, time.Second).Notify(context.Background(), notification)
+		assert.Error(t, err)
+	})
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Run("Logs the notifications by default", func(t *testing.T) {
+		t.Setenv("NOTIFIER", "")
+
+		n, err := FromEnv()
+		assert.NoError(t, err)
+		assert.IsType(t, &LogNotifier{}, n)
+	})
+
+	t.Run("The webhook notifier needs a URL", func(t *testing.T) {
+		t.Setenv("NOTIFIER", "webhook")
+		t.Setenv("NOTIFIER_WEBHOOK_URL", "")
+
+		_, err := FromEnv()
+		assert.Error(t, err)
+
+		t.Setenv("NOTIFIER_WEBHOOK_URL", "http://push/notifications")
+		n, err := FromEnv()
+		assert.NoError(t, err)
+		assert.IsType(t, &WebhookNotifier{}, n)
+	})
+
+	t.Run("Rejects unknown notifiers", func(t *testing.T) {
+		t.Setenv("NOTIFIER", "pigeon")
+
+		_, err := FromEnv()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,159 @@
+// Package outbox relays the domain events stored in the outbox table to the publishers chosen
+// with the OUTBOX_PUBLISHERS environment variable. The events are stored in the same transaction
+// as the change they are about, so they are published at least once after it's committed, and
+// never if it's rolled back.
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/events"
+	"github.com/NutriPocket/ProgressService/model"
+)
+
+// EventIDHeader is the header of the ID of the event posted by the HTTP publisher, its
+// consumers use it to discard the events delivered twice
+const EventIDHeader = "Idempotency-Key"
+
+// Publisher publishes an event relayed from the outbox. A returned error makes the event, and
+// the ones stored after it, be relayed again later, so the same event, identified by its ID, may
+// be published more than once.
+type Publisher interface {
+	Publish(ctx context.Context, event events.Event) error
+}
+
+// PublisherFunc adapts a function to a Publisher
+type PublisherFunc func(ctx context.Context, event events.Event) error
+
+func (f PublisherFunc) Publish(ctx context.Context, event events.Event) error {
+	return f(ctx, event)
+}
+
+// BusPublisher dispatches the events to the handlers subscribed to the bus of the process
+type BusPublisher struct{}
+
+func (p *BusPublisher) Publish(ctx context.Context, event events.Event) error {
+	events.Publish(ctx, event)
+	return nil
+}
+
+// HTTPPublisher posts the events as JSON to a URL, e.g. the HTTP bridge of a message broker
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPPublisher(url string, timeout time.Duration) *HTTPPublisher {
+	return &HTTPPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Publish fails unless the URL answers with a 2xx status
+func (p *HTTPPublisher) Publish(ctx context.Context, event events.Event) error {
+	body, err := json.Marshal(model.WebhookEvent{
+		ID:         event.ID,
+		Type:       event.Type,
+		UserID:     event.UserID,
+		OccurredAt: event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		Data:       event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, event.ID)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("the publisher URL answered with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// EventOf returns the event stored in the outbox, with its JSON payload as a json.RawMessage
+func EventOf(stored model.OutboxEvent) (events.Event, error) {
+	occurredAt, err := time.Parse(time.RFC3339Nano, stored.OccurredAt)
+	if err != nil {
+		return events.Event{}, err
+	}
+
+	return events.Event{
+		ID:         stored.EventID,
+		Type:       stored.EventType,
+		UserID:     stored.UserID,
+		OccurredAt: occurredAt,
+		Payload:    json.RawMessage(stored.Payload),
+	}, nil
+}
+
+// defaultPublishers are the publishers used if OUTBOX_PUBLISHERS isn't set
+const defaultPublishers = "bus,webhooks"
+
+// defaultHTTPTimeout bounds the requests of the HTTP publisher if OUTBOX_HTTP_TIMEOUT isn't set
+const defaultHTTPTimeout = 10 * time.Second
+
+// FromEnv returns the publishers listed, separated by commas, in OUTBOX_PUBLISHERS: "bus", which
+// dispatches the events within the process, "http", which posts them to OUTBOX_HTTP_URL with a
+// timeout of OUTBOX_HTTP_TIMEOUT, or any of the names of named. It defaults to "bus,webhooks".
+func FromEnv(named map[string]Publisher) ([]Publisher, error) {
+	list := os.Getenv("OUTBOX_PUBLISHERS")
+	if list == "" {
+		list = defaultPublishers
+	}
+
+	publishers := make([]Publisher, 0)
+	for _, name := range strings.Split(list, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "":
+			continue
+		case "bus":
+			publishers = append(publishers, &BusPublisher{})
+		case "http":
+			url := os.Getenv("OUTBOX_HTTP_URL")
+			if url == "" {
+				return nil, fmt.Errorf("OUTBOX_HTTP_URL must be set to use the http publisher")
+			}
+
+			timeout := defaultHTTPTimeout
+			if value := os.Getenv("OUTBOX_HTTP_TIMEOUT"); value != "" {
+				parsed, err := time.ParseDuration(value)
+				if err != nil || parsed <= 0 {
+					return nil, fmt.Errorf("invalid OUTBOX_HTTP_TIMEOUT %q", value)
+				}
+				timeout = parsed
+			}
+
+			publishers = append(publishers, NewHTTPPublisher(url, timeout))
+		default:
+			publisher, ok := named[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown outbox publisher %q", name)
+			}
+
+			publishers = append(publishers, publisher)
+		}
+	}
+
+	return publishers, nil
+}
@@ -0,0 +1,111 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/events"
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventOf(t *testing.T) {
+	t.Run("Keeps the JSON payload as is", func(t *testing.T) {
+		event, err := EventOf(model.OutboxEvent{
+			ID:         1,
+			EventID:    "abc",
+			EventType:  events.ExerciseCreated,
+			UserID:     "1",
+			Payload:    `{"id":1}`,
+			OccurredAt: "2025-05-01T10:00:00.5Z",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", event.ID)
+		assert.Equal(t, events.ExerciseCreated, event.Type)
+		assert.Equal(t, time.Date(2025, 5, 1, 10, 0, 0, 5e8, time.UTC), event.OccurredAt.UTC())
+		assert.Equal(t, json.RawMessage(`{"id":1}`), event.Payload)
+	})
+
+	t.Run("Fails if the date can't be parsed", func(t *testing.T) {
+		_, err := EventOf(model.OutboxEvent{OccurredAt: "yesterday"})
+		assert.Error(t, err)
+	})
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	event := events.Event{
+		ID:         "abc",
+		Type:       events.ExerciseCreated,
+		UserID:     "1",
+		OccurredAt: time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC),
+		Payload:    json.RawMessage(`{"id":1}`),
+	}
+
+	t.Run("Posts the event as JSON with its ID", func(t *testing.T) {
+		var received map[string]any
+		var id string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id = r.Header.Get(EventIDHeader)
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		err := NewHTTPPublisher(server.URL, time.Second).Publish(context.Background(), event)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", id)
+		assert.Equal(t, "exercise.created", received["type"])
+		assert.Equal(t, "2025-05-01T10:00:00Z", received["occurred_at"])
+		assert.Equal(t, map[string]any{"id": float64(1)}, received["data"])
+	})
+
+	t.Run("Fails if the URL doesn't answer with a 2xx status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		err := NewHTTPPublisher(server.URL, time.Second).Publish(context.Background(), event)
+		assert.Error(t, err)
+	})
+}
+
+func TestFromEnv(t *testing.T) {
+	webhooks := PublisherFunc(func(ctx context.Context, event events.Event) error { return nil })
+	named := map[string]Publisher{"webhooks": webhooks}
+
+	t.Run("Publishes to the bus and the webhooks by default", func(t *testing.T) {
+		t.Setenv("OUTBOX_PUBLISHERS", "")
+
+		publishers, err := FromEnv(named)
+		assert.NoError(t, err)
+		assert.Len(t, publishers, 2)
+		assert.IsType(t, &BusPublisher{}, publishers[0])
+		assert.IsType(t, PublisherFunc(nil), publishers[1])
+	})
+
+	t.Run("The http publisher needs a URL", func(t *testing.T) {
+		t.Setenv("OUTBOX_PUBLISHERS", "http")
+		t.Setenv("OUTBOX_HTTP_URL", "")
+
+		_, err := FromEnv(named)
+		assert.Error(t, err)
+
+		t.Setenv("OUTBOX_HTTP_URL", "http://broker/topics/progress")
+		publishers, err := FromEnv(named)
+		assert.NoError(t, err)
+		assert.Len(t, publishers, 1)
+		assert.IsType(t, &HTTPPublisher{}, publishers[0])
+	})
+
+	t.Run("Rejects unknown publishers", func(t *testing.T) {
+		t.Setenv("OUTBOX_PUBLISHERS", "bus, pigeon")
+
+		_, err := FromEnv(named)
+		assert.Error(t, err)
+	})
+}
@@ -2,19 +2,29 @@
 package repository
 
 import (
+	"context"
+	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/events"
 	"github.com/NutriPocket/ProgressService/model"
+	"gorm.io/gorm"
 )
 
 // IAnthropometricRepository is an interface that contains the methods that will implement a repository struct that interact with the users table.
 type IAnthropometricRepository interface {
-	CreateData(data *model.AnthropometricData) (model.AnthropometricData, error)
-	ReplaceTodayData(data *model.AnthropometricData) (model.AnthropometricData, error)
-	GetDataByUserIdAndDate(userId string, date string, data *model.AnthropometricData) error
-	GetTodayDataByUserId(userId string, data *model.AnthropometricData) error
-	GetAllDataByUserId(userId string, params *model.GetAnthropometricParams) ([]model.AnthropometricData, error)
+	CreateEntry(ctx context.Context, data *model.AnthropometricEntry) (model.AnthropometricEntry, error)
+	ReplaceEntry(ctx context.Context, data *model.AnthropometricEntry) (model.AnthropometricEntry, error)
+	GetEntryById(ctx context.Context, userId string, id uint64, data *model.AnthropometricEntry) error
+	GetEntriesByUserIdAndDate(ctx context.Context, userId string, date string) ([]model.AnthropometricEntry, error)
+	GetAllDataByUserId(ctx context.Context, userId string, params *model.GetAnthropometricParams) (model.Page[model.AnthropometricEntry], error)
+	DeleteEntry(ctx context.Context, userId string, id uint64) error
+	DeleteDataByDate(ctx context.Context, userId string, date string) error
+	GetWeightAt(ctx context.Context, userId string, at time.Time) (*float32, error)
+	GetMeasurementTimesBetween(ctx context.Context, userId string, from time.Time, to time.Time) ([]time.Time, error)
+	GetLastMeasurementTime(ctx context.Context, userId string) (*time.Time, error)
 }
 
 type AnthropometricRepository struct {
@@ -27,7 +37,7 @@ func NewAnthropometricRepository(db IDatabase) (*AnthropometricRepository, error
 	if db == nil {
 		db, err = database.GetPoolConnection()
 		if err != nil {
-			log.Errorf("Failed to connect to database")
+			slog.Error("Failed to connect to database", "error", err)
 			return nil, err
 		}
 	}
@@ -37,94 +47,457 @@ func NewAnthropometricRepository(db IDatabase) (*AnthropometricRepository, error
 	}, nil
 }
 
-func (r *AnthropometricRepository) GetTodayDataByUserId(userId string, data *model.AnthropometricData) error {
-	var date string
-	return r.GetDataByUserIdAndDate(userId, date, data)
+// anthropometricColumns are the columns of an anthropometric entry joined with its body measurements
+const anthropometricColumns = `a.id, a.user_id, a.weight, a.muscle_mass, a.fat_mass, a.bone_mass, a.context, a.created_at,
+		m.waist, m.hip, m.neck, m.chest, m.arm, m.thigh,
+		m.triceps_skinfold, m.biceps_skinfold, m.subscapular_skinfold, m.suprailiac_skinfold, m.abdominal_skinfold, m.thigh_skinfold`
+
+// anthropometricRow is a row of anthropometricColumns
+type anthropometricRow struct {
+	ID uint64
+	model.AnthropometricData
+	Context *string
+
+	Waist, Hip, Neck, Chest, Arm, Thigh *float32
+
+	TricepsSkinfold, BicepsSkinfold, SubscapularSkinfold *float32
+	SuprailiacSkinfold, AbdominalSkinfold, ThighSkinfold *float32
 }
 
-func (r *AnthropometricRepository) CreateData(data *model.AnthropometricData) (model.AnthropometricData, error) {
-	res := r.db.Exec(`
-		INSERT INTO anthropometric_data (user_id, weight, muscle_mass, fat_mass, bone_mass)
-		VALUES (?, ?, ?, ?, ?);
-	`,
-		data.UserID, data.Weight, data.MuscleMass, data.FatMass, data.BoneMass,
-	)
+func (r *anthropometricRow) entry() model.AnthropometricEntry {
+	entry := model.AnthropometricEntry{
+		ID:                 r.ID,
+		AnthropometricData: r.AnthropometricData,
+		Context:            r.Context,
+	}
 
-	if res.Error != nil {
-		log.Errorf("Failed to create anthropometric data for user %s: %v", data.UserID, res.Error)
-		return model.AnthropometricData{}, res.Error
+	circumferences := model.Circumferences{
+		Waist: r.Waist, Hip: r.Hip, Neck: r.Neck, Chest: r.Chest, Arm: r.Arm, Thigh: r.Thigh,
+	}
+	if anySet(circumferences.Fields()) {
+		entry.Circumferences = &circumferences
 	}
 
-	var ret model.AnthropometricData
-	err := r.GetTodayDataByUserId(data.UserID, &ret)
+	skinfolds := model.Skinfolds{
+		Triceps: r.TricepsSkinfold, Biceps: r.BicepsSkinfold, Subscapular: r.SubscapularSkinfold,
+		Suprailiac: r.SuprailiacSkinfold, Abdominal: r.AbdominalSkinfold, Thigh: r.ThighSkinfold,
+	}
+	if anySet(skinfolds.Fields()) {
+		entry.Skinfolds = &skinfolds
+	}
 
-	return ret, err
+	return entry
 }
 
-func (r *AnthropometricRepository) ReplaceTodayData(data *model.AnthropometricData) (model.AnthropometricData, error) {
-	res := r.db.Exec(`
-		UPDATE anthropometric_data
-		SET weight = ?, muscle_mass = ?, fat_mass = ?, bone_mass = ?
-		WHERE user_id = ? 
-			AND DATE(created_at) = CURDATE();
-	`,
-		data.Weight, data.MuscleMass, data.FatMass, data.BoneMass, data.UserID,
-	)
+func anySet(fields []**float32) bool {
+	for _, field := range fields {
+		if *field != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func entriesOf(rows []anthropometricRow) []model.AnthropometricEntry {
+	entries := make([]model.AnthropometricEntry, len(rows))
+	for i := range rows {
+		entries[i] = rows[i].entry()
+	}
 
+	return entries
+}
+
+// saveBodyMeasurements replaces the body measurements of the entry with the ones in data
+func saveBodyMeasurements(tx *gorm.DB, id uint64, data *model.BodyMeasurements) error {
+	res := tx.Exec(`DELETE FROM body_measurements WHERE anthropometric_id = ?;`, id)
 	if res.Error != nil {
-		log.Errorf("Failed to update anthropometric data for user %s: %v", data.UserID, res.Error)
-		return model.AnthropometricData{}, res.Error
+		return res.Error
+	}
+
+	if data.Circumferences == nil && data.Skinfolds == nil {
+		return nil
+	}
+
+	circumferences := data.Circumferences
+	if circumferences == nil {
+		circumferences = &model.Circumferences{}
+	}
+
+	skinfolds := data.Skinfolds
+	if skinfolds == nil {
+		skinfolds = &model.Skinfolds{}
+	}
+
+	return tx.Exec(`
+		INSERT INTO body_measurements (
+			anthropometric_id, waist, hip, neck, chest, arm, thigh,
+			triceps_skinfold, biceps_skinfold, subscapular_skinfold, suprailiac_skinfold, abdominal_skinfold, thigh_skinfold
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+	`,
+		id, circumferences.Waist, circumferences.Hip, circumferences.Neck, circumferences.Chest, circumferences.Arm, circumferences.Thigh,
+		skinfolds.Triceps, skinfolds.Biceps, skinfolds.Subscapular, skinfolds.Suprailiac, skinfolds.Abdominal, skinfolds.Thigh,
+	).Error
+}
+
+func (r *AnthropometricRepository) CreateEntry(ctx context.Context, data *model.AnthropometricEntry) (model.AnthropometricEntry, error) {
+	ctx, done := instrument(ctx, "anthropometric", "CreateEntry")
+	defer done()
+
+	var lastID uint64
+	var ret model.AnthropometricEntry
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			INSERT INTO anthropometric_data (user_id, weight, muscle_mass, fat_mass, bone_mass, context)
+			VALUES (?, ?, ?, ?, ?, ?);
+		`,
+			data.UserID, data.Weight, data.MuscleMass, data.FatMass, data.BoneMass, data.Context,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res := tx.Raw("SELECT LAST_INSERT_ID()").Scan(&lastID); res.Error != nil {
+			return res.Error
+		}
+
+		if err := saveBodyMeasurements(tx, lastID, &data.BodyMeasurements); err != nil {
+			return err
+		}
+
+		return addEntryEvent(tx, data.UserID, lastID, &ret)
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create anthropometric data", "user_id", data.UserID, "error", err)
+		return model.AnthropometricEntry{}, err
 	}
 
-	var ret model.AnthropometricData
-	err := r.GetTodayDataByUserId(data.UserID, &ret)
+	return ret, nil
+}
+
+func (r *AnthropometricRepository) ReplaceEntry(ctx context.Context, data *model.AnthropometricEntry) (model.AnthropometricEntry, error) {
+	ctx, done := instrument(ctx, "anthropometric", "ReplaceEntry")
+	defer done()
+
+	var ret model.AnthropometricEntry
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			UPDATE anthropometric_data
+			SET weight = ?, muscle_mass = ?, fat_mass = ?, bone_mass = ?, context = ?
+			WHERE id = ?
+				AND user_id = ?;
+		`,
+			data.Weight, data.MuscleMass, data.FatMass, data.BoneMass, data.Context, data.ID, data.UserID,
+		)
 
-	return ret, err
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if err := saveBodyMeasurements(tx, data.ID, &data.BodyMeasurements); err != nil {
+			return err
+		}
+
+		return addEntryEvent(tx, data.UserID, data.ID, &ret)
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update anthropometric data", "user_id", data.UserID, "id", data.ID, "error", err)
+		return model.AnthropometricEntry{}, err
+	}
+
+	return ret, nil
 }
 
-func (r *AnthropometricRepository) GetDataByUserIdAndDate(userId string, date string, data *model.AnthropometricData) error {
-	if date == "" {
-		date = time.Now().Format("2006-01-02")
+// addEntryEvent reads the stored entry into data and records its event, within the transaction
+// that adds or replaces it
+func addEntryEvent(tx *gorm.DB, userId string, id uint64, data *model.AnthropometricEntry) error {
+	if err := getEntry(tx, userId, id, data); err != nil {
+		return err
 	}
-	res := r.db.Raw(`
-		SELECT user_id, weight, muscle_mass, fat_mass, bone_mass, created_at
-		FROM anthropometric_data 
-		WHERE user_id = ? 
-			AND DATE(created_at) = ?
+
+	return addOutboxEvent(tx, events.AnthropometricRecorded, userId, data)
+}
+
+func (r *AnthropometricRepository) GetEntryById(ctx context.Context, userId string, id uint64, data *model.AnthropometricEntry) error {
+	ctx, done := instrument(ctx, "anthropometric", "GetEntryById")
+	defer done()
+
+	return getEntry(r.db.WithContext(ctx), userId, id, data)
+}
+
+// getEntry reads the entry of the user with db, which may be a transaction
+func getEntry(db *gorm.DB, userId string, id uint64, data *model.AnthropometricEntry) error {
+	var row anthropometricRow
+	res := db.Raw(`
+		SELECT `+anthropometricColumns+`
+		FROM anthropometric_data a
+		LEFT JOIN body_measurements m ON m.anthropometric_id = a.id
+		WHERE a.id = ?
+			AND a.user_id = ?
 		LIMIT 1;`,
-		userId, date,
-	).Scan(&data)
+		id, userId,
+	).Scan(&row)
 
 	if res.Error != nil {
 		return res.Error
 	}
 
+	*data = row.entry()
 	if data.UserID == "" {
 		return &model.NotFoundError{
-			Title:  "Anthropometric data not found",
-			Detail: "No anthropometric data not found for user " + userId + " on date " + date,
+			Code:   "anthropometric.entry_not_found",
+			Params: map[string]string{"userId": userId, "id": strconv.FormatUint(id, 10)},
+		}
+	}
+
+	return nil
+}
+
+// GetEntriesByUserIdAndDate returns the measurements of the user in a date, from the first to the last one.
+// It returns an empty slice if there are none.
+func (r *AnthropometricRepository) GetEntriesByUserIdAndDate(ctx context.Context, userId string, date string) ([]model.AnthropometricEntry, error) {
+	ctx, done := instrument(ctx, "anthropometric", "GetEntriesByUserIdAndDate")
+	defer done()
+
+	var rows []anthropometricRow
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT `+anthropometricColumns+`
+		FROM anthropometric_data a
+		LEFT JOIN body_measurements m ON m.anthropometric_id = a.id
+		WHERE a.user_id = ?
+			AND DATE(a.created_at) = ?
+		ORDER BY a.created_at ASC, a.id ASC;`,
+		userId, date,
+	).Scan(&rows)
+
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	return entriesOf(rows), nil
+}
+
+func (r *AnthropometricRepository) GetAllDataByUserId(ctx context.Context, userId string, params *model.GetAnthropometricParams) (model.Page[model.AnthropometricEntry], error) {
+	ctx, done := instrument(ctx, "anthropometric", "GetAllDataByUserId")
+	defer done()
+
+	var rows []anthropometricRow
+
+	after, afterArgs, orderBy, err := keyset([]string{"a.created_at", "a.id"}, params.PageParams)
+	if err != nil {
+		return model.Page[model.AnthropometricEntry]{}, err
+	}
+
+	args := []any{userId, params.StartDate, params.StartDate, params.EndDate, params.EndDate}
+	args = append(args, afterArgs...)
+	args = append(args, params.Limit+1)
+
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT `+anthropometricColumns+`
+		FROM anthropometric_data a
+		LEFT JOIN body_measurements m ON m.anthropometric_id = a.id
+		WHERE a.user_id = ?
+			AND (a.created_at >= ? OR ? IS NULL)
+			AND (a.created_at <= ? OR ? IS NULL)
+			AND `+after+`
+		ORDER BY `+orderBy+`
+		LIMIT ?;
+	`, args...,
+	).Scan(&rows)
+
+	if res.Error != nil {
+		return model.Page[model.AnthropometricEntry]{}, res.Error
+	}
+
+	return pageOf(entriesOf(rows), params.PageParams, func(d model.AnthropometricEntry) []string {
+		return []string{cursorTime(d.CreatedAt), strconv.FormatUint(d.ID, 10)}
+	}), nil
+}
+
+func (r *AnthropometricRepository) DeleteEntry(ctx context.Context, userId string, id uint64) error {
+	ctx, done := instrument(ctx, "anthropometric", "DeleteEntry")
+	defer done()
+
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			DELETE FROM anthropometric_data
+			WHERE id = ?
+				AND user_id = ?;
+		`,
+			id, userId,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		deleted = res.RowsAffected
+		if deleted == 0 {
+			return nil
+		}
+
+		return tx.Exec(`DELETE FROM body_measurements WHERE anthropometric_id = ?;`, id).Error
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete anthropometric data", "user_id", userId, "id", id, "error", err)
+		return err
+	}
+
+	if deleted == 0 {
+		return &model.NotFoundError{
+			Code:   "anthropometric.entry_not_found",
+			Params: map[string]string{"userId": userId, "id": strconv.FormatUint(id, 10)},
+		}
+	}
+
+	return nil
+}
+
+func (r *AnthropometricRepository) DeleteDataByDate(ctx context.Context, userId string, date string) error {
+	ctx, done := instrument(ctx, "anthropometric", "DeleteDataByDate")
+	defer done()
+
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			DELETE FROM body_measurements
+			WHERE anthropometric_id IN (
+				SELECT id
+				FROM anthropometric_data
+				WHERE user_id = ?
+					AND DATE(created_at) = ?
+			);
+		`,
+			userId, date,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		res = tx.Exec(`
+			DELETE FROM anthropometric_data
+			WHERE user_id = ?
+				AND DATE(created_at) = ?;
+		`,
+			userId, date,
+		)
+
+		deleted = res.RowsAffected
+		return res.Error
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete anthropometric data", "user_id", userId, "date", date, "error", err)
+		return err
+	}
+
+	if deleted == 0 {
+		return &model.NotFoundError{
+			Code:   "anthropometric.not_found",
+			Params: map[string]string{"userId": userId, "date": date},
 		}
 	}
 
 	return nil
 }
 
-func (r *AnthropometricRepository) GetAllDataByUserId(userId string, params *model.GetAnthropometricParams) ([]model.AnthropometricData, error) {
-	var data []model.AnthropometricData = make([]model.AnthropometricData, 0)
+// GetWeightAt returns the weight of the last measurement of the user taken at or before at,
+// or of the first one taken after it if there's none before. It returns nil if the user has
+// no measurements.
+func (r *AnthropometricRepository) GetWeightAt(ctx context.Context, userId string, at time.Time) (*float32, error) {
+	ctx, done := instrument(ctx, "anthropometric", "GetWeightAt")
+	defer done()
 
-	res := r.db.Raw(`
-		SELECT user_id, weight, muscle_mass, fat_mass, bone_mass, created_at
-		FROM anthropometric_data 
-		WHERE user_id = ? 
-			AND (created_at >= ? OR ? IS NULL)
-			AND (created_at <= ? OR ? IS NULL)
-		ORDER BY created_at DESC;
-	`, userId, params.StartDate, params.StartDate, params.EndDate, params.EndDate,
-	).Scan(&data)
+	var weights []float32
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT weight
+		FROM anthropometric_data
+		WHERE user_id = ?
+			AND created_at <= ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1;`,
+		userId, at.UTC(),
+	).Scan(&weights)
+
+	if res.Error == nil && len(weights) == 0 {
+		res = r.db.WithContext(ctx).Raw(`
+			SELECT weight
+			FROM anthropometric_data
+			WHERE user_id = ?
+			ORDER BY created_at ASC, id ASC
+			LIMIT 1;`,
+			userId,
+		).Scan(&weights)
+	}
 
 	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to get weight", "user_id", userId, "error", res.Error)
 		return nil, res.Error
 	}
 
-	return data, nil
+	if len(weights) == 0 {
+		return nil, nil
+	}
+
+	return &weights[0], nil
+}
+
+// GetMeasurementTimesBetween returns when the measurements of the user taken since from and
+// before to were taken
+func (r *AnthropometricRepository) GetMeasurementTimesBetween(ctx context.Context, userId string, from time.Time, to time.Time) ([]time.Time, error) {
+	ctx, done := instrument(ctx, "anthropometric", "GetMeasurementTimesBetween")
+	defer done()
+
+	times := make([]time.Time, 0)
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT created_at
+		FROM anthropometric_data
+		WHERE user_id = ?
+			AND created_at >= ?
+			AND created_at < ?
+		ORDER BY created_at ASC;`,
+		userId, from.UTC(), to.UTC(),
+	).Scan(&times)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to get measurement times", "user_id", userId, "error", res.Error)
+		return nil, res.Error
+	}
+
+	return times, nil
+}
+
+// GetLastMeasurementTime returns when the user measured the weight for the last time, or nil if
+// the user never did
+func (r *AnthropometricRepository) GetLastMeasurementTime(ctx context.Context, userId string) (*time.Time, error) {
+	ctx, done := instrument(ctx, "anthropometric", "GetLastMeasurementTime")
+	defer done()
+
+	var times []time.Time
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT created_at
+		FROM anthropometric_data
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT 1;`,
+		userId,
+	).Scan(&times)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to get the last measurement time", "user_id", userId, "error", res.Error)
+		return nil, res.Error
+	}
+
+	if len(times) == 0 {
+		return nil, nil
+	}
+
+	return &times[0], nil
 }
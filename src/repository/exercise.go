@@ -2,19 +2,26 @@
 package repository
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
 
 	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/events"
 	"github.com/NutriPocket/ProgressService/model"
+	"gorm.io/gorm"
 )
 
 // IExerciseRepository is an interface that contains the methods that will implement a repository struct that interact with the exercise_by_day table.
 type IExerciseRepository interface {
-	CreateExercise(data *model.ExerciseDTO) (model.ExerciseData, error)
-	GetExerciseById(id uint64, data *model.ExerciseData) error
-	GetExercisesByUserIdAndDate(userId string, date string) (model.AllExercisesInDay, error)
-	UpdateExercise(id uint64, data *model.ExerciseDTO) (model.ExerciseData, error)
-	DeleteExercise(id uint64) error
+	CreateExercise(ctx context.Context, data *model.ExerciseDTO) (model.ExerciseData, error)
+	GetExerciseById(ctx context.Context, id uint64, data *model.ExerciseData) error
+	GetExercisesByUserIdAndDate(ctx context.Context, userId string, date string, page model.PageParams) (model.AllExercisesInDay, *model.Cursor, error)
+	UpdateExercise(ctx context.Context, id uint64, data *model.ExerciseDTO, version *uint) (model.ExerciseData, error)
+	DeleteExercise(ctx context.Context, id uint64, version *uint) error
+	GetTotalsBetween(ctx context.Context, userId string, from time.Time, to time.Time) (model.ExerciseTotals, error)
 }
 
 type ExerciseRepository struct {
@@ -27,7 +34,7 @@ func NewExerciseRepository(db IDatabase) (*ExerciseRepository, error) {
 	if db == nil {
 		db, err = database.GetPoolConnection()
 		if err != nil {
-			log.Errorf("Failed to connect to database")
+			slog.Error("Failed to connect to database", "error", err)
 			return nil, err
 		}
 	}
@@ -37,36 +44,56 @@ func NewExerciseRepository(db IDatabase) (*ExerciseRepository, error) {
 	}, nil
 }
 
-func (r *ExerciseRepository) CreateExercise(data *model.ExerciseDTO) (model.ExerciseData, error) {
-	res := r.db.Exec(`
-        INSERT INTO exercise_by_day (user_id, exercise_name, calories_burned)
-        VALUES (?, ?, ?);
-    `,
-		data.UserID, data.ExerciseName, data.CaloriesBurned,
-	)
+func (r *ExerciseRepository) CreateExercise(ctx context.Context, data *model.ExerciseDTO) (model.ExerciseData, error) {
+	ctx, done := instrument(ctx, "exercise", "CreateExercise")
+	defer done()
 
-	if res.Error != nil {
-		log.Errorf("Failed to create exercise for user %s: %v", data.UserID, res.Error)
-		return model.ExerciseData{}, res.Error
-	}
+	var createdExercise model.ExerciseData
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			INSERT INTO exercise_by_day (user_id, exercise_name, calories_burned)
+			VALUES (?, ?, ?);
+		`,
+			data.UserID, data.ExerciseName, data.CaloriesBurned,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		// Get the last inserted ID
+		var lastID uint64
+		if res := tx.Raw("SELECT LAST_INSERT_ID()").Scan(&lastID); res.Error != nil {
+			return res.Error
+		}
+
+		// Retrieve the created exercise
+		if err := getExercise(tx, lastID, &createdExercise); err != nil {
+			return err
+		}
+
+		return addOutboxEvent(tx, events.ExerciseCreated, data.UserID, createdExercise)
+	})
 
-	// Get the last inserted ID
-	var lastID uint64
-	idRes := r.db.Raw("SELECT LAST_INSERT_ID()").Scan(&lastID)
-	if idRes.Error != nil {
-		log.Errorf("Failed to get last inserted ID: %v", idRes.Error)
-		return model.ExerciseData{}, idRes.Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create exercise", "user_id", data.UserID, "error", err)
+		return model.ExerciseData{}, err
 	}
 
-	// Retrieve the created exercise
-	var createdExercise model.ExerciseData
-	err := r.GetExerciseById(lastID, &createdExercise)
-	return createdExercise, err
+	return createdExercise, nil
 }
 
-func (r *ExerciseRepository) GetExerciseById(id uint64, data *model.ExerciseData) error {
-	res := r.db.Raw(`
-        SELECT id, user_id, exercise_name, calories_burned, created_at
+func (r *ExerciseRepository) GetExerciseById(ctx context.Context, id uint64, data *model.ExerciseData) error {
+	ctx, done := instrument(ctx, "exercise", "GetExerciseById")
+	defer done()
+
+	return getExercise(r.db.WithContext(ctx), id, data)
+}
+
+// getExercise reads the exercise with db, which may be a transaction
+func getExercise(db *gorm.DB, id uint64, data *model.ExerciseData) error {
+	res := db.Raw(`
+        SELECT id, user_id, exercise_name, calories_burned, version, created_at
         FROM exercise_by_day
         WHERE id = ?
         LIMIT 1;
@@ -80,73 +107,138 @@ func (r *ExerciseRepository) GetExerciseById(id uint64, data *model.ExerciseData
 
 	if data.ID == 0 {
 		return &model.NotFoundError{
-			Title:  "Exercise not found",
-			Detail: "No exercise found with ID " + fmt.Sprintf("%d", id),
+			Code:   "exercise.not_found",
+			Params: map[string]string{"id": fmt.Sprintf("%d", id)},
 		}
 	}
 
 	return nil
 }
 
-func (r *ExerciseRepository) UpdateExercise(id uint64, data *model.ExerciseDTO) (model.ExerciseData, error) {
-	// Update the exercise
-	res := r.db.Exec(`
-		UPDATE exercise_by_day
-		SET exercise_name = ?, calories_burned = ?
-		WHERE id = ?;
-	`,
-		data.ExerciseName, data.CaloriesBurned, id,
-	)
+// UpdateExercise replaces the exercise, only if it's still at version when it's not nil
+func (r *ExerciseRepository) UpdateExercise(ctx context.Context, id uint64, data *model.ExerciseDTO, version *uint) (model.ExerciseData, error) {
+	ctx, done := instrument(ctx, "exercise", "UpdateExercise")
+	defer done()
 
-	if res.Error != nil {
-		log.Errorf("Failed to update exercise with ID %d: %v", id, res.Error)
-		return model.ExerciseData{}, res.Error
+	var updatedExercise model.ExerciseData
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Update the exercise
+		res := tx.Exec(`
+			UPDATE exercise_by_day
+			SET exercise_name = ?, calories_burned = ?, version = version + 1
+			WHERE id = ? AND (? IS NULL OR version = ?);
+		`,
+			data.ExerciseName, data.CaloriesBurned, id, version, version,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 && version != nil {
+			return &model.PreconditionFailedError{
+				Code: "request.precondition_failed",
+			}
+		}
+
+		// Retrieve the updated exercise
+		if err := getExercise(tx, id, &updatedExercise); err != nil {
+			return err
+		}
+
+		return addOutboxEvent(tx, events.ExerciseUpdated, updatedExercise.UserID, updatedExercise)
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update exercise", "exercise_id", id, "error", err)
+		return model.ExerciseData{}, err
 	}
 
-	// Retrieve the updated exercise
-	var updatedExercise model.ExerciseData
-	err := r.GetExerciseById(id, &updatedExercise)
-	return updatedExercise, err
+	return updatedExercise, nil
 }
 
-func (r *ExerciseRepository) DeleteExercise(id uint64) error {
-	// Delete the exercise
-	res := r.db.Exec(`
-        DELETE FROM exercise_by_day
-        WHERE id = ?;
-    `,
-		id,
-	)
+// DeleteExercise deletes the exercise, only if it's still at version when it's not nil
+func (r *ExerciseRepository) DeleteExercise(ctx context.Context, id uint64, version *uint) error {
+	ctx, done := instrument(ctx, "exercise", "DeleteExercise")
+	defer done()
 
-	if res.Error != nil {
-		log.Errorf("Failed to delete exercise with ID %d: %v", id, res.Error)
-		return res.Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The deleted exercise is the payload of its event
+		var deletedExercise model.ExerciseData
+		if err := getExercise(tx, id, &deletedExercise); err != nil {
+			return err
+		}
+
+		// Delete the exercise
+		res := tx.Exec(`
+			DELETE FROM exercise_by_day
+			WHERE id = ? AND (? IS NULL OR version = ?);
+		`,
+			id, version, version,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 && version != nil {
+			return &model.PreconditionFailedError{
+				Code: "request.precondition_failed",
+			}
+		}
+
+		return addOutboxEvent(tx, events.ExerciseDeleted, deletedExercise.UserID, deletedExercise)
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete exercise", "exercise_id", id, "error", err)
+		return err
 	}
 
 	return nil
 }
 
-func (r *ExerciseRepository) GetExercisesByUserIdAndDate(userId string, date string) (model.AllExercisesInDay, error) {
-	// First, get all exercises for the day
-	var exercises []model.ExerciseData
-	res := r.db.Raw(`
+// GetExercisesByUserIdAndDate returns a page of the exercises of the user on the date, along with
+// the calories burned by all the exercises of the date, and the cursor of the next page if any
+func (r *ExerciseRepository) GetExercisesByUserIdAndDate(ctx context.Context, userId string, date string, page model.PageParams) (model.AllExercisesInDay, *model.Cursor, error) {
+	ctx, done := instrument(ctx, "exercise", "GetExercisesByUserIdAndDate")
+	defer done()
+
+	after, afterArgs, orderBy, err := keyset([]string{"created_at", "id"}, page)
+	if err != nil {
+		return model.AllExercisesInDay{}, nil, err
+	}
+
+	args := []any{userId, date}
+	args = append(args, afterArgs...)
+	args = append(args, page.Limit+1)
+
+	// First, get a page of the exercises of the day
+	exercises := make([]model.ExerciseData, 0)
+	res := r.db.WithContext(ctx).Raw(`
         SELECT id, user_id, exercise_name, calories_burned, created_at
         FROM exercise_by_day
         WHERE user_id = ?
         AND DATE(created_at) = ?
-        ORDER BY created_at ASC;
+        AND `+after+`
+        ORDER BY `+orderBy+`
+        LIMIT ?;
     `,
-		userId, date,
+		args...,
 	).Scan(&exercises)
 
 	if res.Error != nil {
-		log.Errorf("Failed to get exercises for user %s on date %s: %v", userId, date, res.Error)
-		return model.AllExercisesInDay{}, res.Error
+		slog.ErrorContext(ctx, "Failed to get exercises", "user_id", userId, "date", date, "error", res.Error)
+		return model.AllExercisesInDay{}, nil, res.Error
 	}
 
+	exercisesPage := pageOf(exercises, page, func(e model.ExerciseData) []string {
+		return []string{cursorTime(e.CreatedAt), strconv.FormatUint(e.ID, 10)}
+	})
+
 	// Then, calculate the total calories burned
 	var totalBurned float64
-	sumRes := r.db.Raw(`
+	sumRes := r.db.WithContext(ctx).Raw(`
         SELECT COALESCE(SUM(calories_burned), 0) as total
         FROM exercise_by_day
         WHERE user_id = ?
@@ -156,15 +248,40 @@ func (r *ExerciseRepository) GetExercisesByUserIdAndDate(userId string, date str
 	).Scan(&totalBurned)
 
 	if sumRes.Error != nil {
-		log.Errorf("Failed to calculate total calories burned for user %s on date %s: %v", userId, date, sumRes.Error)
-		return model.AllExercisesInDay{}, sumRes.Error
+		slog.ErrorContext(ctx, "Failed to calculate total calories burned", "user_id", userId, "date", date, "error", sumRes.Error)
+		return model.AllExercisesInDay{}, nil, sumRes.Error
 	}
 
 	// Construct the result object
 	result := model.AllExercisesInDay{
 		TotalBurned: totalBurned,
-		Exercises:   exercises,
+		Exercises:   exercisesPage.Items,
+	}
+
+	return result, exercisesPage.Next, nil
+}
+
+// GetTotalsBetween returns the calories burned and the number of exercises of the user done
+// since from and before to
+func (r *ExerciseRepository) GetTotalsBetween(ctx context.Context, userId string, from time.Time, to time.Time) (model.ExerciseTotals, error) {
+	ctx, done := instrument(ctx, "exercise", "GetTotalsBetween")
+	defer done()
+
+	var totals model.ExerciseTotals
+	res := r.db.WithContext(ctx).Raw(`
+        SELECT COALESCE(SUM(calories_burned), 0) AS calories, COUNT(*) AS count
+        FROM exercise_by_day
+        WHERE user_id = ?
+        AND created_at >= ?
+        AND created_at < ?;
+    `,
+		userId, from.UTC(), to.UTC(),
+	).Scan(&totals)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to get exercise totals", "user_id", userId, "error", res.Error)
+		return model.ExerciseTotals{}, res.Error
 	}
 
-	return result, nil
+	return totals, nil
 }
@@ -2,19 +2,23 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 
 	"github.com/NutriPocket/ProgressService/database"
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
 )
 
 // IFixedDataRepository is an interface that contains the methods that will implement a repository struct that interact with the users table.
 type IFixedDataRepository interface {
-	CreateData(data *model.BaseFixedUserData) (model.FixedUserData, error)
-	ReplaceData(data *model.BaseFixedUserData) (model.FixedUserData, error)
-	GetBaseFixedUserData(userId string, data *model.BaseFixedUserData) error
-	GetUserData(userId string, data *model.FixedUserData) error
+	CreateData(ctx context.Context, data *model.BaseFixedUserData) (model.FixedUserData, error)
+	ReplaceData(ctx context.Context, data *model.BaseFixedUserData, version *uint) (model.FixedUserData, error)
+	GetBaseFixedUserData(ctx context.Context, userId string, data *model.BaseFixedUserData) error
+	GetUserData(ctx context.Context, userId string, data *model.FixedUserData) error
+	GetHeightHistory(ctx context.Context, userId string) ([]model.HeightRecord, error)
 }
 
 type FixedDataRepository struct {
@@ -27,7 +31,7 @@ func NewFixedDataRepository(db IDatabase) (*FixedDataRepository, error) {
 	if db == nil {
 		db, err = database.GetPoolConnection()
 		if err != nil {
-			log.Errorf("Failed to connect to database")
+			slog.Error("Failed to connect to database", "error", err)
 			return nil, err
 		}
 	}
@@ -37,90 +41,190 @@ func NewFixedDataRepository(db IDatabase) (*FixedDataRepository, error) {
 	}, nil
 }
 
-func (r *FixedDataRepository) CreateData(data *model.BaseFixedUserData) (model.FixedUserData, error) {
-	res := r.db.Exec(`
-		INSERT INTO fixed_user_data (user_id, height, birthday)
-		VALUES (?, ?, ?);
-	`,
-		data.UserID, data.Height, data.Birthday,
-	)
+func (r *FixedDataRepository) CreateData(ctx context.Context, data *model.BaseFixedUserData) (model.FixedUserData, error) {
+	ctx, done := instrument(ctx, "fixed_data", "CreateData")
+	defer done()
 
-	if res.Error != nil {
-		log.Errorf("Failed to create fixed user data for user %s: %v", data.UserID, res.Error)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			INSERT INTO fixed_user_data (user_id, height, birthday, sex, units, time_zone)
+			VALUES (?, ?, ?, ?, ?, ?);
+		`,
+			data.UserID, data.Height, data.Birthday, data.Sex, data.Units, data.TimeZone,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		return tx.Exec(`
+			INSERT INTO height_history (user_id, height)
+			VALUES (?, ?);
+		`,
+			data.UserID, data.Height,
+		).Error
+	})
 
-		if errors.Is(res.Error, &mysql.MySQLError{Number: 1062}) {
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create fixed user data", "user_id", data.UserID, "error", err)
+
+		if errors.Is(err, &mysql.MySQLError{Number: 1062}) {
 			return model.FixedUserData{}, &model.ConflictError{
-				Detail: "User fixed data already exists for the user " + data.UserID,
-				Title:  "User fixed data already exists",
+				Code:   "fixed_data.conflict",
+				Params: map[string]string{"userId": data.UserID},
 			}
 		}
 
-		return model.FixedUserData{}, res.Error
+		return model.FixedUserData{}, err
 	}
 
 	var ret model.FixedUserData
-	err := r.GetUserData(data.UserID, &ret)
+	err = getUserData(r.db.WithContext(ctx), data.UserID, &ret)
 	return ret, err
 }
 
-func (r *FixedDataRepository) ReplaceData(data *model.BaseFixedUserData) (model.FixedUserData, error) {
-	res := r.db.Exec(`
-		UPDATE fixed_user_data
-		SET height = ?, birthday = ?
-		WHERE user_id = ?
-	`,
-		data.Height, data.Birthday, data.UserID,
-	)
+// ReplaceData replaces the fixed data of the user, only if it's still at version when it's not nil
+func (r *FixedDataRepository) ReplaceData(ctx context.Context, data *model.BaseFixedUserData, version *uint) (model.FixedUserData, error) {
+	ctx, done := instrument(ctx, "fixed_data", "ReplaceData")
+	defer done()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The version is increased first, so the row stays locked until the data is replaced
+		res := tx.Exec(`
+			UPDATE fixed_user_data
+			SET version = version + 1
+			WHERE user_id = ? AND (? IS NULL OR version = ?);
+		`,
+			data.UserID, version, version,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
 
-	if res.Error != nil {
-		log.Errorf("Failed to update fixed user data for user %s: %v", data.UserID, res.Error)
-		return model.FixedUserData{}, res.Error
+		if res.RowsAffected == 0 && version != nil {
+			return &model.PreconditionFailedError{
+				Code: "request.precondition_failed",
+			}
+		}
+
+		// Users registered before the history was kept get their previous height recorded as
+		// valid since they registered, so their older measurements keep using it
+		res = tx.Exec(`
+			INSERT INTO height_history (user_id, height, valid_from)
+			SELECT user_id, height, COALESCE(created_at, CURRENT_TIMESTAMP(6))
+			FROM fixed_user_data
+			WHERE user_id = ?
+				AND NOT EXISTS (SELECT 1 FROM height_history WHERE user_id = ?);
+		`,
+			data.UserID, data.UserID,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		// The height is only recorded in the history when it changes
+		res = tx.Exec(`
+			INSERT INTO height_history (user_id, height)
+			SELECT user_id, ?
+			FROM fixed_user_data
+			WHERE user_id = ?
+				AND height <> ?;
+		`,
+			data.Height, data.UserID, data.Height,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		return tx.Exec(`
+			UPDATE fixed_user_data
+			SET height = ?, birthday = ?, sex = ?, units = ?, time_zone = ?
+			WHERE user_id = ?
+		`,
+			data.Height, data.Birthday, data.Sex, data.Units, data.TimeZone, data.UserID,
+		).Error
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update fixed user data", "user_id", data.UserID, "error", err)
+		return model.FixedUserData{}, err
 	}
 
 	var ret model.FixedUserData
-	err := r.GetUserData(data.UserID, &ret)
+	err = getUserData(r.db.WithContext(ctx), data.UserID, &ret)
 	return ret, err
 }
 
-func (r *FixedDataRepository) GetBaseFixedUserData(userId string, data *model.BaseFixedUserData) error {
-	res := r.db.Raw(`
-		SELECT user_id, height, birthday 
+func (r *FixedDataRepository) GetBaseFixedUserData(ctx context.Context, userId string, data *model.BaseFixedUserData) error {
+	ctx, done := instrument(ctx, "fixed_data", "GetBaseFixedUserData")
+	defer done()
+
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT user_id, height, birthday, sex, units, time_zone, version
 		FROM fixed_user_data 
 		WHERE user_id = ?
 		LIMIT 1`,
 		userId,
 	).Scan(&data)
 
-	log.Warningf("Query res: %v", data)
-
 	if res.Error != nil {
 		return res.Error
 	}
 
 	if data.UserID == "" {
 		return &model.NotFoundError{
-			Title:  "Fixed data not found",
-			Detail: "No fixed data not found for user " + userId,
+			Code:   "fixed_data.not_found",
+			Params: map[string]string{"userId": userId},
 		}
 	}
 
 	return nil
 }
 
-func (r *FixedDataRepository) GetUserData(userId string, data *model.FixedUserData) error {
-	res := r.db.Raw(`
-		SELECT user_id, height, FLOOR(DATEDIFF(CURRENT_DATE(), birthday) / 365.25) AS age 
+func (r *FixedDataRepository) GetUserData(ctx context.Context, userId string, data *model.FixedUserData) error {
+	ctx, done := instrument(ctx, "fixed_data", "GetUserData")
+	defer done()
+
+	return getUserData(r.db.WithContext(ctx), userId, data)
+}
+
+// getUserData reads the fixed data of the user with db, which may be a transaction
+func getUserData(db *gorm.DB, userId string, data *model.FixedUserData) error {
+	res := db.Raw(`
+		SELECT user_id, height, sex, units, time_zone
 		FROM fixed_user_data 
 		WHERE user_id = ?
 		LIMIT 1`,
 		userId,
 	).Scan(data)
 
-	log.Warningf("Query res: %v", data)
-
 	if res.Error != nil {
 		return res.Error
 	}
 
 	return nil
 }
+
+// GetHeightHistory returns the heights of the user from the oldest to the current one
+func (r *FixedDataRepository) GetHeightHistory(ctx context.Context, userId string) ([]model.HeightRecord, error) {
+	ctx, done := instrument(ctx, "fixed_data", "GetHeightHistory")
+	defer done()
+
+	history := make([]model.HeightRecord, 0)
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT height, valid_from
+		FROM height_history
+		WHERE user_id = ?
+		ORDER BY valid_from ASC, id ASC;`,
+		userId,
+	).Scan(&history)
+
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	return history, nil
+}
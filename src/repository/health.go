@@ -0,0 +1,150 @@
+// Package repository provides structs and methods to interact with the database.
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/NutriPocket/ProgressService/database"
+)
+
+// RequiredTables are the tables created by sql/tables.sql that the repositories rely on.
+var RequiredTables = []string{
+	"fixed_user_data",
+	"height_history",
+	"anthropometric_data",
+	"body_measurements",
+	"objective",
+	"objective_history",
+	"objective_milestone",
+	"user_routines",
+	"exercise_by_day",
+	"reminder_rule",
+	"outbox",
+	"idempotency_key",
+	"webhook_subscription",
+	"webhook_delivery",
+	"job",
+	"job_run",
+}
+
+// RequiredColumns are the columns added by sql/migrations.sql to the tables of earlier schemas, by table,
+// which databases created before them lack until it's run.
+var RequiredColumns = map[string][]string{
+	"anthropometric_data": {"id", "context"},
+	"fixed_user_data":     {"sex", "units", "time_zone", "version"},
+	"objective":           {"type", "target", "period", "status", "closed_at", "final_value", "version"},
+	"objective_history":   {"type", "target", "period"},
+	"objective_milestone": {"created_at"},
+	"exercise_by_day":     {"version"},
+}
+
+// IHealthRepository is an interface that contains the methods that will implement a repository struct that inspects the database schema.
+type IHealthRepository interface {
+	GetMissingTables(ctx context.Context, tables []string) ([]string, error)
+	GetMissingColumns(ctx context.Context, columns map[string][]string) ([]string, error)
+}
+
+type HealthRepository struct {
+	db IDatabase
+}
+
+func NewHealthRepository(db IDatabase) (*HealthRepository, error) {
+	var err error
+
+	if db == nil {
+		db, err = database.GetPoolConnection()
+		if err != nil {
+			slog.Error("Failed to connect to database", "error", err)
+			return nil, err
+		}
+	}
+
+	return &HealthRepository{
+		db: db,
+	}, nil
+}
+
+// GetMissingTables returns the subset of tables that doesn't exist in the current database schema.
+func (r *HealthRepository) GetMissingTables(ctx context.Context, tables []string) ([]string, error) {
+	ctx, done := instrument(ctx, "health", "GetMissingTables")
+	defer done()
+
+	var existing []string
+
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+			AND table_name IN ?;
+	`,
+		tables,
+	).Scan(&existing)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to list the database tables", "error", res.Error)
+		return nil, res.Error
+	}
+
+	found := make(map[string]bool, len(existing))
+	for _, table := range existing {
+		found[table] = true
+	}
+
+	missing := make([]string, 0)
+	for _, table := range tables {
+		if !found[table] {
+			missing = append(missing, table)
+		}
+	}
+
+	return missing, nil
+}
+
+// GetMissingColumns returns the columns, as table.column, that don't exist in the current database schema.
+func (r *HealthRepository) GetMissingColumns(ctx context.Context, columns map[string][]string) ([]string, error) {
+	ctx, done := instrument(ctx, "health", "GetMissingColumns")
+	defer done()
+
+	tables := make([]string, 0, len(columns))
+	for table := range columns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var existing []struct {
+		TableName  string
+		ColumnName string
+	}
+
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT table_name AS table_name, column_name AS column_name
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+			AND table_name IN ?;
+	`,
+		tables,
+	).Scan(&existing)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to list the database columns", "error", res.Error)
+		return nil, res.Error
+	}
+
+	found := make(map[string]bool, len(existing))
+	for _, column := range existing {
+		found[column.TableName+"."+column.ColumnName] = true
+	}
+
+	missing := make([]string, 0)
+	for _, table := range tables {
+		for _, column := range columns[table] {
+			if !found[table+"."+column] {
+				missing = append(missing, table+"."+column)
+			}
+		}
+	}
+
+	return missing, nil
+}
@@ -1,13 +1,40 @@
 package repository
 
 import (
-	"github.com/op/go-logging"
+	"context"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/metrics"
+	"github.com/NutriPocket/ProgressService/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 )
 
-var log = logging.MustGetLogger("log")
-
+// IDatabase is the subset of *gorm.DB used by the repositories. Queries must be built from
+// WithContext(ctx) so they are cancelled when the request is cancelled or times out.
 type IDatabase interface {
 	Exec(sql string, args ...interface{}) *gorm.DB
 	Raw(sql string, args ...interface{}) *gorm.DB
+	WithContext(ctx context.Context) *gorm.DB
+}
+
+// instrument starts a span for a repository method and returns the context holding it and
+// a function that ends the span and records the latency of the method. It's meant to be
+// used at the beginning of every repository method:
+//
+//	ctx, done := instrument(ctx, "exercise", "CreateExercise")
+//	defer done()
+func instrument(ctx context.Context, repository string, method string) (context.Context, func()) {
+	start := time.Now()
+
+	ctx, span := tracing.StartSpan(
+		ctx,
+		repository+"."+method,
+		attribute.String("db.system", "mysql"),
+	)
+
+	return ctx, func() {
+		span.End()
+		metrics.ObserveQuery(repository, method, start)
+	}
 }
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/go-sql-driver/mysql"
+)
+
+// IIdempotencyRepository is an interface that contains the methods that will implement a repository struct that interact with the idempotency_key table.
+type IIdempotencyRepository interface {
+	ClaimKey(ctx context.Context, record *model.IdempotencyRecord, now time.Time, lockedUntil time.Time, expiresAt time.Time) (*model.IdempotencyRecord, error)
+	CompleteKey(ctx context.Context, userId string, key string, statusCode int, contentType string, response string) error
+	ReleaseKey(ctx context.Context, userId string, key string) error
+	DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+type IdempotencyRepository struct {
+	db IDatabase
+}
+
+func NewIdempotencyRepository(db IDatabase) (*IdempotencyRepository, error) {
+	var err error
+
+	if db == nil {
+		db, err = database.GetPoolConnection()
+		if err != nil {
+			slog.Error("Failed to connect to database", "error", err)
+			return nil, err
+		}
+	}
+
+	return &IdempotencyRepository{
+		db: db,
+	}, nil
+}
+
+// claimAttempts is how many times a key released while it was being claimed is claimed again
+const claimAttempts = 3
+
+// ClaimKey stores the key of the user for the request of the record, locked until lockedUntil
+// and kept until expiresAt. It returns nil if the key was claimed, or the stored record if it's
+// already used. Expired keys, and the ones of requests abandoned before completing, are claimed
+// again, as well as the ones released while they were being claimed.
+func (r *IdempotencyRepository) ClaimKey(ctx context.Context, record *model.IdempotencyRecord, now time.Time, lockedUntil time.Time, expiresAt time.Time) (*model.IdempotencyRecord, error) {
+	ctx, done := instrument(ctx, "idempotency", "ClaimKey")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		DELETE FROM idempotency_key
+		WHERE user_id = ? AND idempotency_key = ?
+			AND (expires_at <= ? OR (status_code IS NULL AND locked_until <= ?));
+	`,
+		record.UserID, record.Key, now, now,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to delete expired idempotency key", "user_id", record.UserID, "error", res.Error)
+		return nil, res.Error
+	}
+
+	// The key may be released between the insert failing and reading it, then it's claimed again
+	for range claimAttempts {
+		res = r.db.WithContext(ctx).Exec(`
+			INSERT INTO idempotency_key (user_id, idempotency_key, fingerprint, locked_until, expires_at)
+			VALUES (?, ?, ?, ?, ?);
+		`,
+			record.UserID, record.Key, record.Fingerprint, lockedUntil, expiresAt,
+		)
+
+		if res.Error == nil {
+			return nil, nil
+		}
+
+		// Check for MySQL duplicate entry error (error code 1062)
+		if !errors.Is(res.Error, &mysql.MySQLError{Number: 1062}) {
+			slog.ErrorContext(ctx, "Failed to claim idempotency key", "user_id", record.UserID, "error", res.Error)
+			return nil, res.Error
+		}
+
+		var stored model.IdempotencyRecord
+		res = r.db.WithContext(ctx).Raw(`
+			SELECT user_id, idempotency_key, fingerprint, status_code, content_type, response, locked_until, expires_at, created_at
+			FROM idempotency_key
+			WHERE user_id = ? AND idempotency_key = ?
+			LIMIT 1;
+		`,
+			record.UserID, record.Key,
+		).Scan(&stored)
+
+		if res.Error != nil {
+			slog.ErrorContext(ctx, "Failed to get idempotency key", "user_id", record.UserID, "error", res.Error)
+			return nil, res.Error
+		}
+
+		if stored.UserID != "" {
+			return &stored, nil
+		}
+	}
+
+	// Other requests with the key keep claiming and releasing it
+	return nil, &model.ConflictError{
+		Code: "idempotency.request_in_progress",
+	}
+}
+
+// CompleteKey stores the response of the request of the key of the user
+func (r *IdempotencyRepository) CompleteKey(ctx context.Context, userId string, key string, statusCode int, contentType string, response string) error {
+	ctx, done := instrument(ctx, "idempotency", "CompleteKey")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		UPDATE idempotency_key
+		SET status_code = ?, content_type = ?, response = ?
+		WHERE user_id = ? AND idempotency_key = ?;
+	`,
+		statusCode, contentType, response, userId, key,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to complete idempotency key", "user_id", userId, "error", res.Error)
+	}
+
+	return res.Error
+}
+
+// ReleaseKey deletes the key of the user if its request wasn't completed, so it can be retried
+func (r *IdempotencyRepository) ReleaseKey(ctx context.Context, userId string, key string) error {
+	ctx, done := instrument(ctx, "idempotency", "ReleaseKey")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		DELETE FROM idempotency_key
+		WHERE user_id = ? AND idempotency_key = ? AND status_code IS NULL;
+	`,
+		userId, key,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to release idempotency key", "user_id", userId, "error", res.Error)
+	}
+
+	return res.Error
+}
+
+// DeleteExpiredKeys deletes the keys expired at now and returns how many were deleted
+func (r *IdempotencyRepository) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	ctx, done := instrument(ctx, "idempotency", "DeleteExpiredKeys")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		DELETE FROM idempotency_key
+		WHERE expires_at <= ?;
+	`,
+		now.UTC(),
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to delete expired idempotency keys", "error", res.Error)
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
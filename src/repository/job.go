@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/model"
+	"gorm.io/gorm"
+)
+
+// IJobRepository is an interface that contains the methods that will implement a repository struct that interact with the job tables.
+type IJobRepository interface {
+	ScheduleJob(ctx context.Context, job *model.Job, runAt time.Time) error
+	EnqueueJob(ctx context.Context, job *model.Job, runAt time.Time) (bool, error)
+	ClaimJob(ctx context.Context, worker string, now time.Time, lease time.Duration) (*model.Job, *model.JobRun, error)
+	FinishJob(ctx context.Context, job *model.Job, run *model.JobRun, runAt time.Time, finishedAt time.Time) (bool, error)
+	GetJob(ctx context.Context, id uint64) (model.Job, error)
+	GetJobs(ctx context.Context, page model.PageParams) (model.Page[model.Job], error)
+	GetJobRuns(ctx context.Context, jobId uint64, page model.PageParams) (model.Page[model.JobRun], error)
+}
+
+type JobRepository struct {
+	db IDatabase
+}
+
+func NewJobRepository(db IDatabase) (*JobRepository, error) {
+	var err error
+
+	if db == nil {
+		db, err = database.GetPoolConnection()
+		if err != nil {
+			slog.Error("Failed to connect to database", "error", err)
+			return nil, err
+		}
+	}
+
+	return &JobRepository{
+		db: db,
+	}, nil
+}
+
+const jobColumns = `id, name, job_key, schedule, payload, status, run_at, attempts, max_attempts, locked_by,
+		locked_until, last_run_at, last_error, created_at`
+
+// ScheduleJob stores a recurring job, identified by its key. If it's already stored its
+// schedule, payload and max attempts are updated, and its next run is only moved to runAt if
+// the schedule changed.
+func (r *JobRepository) ScheduleJob(ctx context.Context, job *model.Job, runAt time.Time) error {
+	ctx, done := instrument(ctx, "job", "ScheduleJob")
+	defer done()
+
+	// run_at is assigned before schedule, so it still compares the stored schedule
+	res := r.db.WithContext(ctx).Exec(`
+		INSERT INTO job (name, job_key, schedule, payload, run_at, max_attempts)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			run_at = IF(schedule <=> VALUES(schedule), run_at, VALUES(run_at)),
+			schedule = VALUES(schedule),
+			payload = VALUES(payload),
+			max_attempts = VALUES(max_attempts);
+	`,
+		job.Name, job.Key, job.Schedule, job.Payload, runAt, job.MaxAttempts,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to schedule job", "name", job.Name, "error", res.Error)
+	}
+
+	return res.Error
+}
+
+// EnqueueJob stores a one-off job that runs at runAt. A job whose key is already stored isn't
+// enqueued again, then it returns false.
+func (r *JobRepository) EnqueueJob(ctx context.Context, job *model.Job, runAt time.Time) (bool, error) {
+	ctx, done := instrument(ctx, "job", "EnqueueJob")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		INSERT INTO job (name, job_key, payload, run_at, max_attempts)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = id;
+	`,
+		job.Name, job.Key, job.Payload, runAt, job.MaxAttempts,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to enqueue job", "name", job.Name, "error", res.Error)
+		return false, res.Error
+	}
+
+	// A duplicate key leaves the row untouched, so no row is affected
+	return res.RowsAffected > 0, nil
+}
+
+// ClaimJob locks the next job due at now for worker until the lease ends, and records the start
+// of its run. Jobs whose lease ended while running are taken over, their runs are marked as
+// failed. It returns a nil job if none is due.
+//
+// The job is selected with FOR UPDATE SKIP LOCKED, so concurrent workers of every replica pick
+// different jobs, and the lease is checked again when it's locked.
+func (r *JobRepository) ClaimJob(ctx context.Context, worker string, now time.Time, lease time.Duration) (*model.Job, *model.JobRun, error) {
+	ctx, done := instrument(ctx, "job", "ClaimJob")
+	defer done()
+
+	var job *model.Job
+	var run *model.JobRun
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint64
+		res := tx.Raw(`
+			SELECT id
+			FROM job
+			WHERE (status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?)
+			ORDER BY run_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED;
+		`,
+			model.JobPending, now, model.JobRunning, now,
+		).Scan(&ids)
+
+		if res.Error != nil || len(ids) == 0 {
+			return res.Error
+		}
+
+		res = tx.Exec(`
+			UPDATE job
+			SET status = ?, locked_by = ?, locked_until = ?, last_run_at = ?, attempts = attempts + 1
+			WHERE id = ? AND ((status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?));
+		`,
+			model.JobRunning, worker, now.Add(lease), now, ids[0], model.JobPending, now, model.JobRunning, now,
+		)
+
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		res = tx.Exec(`
+			UPDATE job_run
+			SET status = ?, error = 'The lease of the worker ended before the run finished', finished_at = ?
+			WHERE job_id = ? AND status = ?;
+		`,
+			model.JobFailed, now, ids[0], model.JobRunning,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		job = &model.Job{}
+		res = tx.Raw(`
+			SELECT `+jobColumns+`
+			FROM job
+			WHERE id = ?;
+		`,
+			ids[0],
+		).Scan(job)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		res = tx.Exec(`
+			INSERT INTO job_run (job_id, worker, attempt, status, started_at)
+			VALUES (?, ?, ?, ?, ?);
+		`,
+			job.ID, worker, job.Attempts, model.JobRunning, now,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		run = &model.JobRun{}
+		return tx.Raw(`
+			SELECT id, job_id, worker, attempt, status, error, started_at, finished_at
+			FROM job_run
+			WHERE job_id = ? AND status = ?
+			ORDER BY id DESC
+			LIMIT 1;
+		`,
+			job.ID, model.JobRunning,
+		).Scan(run).Error
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to claim job", "worker", worker, "error", err)
+		return nil, nil, err
+	}
+
+	return job, run, nil
+}
+
+// FinishJob records the outcome of the run and the next state of the job, set by the caller:
+// its status, attempts and last error, and runAt as its next run. It returns false if the job
+// was taken over by another worker since it was claimed, in which case nothing is recorded.
+func (r *JobRepository) FinishJob(ctx context.Context, job *model.Job, run *model.JobRun, runAt time.Time, finishedAt time.Time) (bool, error) {
+	ctx, done := instrument(ctx, "job", "FinishJob")
+	defer done()
+
+	finished := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			UPDATE job
+			SET status = ?, run_at = ?, attempts = ?, last_error = ?, locked_by = NULL, locked_until = NULL
+			WHERE id = ? AND status = ? AND locked_by = ?;
+		`,
+			job.Status, runAt, job.Attempts, job.LastError, job.ID, model.JobRunning, run.Worker,
+		)
+
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		res = tx.Exec(`
+			UPDATE job_run
+			SET status = ?, error = ?, finished_at = ?
+			WHERE id = ?;
+		`,
+			run.Status, run.Error, finishedAt, run.ID,
+		)
+
+		finished = res.Error == nil
+		return res.Error
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to finish job", "job_id", job.ID, "error", err)
+		return false, err
+	}
+
+	return finished, nil
+}
+
+func (r *JobRepository) GetJob(ctx context.Context, id uint64) (model.Job, error) {
+	ctx, done := instrument(ctx, "job", "GetJob")
+	defer done()
+
+	var job model.Job
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT `+jobColumns+`
+		FROM job
+		WHERE id = ?;`,
+		id,
+	).Scan(&job)
+
+	if res.Error != nil {
+		return model.Job{}, res.Error
+	}
+
+	if job.ID == 0 {
+		return model.Job{}, &model.NotFoundError{
+			Code:   "job.not_found",
+			Params: map[string]string{"jobId": strconv.FormatUint(id, 10)},
+		}
+	}
+
+	return job, nil
+}
+
+// GetJobs returns a page of every job, by creation date
+func (r *JobRepository) GetJobs(ctx context.Context, page model.PageParams) (model.Page[model.Job], error) {
+	ctx, done := instrument(ctx, "job", "GetJobs")
+	defer done()
+
+	after, args, orderBy, err := keyset([]string{"created_at", "id"}, page)
+	if err != nil {
+		return model.Page[model.Job]{}, err
+	}
+	args = append(args, page.Limit+1)
+
+	var jobs []model.Job
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT `+jobColumns+`
+		FROM job
+		WHERE `+after+`
+		ORDER BY `+orderBy+`
+		LIMIT ?;
+	`,
+		args...,
+	).Scan(&jobs)
+
+	if res.Error != nil {
+		return model.Page[model.Job]{}, res.Error
+	}
+
+	return pageOf(jobs, page, func(job model.Job) []string {
+		return []string{cursorTime(job.CreatedAt), strconv.FormatUint(job.ID, 10)}
+	}), nil
+}
+
+// GetJobRuns returns a page of the runs of a job, by start date
+func (r *JobRepository) GetJobRuns(ctx context.Context, jobId uint64, page model.PageParams) (model.Page[model.JobRun], error) {
+	ctx, done := instrument(ctx, "job", "GetJobRuns")
+	defer done()
+
+	after, afterArgs, orderBy, err := keyset([]string{"started_at", "id"}, page)
+	if err != nil {
+		return model.Page[model.JobRun]{}, err
+	}
+
+	args := []any{jobId}
+	args = append(args, afterArgs...)
+	args = append(args, page.Limit+1)
+
+	var runs []model.JobRun
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT id, job_id, worker, attempt, status, error, started_at, finished_at
+		FROM job_run
+		WHERE job_id = ?
+			AND `+after+`
+		ORDER BY `+orderBy+`
+		LIMIT ?;
+	`,
+		args...,
+	).Scan(&runs)
+
+	if res.Error != nil {
+		return model.Page[model.JobRun]{}, res.Error
+	}
+
+	return pageOf(runs, page, func(run model.JobRun) []string {
+		return []string{cursorTime(run.StartedAt), strconv.FormatUint(run.ID, 10)}
+	}), nil
+}
@@ -2,15 +2,27 @@
 package repository
 
 import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
 	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/events"
 	"github.com/NutriPocket/ProgressService/model"
+	"gorm.io/gorm"
 )
 
 // IObjectiveRepository is an interface that contains the methods that will implement a repository struct that interact with the users table.
 type IObjectiveRepository interface {
-	CreateObjective(data *model.ObjectiveData) (model.ObjectiveData, error)
-	ReplaceObjective(data *model.ObjectiveData) (model.ObjectiveData, error)
-	GetObjectiveByUserId(userId string, data *model.ObjectiveData) error
+	CreateObjective(ctx context.Context, data *model.ObjectiveData, milestones []model.Milestone) (model.ObjectiveData, error)
+	ReplaceObjective(ctx context.Context, data *model.ObjectiveData, milestones []model.Milestone, version *uint) (model.ObjectiveData, error)
+	GetObjectiveByUserId(ctx context.Context, userId string, data *model.ObjectiveData) error
+	GetObjectiveHistoryByUserId(ctx context.Context, userId string, page model.PageParams) (model.Page[model.ObjectiveData], error)
+	GetMilestonesByUserId(ctx context.Context, userId string) ([]model.Milestone, error)
+	AchieveMilestones(ctx context.Context, userId string, weight float32, at time.Time) (int64, error)
+	GetActiveObjectives(ctx context.Context, afterUserId string, limit int) ([]model.ObjectiveData, error)
+	CloseObjective(ctx context.Context, data *model.ObjectiveData, closedAt time.Time) (bool, error)
 }
 
 type ObjectiveRepository struct {
@@ -23,7 +35,7 @@ func NewObjectiveRepository(db IDatabase) (*ObjectiveRepository, error) {
 	if db == nil {
 		db, err = database.GetPoolConnection()
 		if err != nil {
-			log.Errorf("Failed to connect to database")
+			slog.Error("Failed to connect to database", "error", err)
 			return nil, err
 		}
 	}
@@ -33,48 +45,96 @@ func NewObjectiveRepository(db IDatabase) (*ObjectiveRepository, error) {
 	}, nil
 }
 
-func (r *ObjectiveRepository) CreateObjective(data *model.ObjectiveData) (model.ObjectiveData, error) {
-	res := r.db.Exec(`
-		INSERT INTO objective (user_id, weight, muscle_mass, fat_mass, bone_mass, deadline)
-		VALUES (?, ?, ?, ?, ?, ?);
-	`,
-		data.UserID, data.Weight, data.MuscleMass, data.FatMass, data.BoneMass, data.Deadline,
-	)
+// CreateObjective stores the objective of the user along with its milestones
+func (r *ObjectiveRepository) CreateObjective(ctx context.Context, data *model.ObjectiveData, milestones []model.Milestone) (model.ObjectiveData, error) {
+	ctx, done := instrument(ctx, "objective", "CreateObjective")
+	defer done()
 
-	if res.Error != nil {
-		log.Errorf("Failed to create anthropometric data for user %s: %v", data.UserID, res.Error)
-		return model.ObjectiveData{}, res.Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			INSERT INTO objective (user_id, type, weight, muscle_mass, fat_mass, bone_mass, target, period, deadline)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+		`,
+			objectiveValues(data)...,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if err := addObjectiveHistory(tx, data); err != nil {
+			return err
+		}
+
+		return replaceMilestones(tx, data.UserID, milestones)
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create objective", "user_id", data.UserID, "error", err)
+		return model.ObjectiveData{}, err
 	}
 
 	var ret model.ObjectiveData
-	err := r.GetObjectiveByUserId(data.UserID, &ret)
+	err = getObjective(r.db.WithContext(ctx), data.UserID, &ret)
 
 	return ret, err
 }
 
-func (r *ObjectiveRepository) ReplaceObjective(data *model.ObjectiveData) (model.ObjectiveData, error) {
-	res := r.db.Exec(`
-		UPDATE objective
-		SET weight = ?, muscle_mass = ?, fat_mass = ?, bone_mass = ?, deadline = ?
-		WHERE user_id = ?;
-	`,
-		data.Weight, data.MuscleMass, data.FatMass, data.BoneMass, data.Deadline, data.UserID,
-	)
+// ReplaceObjective replaces the objective of the user and its milestones, only if it's still at
+// version when it's not nil
+func (r *ObjectiveRepository) ReplaceObjective(ctx context.Context, data *model.ObjectiveData, milestones []model.Milestone, version *uint) (model.ObjectiveData, error) {
+	ctx, done := instrument(ctx, "objective", "ReplaceObjective")
+	defer done()
 
-	if res.Error != nil {
-		log.Errorf("Failed to update anthropometric data for user %s: %v", data.UserID, res.Error)
-		return model.ObjectiveData{}, res.Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			UPDATE objective
+			SET type = ?, weight = ?, muscle_mass = ?, fat_mass = ?, bone_mass = ?, target = ?, period = ?, deadline = ?,
+				status = 'active', closed_at = NULL, final_value = NULL, version = version + 1
+			WHERE user_id = ? AND (? IS NULL OR version = ?);
+		`,
+			append(objectiveValues(data)[1:], data.UserID, version, version)...,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 && version != nil {
+			return &model.PreconditionFailedError{
+				Code: "request.precondition_failed",
+			}
+		}
+
+		if err := addObjectiveHistory(tx, data); err != nil {
+			return err
+		}
+
+		return replaceMilestones(tx, data.UserID, milestones)
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update objective", "user_id", data.UserID, "error", err)
+		return model.ObjectiveData{}, err
 	}
 
 	var ret model.ObjectiveData
-	err := r.GetObjectiveByUserId(data.UserID, &ret)
+	err = getObjective(r.db.WithContext(ctx), data.UserID, &ret)
 
 	return ret, err
 }
 
-func (r *ObjectiveRepository) GetObjectiveByUserId(userId string, data *model.ObjectiveData) error {
-	res := r.db.Raw(`
-		SELECT user_id, weight, muscle_mass, fat_mass, bone_mass, created_at, deadline
+func (r *ObjectiveRepository) GetObjectiveByUserId(ctx context.Context, userId string, data *model.ObjectiveData) error {
+	ctx, done := instrument(ctx, "objective", "GetObjectiveByUserId")
+	defer done()
+
+	return getObjective(r.db.WithContext(ctx), userId, data)
+}
+
+// getObjective reads the objective of the user with db, which may be a transaction
+func getObjective(db *gorm.DB, userId string, data *model.ObjectiveData) error {
+	res := db.Raw(`
+		SELECT `+objectiveColumns+`
 		FROM objective
 		WHERE user_id = ?
 		LIMIT 1;`,
@@ -87,10 +147,228 @@ func (r *ObjectiveRepository) GetObjectiveByUserId(userId string, data *model.Ob
 
 	if data.UserID == "" {
 		return &model.NotFoundError{
-			Title:  "Objective data not found",
-			Detail: "No objective data not found for user " + userId,
+			Code:   "objective.not_found",
+			Params: map[string]string{"userId": userId},
+		}
+	}
+
+	return nil
+}
+
+// objectiveColumns are the columns of the objective table selected into a model.ObjectiveData
+const objectiveColumns = `user_id, type, COALESCE(weight, 0) AS weight, muscle_mass, fat_mass, bone_mass, target, period,
+		created_at, deadline, status, closed_at, final_value, version`
+
+// GetActiveObjectives returns up to limit active objectives of the users after afterUserId,
+// ordered by user
+func (r *ObjectiveRepository) GetActiveObjectives(ctx context.Context, afterUserId string, limit int) ([]model.ObjectiveData, error) {
+	ctx, done := instrument(ctx, "objective", "GetActiveObjectives")
+	defer done()
+
+	objectives := make([]model.ObjectiveData, 0)
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT `+objectiveColumns+`
+		FROM objective
+		WHERE status = ? AND user_id > ?
+		ORDER BY user_id ASC
+		LIMIT ?;`,
+		model.ObjectiveActive, afterUserId, limit,
+	).Scan(&objectives)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to get active objectives", "error", res.Error)
+		return nil, res.Error
+	}
+
+	return objectives, nil
+}
+
+// CloseObjective records the status and final_value of the objective, closed at closedAt, along
+// with its event, whose payload is the objective as given. It returns false if the objective was
+// closed or replaced since it was read, based on its version.
+func (r *ObjectiveRepository) CloseObjective(ctx context.Context, data *model.ObjectiveData, closedAt time.Time) (bool, error) {
+	ctx, done := instrument(ctx, "objective", "CloseObjective")
+	defer done()
+
+	eventType := events.ObjectiveExpired
+	if data.Status == model.ObjectiveAchieved {
+		eventType = events.ObjectiveAchieved
+	}
+
+	closed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// created_at is set explicitly so the ON UPDATE clause keeps the date the objective was set at
+		res := tx.Exec(`
+			UPDATE objective
+			SET status = ?, closed_at = ?, final_value = ?, version = version + 1, created_at = created_at
+			WHERE user_id = ? AND status = ? AND version = ?;
+		`,
+			data.Status, closedAt, data.FinalValue, data.UserID, model.ObjectiveActive, data.Version,
+		)
+
+		if res.Error != nil || res.RowsAffected != 1 {
+			return res.Error
+		}
+
+		closed = true
+		return addOutboxEvent(tx, eventType, data.UserID, data)
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to close objective", "user_id", data.UserID, "error", err)
+		return false, err
+	}
+
+	return closed, nil
+}
+
+// objectiveValues returns the columns of an objective in the order user_id, type, weight,
+// muscle_mass, fat_mass, bone_mass, target, period, deadline. Only body composition objectives
+// have a weight.
+func objectiveValues(data *model.ObjectiveData) []any {
+	var weight *float32
+	if data.Type == model.ObjectiveBodyComposition {
+		weight = &data.Weight
+	}
+
+	return []any{
+		data.UserID, data.Type, weight, data.MuscleMass, data.FatMass, data.BoneMass, data.Target, data.Period, data.Deadline,
+	}
+}
+
+// addObjectiveHistory records a version of the objective of a user, within the transaction
+// that creates or replaces it
+func addObjectiveHistory(tx *gorm.DB, data *model.ObjectiveData) error {
+	return tx.Exec(`
+		INSERT INTO objective_history (user_id, type, weight, muscle_mass, fat_mass, bone_mass, target, period, deadline)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+	`,
+		objectiveValues(data)...,
+	).Error
+}
+
+// objectiveHistoryRow is a row of objective_history, the ID breaks ties between versions set at the same time
+type objectiveHistoryRow struct {
+	model.ObjectiveData
+	ID uint64
+}
+
+// GetObjectiveHistoryByUserId returns a page of the versions of the objective of the user,
+// each one with the date it was set at as created_at
+func (r *ObjectiveRepository) GetObjectiveHistoryByUserId(ctx context.Context, userId string, page model.PageParams) (model.Page[model.ObjectiveData], error) {
+	ctx, done := instrument(ctx, "objective", "GetObjectiveHistoryByUserId")
+	defer done()
+
+	after, afterArgs, orderBy, err := keyset([]string{"created_at", "id"}, page)
+	if err != nil {
+		return model.Page[model.ObjectiveData]{}, err
+	}
+
+	args := []any{userId}
+	args = append(args, afterArgs...)
+	args = append(args, page.Limit+1)
+
+	var rows []objectiveHistoryRow
+
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT id, user_id, type, COALESCE(weight, 0) AS weight, muscle_mass, fat_mass, bone_mass, target, period, created_at, deadline
+		FROM objective_history
+		WHERE user_id = ?
+			AND `+after+`
+		ORDER BY `+orderBy+`
+		LIMIT ?;
+	`,
+		args...,
+	).Scan(&rows)
+
+	if res.Error != nil {
+		return model.Page[model.ObjectiveData]{}, res.Error
+	}
+
+	rowsPage := pageOf(rows, page, func(row objectiveHistoryRow) []string {
+		return []string{cursorTime(row.CreatedAt), strconv.FormatUint(row.ID, 10)}
+	})
+
+	history := make([]model.ObjectiveData, len(rowsPage.Items))
+	for i, row := range rowsPage.Items {
+		history[i] = row.ObjectiveData
+	}
+
+	return model.Page[model.ObjectiveData]{Items: history, Next: rowsPage.Next}, nil
+}
+
+// replaceMilestones replaces the milestones of the objective of the user within tx
+func replaceMilestones(tx *gorm.DB, userId string, milestones []model.Milestone) error {
+	res := tx.Exec(`
+		DELETE FROM objective_milestone
+		WHERE user_id = ?;
+	`,
+		userId,
+	)
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	for _, milestone := range milestones {
+		res = tx.Exec(`
+			INSERT INTO objective_milestone (user_id, sequence, weight, start_weight, due_date)
+			VALUES (?, ?, ?, ?, ?);
+		`,
+			userId, milestone.Sequence, milestone.Weight, milestone.StartWeight, milestone.DueDate,
+		)
+
+		if res.Error != nil {
+			return res.Error
 		}
 	}
 
 	return nil
 }
+
+// GetMilestonesByUserId returns the milestones of the objective of the user in order
+func (r *ObjectiveRepository) GetMilestonesByUserId(ctx context.Context, userId string) ([]model.Milestone, error) {
+	ctx, done := instrument(ctx, "objective", "GetMilestonesByUserId")
+	defer done()
+
+	milestones := make([]model.Milestone, 0)
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT sequence, weight, start_weight, due_date, achieved_at
+		FROM objective_milestone
+		WHERE user_id = ?
+		ORDER BY sequence ASC;`,
+		userId,
+	).Scan(&milestones)
+
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	return milestones, nil
+}
+
+// AchieveMilestones marks the pending milestones of the user reached by a measurement of weight
+// taken at at, and returns how many were marked. Measurements taken before the milestones were
+// scheduled with their objective don't achieve them.
+func (r *ObjectiveRepository) AchieveMilestones(ctx context.Context, userId string, weight float32, at time.Time) (int64, error) {
+	ctx, done := instrument(ctx, "objective", "AchieveMilestones")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		UPDATE objective_milestone
+		SET achieved_at = ?
+		WHERE user_id = ?
+			AND achieved_at IS NULL
+			AND created_at <= ?
+			AND ((weight <= start_weight AND ? <= weight) OR (weight > start_weight AND ? >= weight));
+	`,
+		at.UTC(), userId, at.UTC(), weight, weight,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to achieve milestones", "user_id", userId, "error", res.Error)
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
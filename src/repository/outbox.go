@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/events"
+	"github.com/NutriPocket/ProgressService/model"
+	"gorm.io/gorm"
+)
+
+// IOutboxRepository is an interface that contains the methods that will implement a repository struct that interact with the outbox table.
+type IOutboxRepository interface {
+	RelayPending(ctx context.Context, limit int, publish func(event model.OutboxEvent) error) (int, error)
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type OutboxRepository struct {
+	db IDatabase
+}
+
+func NewOutboxRepository(db IDatabase) (*OutboxRepository, error) {
+	var err error
+
+	if db == nil {
+		db, err = database.GetPoolConnection()
+		if err != nil {
+			slog.Error("Failed to connect to database", "error", err)
+			return nil, err
+		}
+	}
+
+	return &OutboxRepository{
+		db: db,
+	}, nil
+}
+
+// addOutboxEvent stores an event of type eventType about payload, within the transaction that
+// makes the change it's about, so the event is only relayed if the change is committed
+func addOutboxEvent(tx *gorm.DB, eventType string, userId string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return tx.Exec(`
+		INSERT INTO outbox (event_id, event_type, user_id, payload, occurred_at)
+		VALUES (?, ?, ?, ?, ?);
+	`,
+		events.NewID(), eventType, userId, string(body), time.Now().UTC(),
+	).Error
+}
+
+// outboxRelayLock is the name of the lock that keeps the relays of every replica from running at once
+const outboxRelayLock = "outbox_relay"
+
+// RelayPending calls publish with up to limit unpublished events, in the order they were stored,
+// and marks the ones it succeeded with as published. It stops at the first event publish fails
+// with, so the rest are relayed after it, and returns how many were published.
+//
+// The relays of every replica take turns with GET_LOCK, so the events are published one batch
+// at a time and in order. A relay that doesn't get the lock publishes nothing. The events are
+// read and published without locking their rows nor holding a transaction open, and marked as
+// published afterwards. An event published right before that fails is published again, its
+// consumers tell by its event ID.
+func (r *OutboxRepository) RelayPending(ctx context.Context, limit int, publish func(event model.OutboxEvent) error) (int, error) {
+	ctx, done := instrument(ctx, "outbox", "RelayPending")
+	defer done()
+
+	published := 0
+	var publishErr error
+
+	// The lock belongs to the connection, so every statement runs on the same one
+	err := r.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var locked int
+		res := conn.Raw(`SELECT COALESCE(GET_LOCK(?, 0), 0);`, outboxRelayLock).Scan(&locked)
+		if res.Error != nil || locked != 1 {
+			return res.Error
+		}
+
+		// The lock is released even if ctx is cancelled, it'd be kept by the pooled connection otherwise
+		defer conn.WithContext(context.WithoutCancel(ctx)).Exec(`DO RELEASE_LOCK(?);`, outboxRelayLock)
+
+		outboxEvents := make([]model.OutboxEvent, 0)
+		res = conn.Raw(`
+			SELECT id, event_id, event_type, user_id, payload, occurred_at, published_at
+			FROM outbox
+			WHERE published_at IS NULL
+			ORDER BY id ASC
+			LIMIT ?;
+		`,
+			limit,
+		).Scan(&outboxEvents)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		ids := make([]uint64, 0, len(outboxEvents))
+		for _, event := range outboxEvents {
+			if publishErr = publish(event); publishErr != nil {
+				break
+			}
+
+			ids = append(ids, event.ID)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		res = conn.Exec(`
+			UPDATE outbox
+			SET published_at = ?
+			WHERE id IN ? AND published_at IS NULL;
+		`,
+			time.Now().UTC(), ids,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		published = len(ids)
+		return nil
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to relay outbox events", "error", err)
+		return 0, err
+	}
+
+	return published, publishErr
+}
+
+// DeletePublishedBefore deletes the events published before the date and returns how many were deleted
+func (r *OutboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	ctx, done := instrument(ctx, "outbox", "DeletePublishedBefore")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		DELETE FROM outbox
+		WHERE published_at < ?;
+	`,
+		before.UTC(),
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to delete published outbox events", "error", res.Error)
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
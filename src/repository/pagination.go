@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+)
+
+// cursorTimeLayout is the layout of the dates stored in the cursors, the one MySQL compares
+// with DATETIME(6) columns
+const cursorTimeLayout = "2006-01-02 15:04:05.999999"
+
+// keyset returns the SQL fragments to fetch a page of a list sorted by columns, the date column
+// first and then the columns that break ties between rows of the same date:
+//   - where: the condition that skips the rows up to the cursor of the page, "TRUE" for the first page
+//   - args: the arguments of the placeholders of where
+//   - orderBy: the ORDER BY expression of the page
+//
+// It returns a validation error if the cursor of the page has a key per column of another list.
+// Pages are fetched with one extra row to know if there's a next one, see pageOf.
+func keyset(columns []string, page model.PageParams) (where string, args []any, orderBy string, err error) {
+	direction, op := "ASC", ">"
+	if page.Sort == model.SortDesc {
+		direction, op = "DESC", "<"
+	}
+
+	order := make([]string, len(columns))
+	for i, column := range columns {
+		order[i] = column + " " + direction
+	}
+	orderBy = strings.Join(order, ", ")
+
+	if page.After == nil {
+		return "TRUE", nil, orderBy, nil
+	}
+
+	if len(page.After.Keys) != len(columns) {
+		return "", nil, "", &model.ValidationError{Code: "pagination.invalid_cursor"}
+	}
+
+	// (c1, c2, c3) > (k1, k2, k3) expands to
+	// c1 > k1 OR (c1 = k1 AND (c2 > k2 OR (c2 = k2 AND c3 > k3)))
+	where = columns[len(columns)-1] + " " + op + " ?"
+	args = []any{page.After.Keys[len(columns)-1]}
+	for i := len(columns) - 2; i >= 0; i-- {
+		where = "(" + columns[i] + " " + op + " ? OR (" + columns[i] + " = ? AND " + where + "))"
+		args = append([]any{page.After.Keys[i], page.After.Keys[i]}, args...)
+	}
+
+	return where, args, orderBy, nil
+}
+
+// pageOf returns the page of rows fetched with one extra row, see keyset.
+// keys returns the values of the sort columns of a row, used to build the cursor of the next page.
+func pageOf[T any](rows []T, page model.PageParams, keys func(T) []string) model.Page[T] {
+	if len(rows) <= page.Limit {
+		return model.Page[T]{Items: rows}
+	}
+
+	rows = rows[:page.Limit]
+
+	return model.Page[T]{
+		Items: rows,
+		Next: &model.Cursor{
+			Sort: page.Sort,
+			Keys: keys(rows[len(rows)-1]),
+		},
+	}
+}
+
+// cursorTime converts a date scanned from the database into the format stored in the cursors
+func cursorTime(date string) string {
+	parsed, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return date
+	}
+
+	return parsed.UTC().Format(cursorTimeLayout)
+}
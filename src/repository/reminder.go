@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/model"
+	"gorm.io/gorm"
+)
+
+// IReminderRepository is an interface that contains the methods that will implement a repository struct that interact with the reminder_rule table.
+type IReminderRepository interface {
+	CreateReminder(ctx context.Context, userId string, data *model.ReminderDTO) (model.Reminder, error)
+	GetReminder(ctx context.Context, id uint64, userId string) (model.Reminder, error)
+	GetRemindersPage(ctx context.Context, userId string, page model.PageParams) (model.Page[model.Reminder], error)
+	GetRemindersAfter(ctx context.Context, afterId uint64, limit int) ([]model.Reminder, error)
+	UpdateReminder(ctx context.Context, id uint64, userId string, data *model.ReminderDTO) (model.Reminder, error)
+	DeleteReminder(ctx context.Context, id uint64, userId string) error
+}
+
+type ReminderRepository struct {
+	db IDatabase
+}
+
+func NewReminderRepository(db IDatabase) (*ReminderRepository, error) {
+	var err error
+
+	if db == nil {
+		db, err = database.GetPoolConnection()
+		if err != nil {
+			slog.Error("Failed to connect to database", "error", err)
+			return nil, err
+		}
+	}
+
+	return &ReminderRepository{
+		db: db,
+	}, nil
+}
+
+// reminderColumns are the columns of the reminder_rule table selected into a model.Reminder
+const reminderColumns = `id, user_id, type, threshold, quiet_start, quiet_end, created_at, updated_at`
+
+func (r *ReminderRepository) CreateReminder(ctx context.Context, userId string, data *model.ReminderDTO) (model.Reminder, error) {
+	ctx, done := instrument(ctx, "reminder", "CreateReminder")
+	defer done()
+
+	// The ID is read in the same transaction, so it's the one of the connection that inserted it
+	var id uint64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			INSERT INTO reminder_rule (user_id, type, threshold, quiet_start, quiet_end)
+			VALUES (?, ?, ?, ?, ?);
+		`,
+			userId, data.Type, data.Threshold, data.QuietStart, data.QuietEnd,
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		return tx.Raw("SELECT LAST_INSERT_ID()").Scan(&id).Error
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create reminder", "user_id", userId, "error", err)
+		return model.Reminder{}, err
+	}
+
+	return getReminder(r.db.WithContext(ctx), id, userId)
+}
+
+// GetReminder returns the reminder rule id of the user, or a not found error if the user has none with that ID
+func (r *ReminderRepository) GetReminder(ctx context.Context, id uint64, userId string) (model.Reminder, error) {
+	ctx, done := instrument(ctx, "reminder", "GetReminder")
+	defer done()
+
+	return getReminder(r.db.WithContext(ctx), id, userId)
+}
+
+// getReminder reads the reminder rule of the user with db, which may be a transaction
+func getReminder(db *gorm.DB, id uint64, userId string) (model.Reminder, error) {
+	var reminder model.Reminder
+	res := db.Raw(`
+		SELECT `+reminderColumns+`
+		FROM reminder_rule
+		WHERE id = ? AND user_id = ?;`,
+		id, userId,
+	).Scan(&reminder)
+
+	if res.Error != nil {
+		return model.Reminder{}, res.Error
+	}
+
+	if reminder.ID == 0 {
+		return model.Reminder{}, &model.NotFoundError{
+			Code:   "reminder.not_found",
+			Params: map[string]string{"id": strconv.FormatUint(id, 10)},
+		}
+	}
+
+	return reminder, nil
+}
+
+// GetRemindersPage returns a page of the reminder rules of the user, by creation date
+func (r *ReminderRepository) GetRemindersPage(ctx context.Context, userId string, page model.PageParams) (model.Page[model.Reminder], error) {
+	ctx, done := instrument(ctx, "reminder", "GetRemindersPage")
+	defer done()
+
+	after, afterArgs, orderBy, err := keyset([]string{"created_at", "id"}, page)
+	if err != nil {
+		return model.Page[model.Reminder]{}, err
+	}
+
+	args := []any{userId}
+	args = append(args, afterArgs...)
+	args = append(args, page.Limit+1)
+
+	reminders := make([]model.Reminder, 0)
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT `+reminderColumns+`
+		FROM reminder_rule
+		WHERE user_id = ?
+			AND `+after+`
+		ORDER BY `+orderBy+`
+		LIMIT ?;
+	`,
+		args...,
+	).Scan(&reminders)
+
+	if res.Error != nil {
+		return model.Page[model.Reminder]{}, res.Error
+	}
+
+	return pageOf(reminders, page, func(reminder model.Reminder) []string {
+		return []string{cursorTime(reminder.CreatedAt), strconv.FormatUint(reminder.ID, 10)}
+	}), nil
+}
+
+// GetRemindersAfter returns up to limit reminder rules of every user with an ID greater than afterId, ordered by ID
+func (r *ReminderRepository) GetRemindersAfter(ctx context.Context, afterId uint64, limit int) ([]model.Reminder, error) {
+	ctx, done := instrument(ctx, "reminder", "GetRemindersAfter")
+	defer done()
+
+	reminders := make([]model.Reminder, 0)
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT `+reminderColumns+`
+		FROM reminder_rule
+		WHERE id > ?
+		ORDER BY id ASC
+		LIMIT ?;
+	`,
+		afterId, limit,
+	).Scan(&reminders)
+
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	return reminders, nil
+}
+
+func (r *ReminderRepository) UpdateReminder(ctx context.Context, id uint64, userId string, data *model.ReminderDTO) (model.Reminder, error) {
+	ctx, done := instrument(ctx, "reminder", "UpdateReminder")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		UPDATE reminder_rule
+		SET type = ?, threshold = ?, quiet_start = ?, quiet_end = ?
+		WHERE id = ? AND user_id = ?;
+	`,
+		data.Type, data.Threshold, data.QuietStart, data.QuietEnd, id, userId,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to update reminder", "user_id", userId, "reminder_id", id, "error", res.Error)
+		return model.Reminder{}, res.Error
+	}
+
+	return getReminder(r.db.WithContext(ctx), id, userId)
+}
+
+// DeleteReminder deletes the reminder rule id of the user, or returns a not found error if the user has none with that ID
+func (r *ReminderRepository) DeleteReminder(ctx context.Context, id uint64, userId string) error {
+	ctx, done := instrument(ctx, "reminder", "DeleteReminder")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		DELETE FROM reminder_rule
+		WHERE id = ? AND user_id = ?;
+	`,
+		id, userId,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to delete reminder", "user_id", userId, "reminder_id", id, "error", res.Error)
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return &model.NotFoundError{
+			Code:   "reminder.not_found",
+			Params: map[string]string{"id": strconv.FormatUint(id, 10)},
+		}
+	}
+
+	return nil
+}
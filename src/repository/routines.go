@@ -2,18 +2,25 @@
 package repository
 
 import (
+	"context"
+	"log/slog"
+	"strconv"
+
 	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/events"
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
 )
 
 // IRoutineRepository is an interface that contains the methods that will implement a repository struct that interact with the users table.
 type IRoutineRepository interface {
-	CreateRoutine(data *model.RoutineDTO) (model.RoutineData, error)
-	GetRoutinesByUserId(userId string, data *[]model.RoutineData) error
-	GetRoutineBySchedule(userId string, schedule *model.Schedule) (model.RoutineData, error)
-	GetRoutinesByInterval(userId string, schedule *model.Schedule) ([]model.RoutineData, error)
-	DeleteRoutineBySchedule(userId string, schedule *model.Schedule) error
+	CreateRoutine(ctx context.Context, data *model.RoutineDTO) (model.RoutineData, error)
+	GetRoutinesByUserId(ctx context.Context, userId string, data *[]model.RoutineData) error
+	GetRoutinesPageByUserId(ctx context.Context, userId string, page model.PageParams) (model.Page[model.RoutineData], error)
+	GetRoutineBySchedule(ctx context.Context, userId string, schedule *model.Schedule) (model.RoutineData, error)
+	GetRoutinesByInterval(ctx context.Context, userId string, schedule *model.Schedule) ([]model.RoutineData, error)
+	DeleteRoutineBySchedule(ctx context.Context, userId string, schedule *model.Schedule, page model.PageParams, check func(current model.Page[model.RoutineData]) error) error
 }
 
 type RoutineRepository struct {
@@ -26,7 +33,7 @@ func NewRoutineRepository(db IDatabase) (*RoutineRepository, error) {
 	if db == nil {
 		db, err = database.GetPoolConnection()
 		if err != nil {
-			log.Errorf("Failed to connect to database")
+			slog.Error("Failed to connect to database", "error", err)
 			return nil, err
 		}
 	}
@@ -36,43 +43,59 @@ func NewRoutineRepository(db IDatabase) (*RoutineRepository, error) {
 	}, nil
 }
 
-func (r *RoutineRepository) CreateRoutine(data *model.RoutineDTO) (model.RoutineData, error) {
-	res := r.db.Exec(`
-		INSERT INTO user_routines (user_id, name, description, day, start_hour, end_hour)
-		VALUES (?, ?, ?, ?, ?, ?);
-	`,
-		data.UserID, data.Name, data.Description, data.Day, data.StartHour, data.EndHour,
-	)
+func (r *RoutineRepository) CreateRoutine(ctx context.Context, data *model.RoutineDTO) (model.RoutineData, error) {
+	ctx, done := instrument(ctx, "routine", "CreateRoutine")
+	defer done()
 
-	if res.Error != nil {
-		// Check for MySQL duplicate entry error (error code 1062)
-		log.Errorf("Failed to create a routine for user %s: %v", data.UserID, res.Error)
+	var ret model.RoutineData
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			INSERT INTO user_routines (user_id, name, description, day, start_hour, end_hour)
+			VALUES (?, ?, ?, ?, ?, ?);
+		`,
+			data.UserID, data.Name, data.Description, data.Day, data.StartHour, data.EndHour,
+		)
 
-		if mysqlErr, ok := res.Error.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
-			return model.RoutineData{}, &model.ConflictError{
-				Title:  "Routine already exists",
-				Detail: "A routine with the same schedule already exists for this user",
-			}
+		if res.Error != nil {
+			return res.Error
 		}
 
-		return model.RoutineData{}, res.Error
-	}
-
-	ret, err := r.GetRoutineBySchedule(
-		data.UserID,
-		&model.Schedule{
+		var err error
+		ret, err = getRoutine(tx, data.UserID, &model.Schedule{
 			Day:       data.Day,
 			StartHour: data.StartHour,
 			EndHour:   data.EndHour,
 		})
+		if err != nil {
+			return err
+		}
+
+		return addOutboxEvent(tx, events.RoutineCreated, data.UserID, ret)
+	})
+
+	if err != nil {
+		// Check for MySQL duplicate entry error (error code 1062)
+		slog.ErrorContext(ctx, "Failed to create a routine", "user_id", data.UserID, "error", err)
 
-	return ret, err
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+			return model.RoutineData{}, &model.ConflictError{
+				Code: "routine.already_exists",
+			}
+		}
+
+		return model.RoutineData{}, err
+	}
+
+	return ret, nil
 }
 
-func (r *RoutineRepository) GetRoutinesByInterval(userId string, schedule *model.Schedule) ([]model.RoutineData, error) {
+func (r *RoutineRepository) GetRoutinesByInterval(ctx context.Context, userId string, schedule *model.Schedule) ([]model.RoutineData, error) {
+	ctx, done := instrument(ctx, "routine", "GetRoutinesByInterval")
+	defer done()
+
 	var routines []model.RoutineData
 
-	res := r.db.Raw(`
+	res := r.db.WithContext(ctx).Raw(`
 		SELECT user_id, name, description, day, start_hour, end_hour, created_at, updated_at
 		FROM user_routines
 		WHERE day = ? AND end_hour > ? AND start_hour < ? AND user_id = ?;
@@ -87,10 +110,18 @@ func (r *RoutineRepository) GetRoutinesByInterval(userId string, schedule *model
 	return routines, nil
 }
 
-func (r *RoutineRepository) GetRoutineBySchedule(userId string, schedule *model.Schedule) (model.RoutineData, error) {
+func (r *RoutineRepository) GetRoutineBySchedule(ctx context.Context, userId string, schedule *model.Schedule) (model.RoutineData, error) {
+	ctx, done := instrument(ctx, "routine", "GetRoutineBySchedule")
+	defer done()
+
+	return getRoutine(r.db.WithContext(ctx), userId, schedule)
+}
+
+// getRoutine reads the routine of the user in the schedule with db, which may be a transaction
+func getRoutine(db *gorm.DB, userId string, schedule *model.Schedule) (model.RoutineData, error) {
 	var routine model.RoutineData
 
-	res := r.db.Raw(`
+	res := db.Raw(`
 		SELECT user_id, name, description, day, start_hour, end_hour, created_at, updated_at
 		FROM user_routines
 		WHERE day = ? AND start_hour = ? AND end_hour = ? AND user_id = ?
@@ -104,16 +135,18 @@ func (r *RoutineRepository) GetRoutineBySchedule(userId string, schedule *model.
 
 	if routine.UserID == "" {
 		return model.RoutineData{}, &model.NotFoundError{
-			Title:  "Routine not found",
-			Detail: "No routine found for the given schedule",
+			Code: "routine.not_found",
 		}
 	}
 
 	return routine, nil
 }
 
-func (r *RoutineRepository) GetRoutinesByUserId(userId string, data *[]model.RoutineData) error {
-	res := r.db.Raw(`
+func (r *RoutineRepository) GetRoutinesByUserId(ctx context.Context, userId string, data *[]model.RoutineData) error {
+	ctx, done := instrument(ctx, "routine", "GetRoutinesByUserId")
+	defer done()
+
+	res := r.db.WithContext(ctx).Raw(`
 		SELECT user_id, name, description, day, start_hour, end_hour, created_at, updated_at
 		FROM user_routines
 		WHERE user_id = ?
@@ -128,20 +161,107 @@ func (r *RoutineRepository) GetRoutinesByUserId(userId string, data *[]model.Rou
 	return nil
 }
 
-func (r *RoutineRepository) DeleteRoutineBySchedule(userId string, schedule *model.Schedule) error {
-	res := r.db.Exec(`
-		DELETE FROM user_routines
-		WHERE day = ? AND start_hour = ? AND end_hour = ? AND user_id = ?;
+// GetRoutinesPageByUserId returns a page of the routines of the user sorted by creation date
+func (r *RoutineRepository) GetRoutinesPageByUserId(ctx context.Context, userId string, page model.PageParams) (model.Page[model.RoutineData], error) {
+	ctx, done := instrument(ctx, "routine", "GetRoutinesPageByUserId")
+	defer done()
+
+	return getRoutinesPage(r.db.WithContext(ctx), userId, page)
+}
+
+// getRoutinesPage reads a page of the routines of the user with db, which may be a transaction
+func getRoutinesPage(db *gorm.DB, userId string, page model.PageParams) (model.Page[model.RoutineData], error) {
+	after, afterArgs, orderBy, err := keyset([]string{"created_at", "day", "start_hour", "end_hour"}, page)
+	if err != nil {
+		return model.Page[model.RoutineData]{}, err
+	}
+
+	args := []any{userId}
+	args = append(args, afterArgs...)
+	args = append(args, page.Limit+1)
+
+	routines := make([]model.RoutineData, 0)
+	res := db.Raw(`
+		SELECT user_id, name, description, day, start_hour, end_hour, created_at, updated_at
+		FROM user_routines
+		WHERE user_id = ?
+			AND `+after+`
+		ORDER BY `+orderBy+`
+		LIMIT ?;
 	`,
-		schedule.Day, schedule.StartHour, schedule.EndHour, userId,
-	)
+		args...,
+	).Scan(&routines)
 
 	if res.Error != nil {
-		log.Errorf(
-			"Failed to delete routine for schedule %s %d-%d for user %s: %v",
-			schedule.Day, schedule.StartHour, schedule.EndHour, userId, res.Error,
+		return model.Page[model.RoutineData]{}, res.Error
+	}
+
+	return pageOf(routines, page, func(routine model.RoutineData) []string {
+		return []string{
+			cursorTime(routine.CreatedAt),
+			routine.Day,
+			strconv.Itoa(routine.StartHour),
+			strconv.Itoa(routine.EndHour),
+		}
+	}), nil
+}
+
+// DeleteRoutineBySchedule deletes the routine of the user in the schedule, the deletion is only
+// published if there was one. If check is set, it's called first with the page of the routines
+// of the user, which are locked until the deletion so they can't change after being checked,
+// and the routine is only deleted if it returns nil.
+func (r *RoutineRepository) DeleteRoutineBySchedule(ctx context.Context, userId string, schedule *model.Schedule, page model.PageParams, check func(current model.Page[model.RoutineData]) error) error {
+	ctx, done := instrument(ctx, "routine", "DeleteRoutineBySchedule")
+	defer done()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if check != nil {
+			// Locks the routines of the user and the gaps between them, so none is created either
+			var locked []int
+			res := tx.Raw(`
+				SELECT 1
+				FROM user_routines
+				WHERE user_id = ?
+				FOR UPDATE;
+			`,
+				userId,
+			).Scan(&locked)
+
+			if res.Error != nil {
+				return res.Error
+			}
+
+			current, err := getRoutinesPage(tx, userId, page)
+			if err != nil {
+				return err
+			}
+
+			if err := check(current); err != nil {
+				return err
+			}
+		}
+
+		res := tx.Exec(`
+			DELETE FROM user_routines
+			WHERE day = ? AND start_hour = ? AND end_hour = ? AND user_id = ?;
+		`,
+			schedule.Day, schedule.StartHour, schedule.EndHour, userId,
 		)
-		return res.Error
+
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		return addOutboxEvent(tx, events.RoutineDeleted, userId, schedule)
+	})
+
+	if err != nil {
+		slog.ErrorContext(
+			ctx, "Failed to delete routine",
+			"user_id", userId, "day", schedule.Day, "start_hour", schedule.StartHour, "end_hour", schedule.EndHour,
+			"error", err,
+		)
+		return err
 	}
 
 	return nil
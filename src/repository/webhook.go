@@ -0,0 +1,414 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/model"
+	"gorm.io/gorm"
+)
+
+// IWebhookRepository is an interface that contains the methods that will implement a repository struct that interact with the webhook tables.
+type IWebhookRepository interface {
+	CreateWebhook(ctx context.Context, data *model.WebhookDTO) (model.Webhook, error)
+	GetWebhook(ctx context.Context, id uint64) (model.Webhook, error)
+	GetWebhooks(ctx context.Context, page model.PageParams) (model.Page[model.Webhook], error)
+	UpdateWebhook(ctx context.Context, id uint64, data *model.WebhookDTO) (model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uint64) error
+	EnqueueDeliveries(ctx context.Context, eventId string, eventType string, payload string, at time.Time) (int64, error)
+	ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (*model.PendingDelivery, error)
+	FinishDelivery(ctx context.Context, delivery *model.WebhookDelivery, nextAttemptAt time.Time, deliveredAt *time.Time) (bool, error)
+	GetDelivery(ctx context.Context, webhookId uint64, id uint64) (model.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, webhookId uint64, status *model.DeliveryStatus, page model.PageParams) (model.Page[model.WebhookDelivery], error)
+	RetryDelivery(ctx context.Context, webhookId uint64, id uint64, at time.Time) (bool, error)
+}
+
+type WebhookRepository struct {
+	db IDatabase
+}
+
+func NewWebhookRepository(db IDatabase) (*WebhookRepository, error) {
+	var err error
+
+	if db == nil {
+		db, err = database.GetPoolConnection()
+		if err != nil {
+			slog.Error("Failed to connect to database", "error", err)
+			return nil, err
+		}
+	}
+
+	return &WebhookRepository{
+		db: db,
+	}, nil
+}
+
+const webhookColumns = `id, url, secret, event_types, created_at, updated_at`
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		last_status_code, last_error, created_at, delivered_at`
+
+// withEventTypes splits the stored event types of the webhooks
+func withEventTypes(webhooks ...*model.Webhook) {
+	for _, webhook := range webhooks {
+		webhook.EventTypes = strings.Split(webhook.EventTypesList, ",")
+	}
+}
+
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, data *model.WebhookDTO) (model.Webhook, error) {
+	ctx, done := instrument(ctx, "webhook", "CreateWebhook")
+	defer done()
+
+	// The ID is read in the same transaction, so it's the one of the connection that inserted it
+	var id uint64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			INSERT INTO webhook_subscription (url, secret, event_types)
+			VALUES (?, ?, ?);
+		`,
+			data.URL, data.Secret, strings.Join(data.EventTypes, ","),
+		)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		return tx.Raw("SELECT LAST_INSERT_ID()").Scan(&id).Error
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create webhook", "error", err)
+		return model.Webhook{}, err
+	}
+
+	return getWebhook(r.db.WithContext(ctx), id)
+}
+
+func (r *WebhookRepository) GetWebhook(ctx context.Context, id uint64) (model.Webhook, error) {
+	ctx, done := instrument(ctx, "webhook", "GetWebhook")
+	defer done()
+
+	return getWebhook(r.db.WithContext(ctx), id)
+}
+
+// getWebhook reads the webhook with db, which may be a transaction
+func getWebhook(db *gorm.DB, id uint64) (model.Webhook, error) {
+	var webhook model.Webhook
+	res := db.Raw(`
+		SELECT `+webhookColumns+`
+		FROM webhook_subscription
+		WHERE id = ?;`,
+		id,
+	).Scan(&webhook)
+
+	if res.Error != nil {
+		return model.Webhook{}, res.Error
+	}
+
+	if webhook.ID == 0 {
+		return model.Webhook{}, &model.NotFoundError{
+			Code:   "webhook.not_found",
+			Params: map[string]string{"webhookId": strconv.FormatUint(id, 10)},
+		}
+	}
+
+	withEventTypes(&webhook)
+	return webhook, nil
+}
+
+// GetWebhooks returns a page of every webhook, by creation date
+func (r *WebhookRepository) GetWebhooks(ctx context.Context, page model.PageParams) (model.Page[model.Webhook], error) {
+	ctx, done := instrument(ctx, "webhook", "GetWebhooks")
+	defer done()
+
+	after, args, orderBy, err := keyset([]string{"created_at", "id"}, page)
+	if err != nil {
+		return model.Page[model.Webhook]{}, err
+	}
+	args = append(args, page.Limit+1)
+
+	webhooks := make([]model.Webhook, 0)
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT `+webhookColumns+`
+		FROM webhook_subscription
+		WHERE `+after+`
+		ORDER BY `+orderBy+`
+		LIMIT ?;
+	`,
+		args...,
+	).Scan(&webhooks)
+
+	if res.Error != nil {
+		return model.Page[model.Webhook]{}, res.Error
+	}
+
+	for i := range webhooks {
+		withEventTypes(&webhooks[i])
+	}
+
+	return pageOf(webhooks, page, func(webhook model.Webhook) []string {
+		return []string{cursorTime(webhook.CreatedAt), strconv.FormatUint(webhook.ID, 10)}
+	}), nil
+}
+
+func (r *WebhookRepository) UpdateWebhook(ctx context.Context, id uint64, data *model.WebhookDTO) (model.Webhook, error) {
+	ctx, done := instrument(ctx, "webhook", "UpdateWebhook")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		UPDATE webhook_subscription
+		SET url = ?, secret = ?, event_types = ?
+		WHERE id = ?;
+	`,
+		data.URL, data.Secret, strings.Join(data.EventTypes, ","), id,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to update webhook", "webhook_id", id, "error", res.Error)
+		return model.Webhook{}, res.Error
+	}
+
+	return getWebhook(r.db.WithContext(ctx), id)
+}
+
+// DeleteWebhook deletes the webhook along with its deliveries, or returns a not found error if it doesn't exist
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id uint64) error {
+	ctx, done := instrument(ctx, "webhook", "DeleteWebhook")
+	defer done()
+
+	deleted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			DELETE FROM webhook_subscription
+			WHERE id = ?;
+		`,
+			id,
+		)
+
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		deleted = true
+		return tx.Exec(`
+			DELETE FROM webhook_delivery
+			WHERE webhook_id = ?;
+		`,
+			id,
+		).Error
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete webhook", "webhook_id", id, "error", err)
+		return err
+	}
+
+	if !deleted {
+		return &model.NotFoundError{
+			Code:   "webhook.not_found",
+			Params: map[string]string{"webhookId": strconv.FormatUint(id, 10)},
+		}
+	}
+
+	return nil
+}
+
+// EnqueueDeliveries stores a delivery of the event due at at for every webhook subscribed to
+// its type, and returns how many were stored. An event is only enqueued once per webhook.
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, eventId string, eventType string, payload string, at time.Time) (int64, error) {
+	ctx, done := instrument(ctx, "webhook", "EnqueueDeliveries")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		INSERT INTO webhook_delivery (webhook_id, event_id, event_type, payload, next_attempt_at)
+		SELECT id, ?, ?, ?, ?
+		FROM webhook_subscription
+		WHERE FIND_IN_SET(?, event_types)
+		ON DUPLICATE KEY UPDATE webhook_delivery.id = webhook_delivery.id;
+	`,
+		eventId, eventType, payload, at, eventType,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to enqueue webhook deliveries", "event_id", eventId, "event_type", eventType, "error", res.Error)
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// ClaimDelivery marks the oldest delivery due at now as being sent until the lease ends, with one
+// more attempt, and returns it with the URL and secret of its webhook. Deliveries whose lease
+// ended while being sent are taken over. It returns nil if none is due.
+//
+// The delivery is selected with FOR UPDATE SKIP LOCKED, so concurrent workers of every replica
+// send different deliveries, and it's only sent by the worker that claimed it.
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (*model.PendingDelivery, error) {
+	ctx, done := instrument(ctx, "webhook", "ClaimDelivery")
+	defer done()
+
+	var delivery *model.PendingDelivery
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint64
+		res := tx.Raw(`
+			SELECT id
+			FROM webhook_delivery
+			WHERE status IN ? AND next_attempt_at <= ?
+			ORDER BY id ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED;
+		`,
+			[]model.DeliveryStatus{model.DeliveryPending, model.DeliverySending}, now,
+		).Scan(&ids)
+
+		if res.Error != nil || len(ids) == 0 {
+			return res.Error
+		}
+
+		res = tx.Exec(`
+			UPDATE webhook_delivery
+			SET status = ?, attempts = attempts + 1, next_attempt_at = ?
+			WHERE id = ? AND status IN ? AND next_attempt_at <= ?;
+		`,
+			model.DeliverySending, now.Add(lease), ids[0],
+			[]model.DeliveryStatus{model.DeliveryPending, model.DeliverySending}, now,
+		)
+
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		delivery = &model.PendingDelivery{}
+		return tx.Raw(`
+			SELECT d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+				d.last_status_code, d.last_error, d.created_at, d.delivered_at, w.url, w.secret
+			FROM webhook_delivery d
+			JOIN webhook_subscription w ON w.id = d.webhook_id
+			WHERE d.id = ?;
+		`,
+			ids[0],
+		).Scan(delivery).Error
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to claim webhook delivery", "error", err)
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// FinishDelivery records the outcome of the attempt to send the delivery claimed with its
+// attempts, set by the caller: its status, last status code and error, nextAttemptAt if it's
+// still pending and deliveredAt if it was delivered. It returns false if the attempt doesn't
+// hold the delivery anymore, because its lease ended and another worker took it over.
+func (r *WebhookRepository) FinishDelivery(ctx context.Context, delivery *model.WebhookDelivery, nextAttemptAt time.Time, deliveredAt *time.Time) (bool, error) {
+	ctx, done := instrument(ctx, "webhook", "FinishDelivery")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		UPDATE webhook_delivery
+		SET status = ?, next_attempt_at = ?, last_status_code = ?, last_error = ?, delivered_at = ?
+		WHERE id = ? AND status = ? AND attempts = ?;
+	`,
+		delivery.Status, nextAttemptAt, delivery.LastStatusCode, delivery.LastError, deliveredAt,
+		delivery.ID, model.DeliverySending, delivery.Attempts,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to finish webhook delivery", "delivery_id", delivery.ID, "error", res.Error)
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, webhookId uint64, id uint64) (model.WebhookDelivery, error) {
+	ctx, done := instrument(ctx, "webhook", "GetDelivery")
+	defer done()
+
+	var delivery model.WebhookDelivery
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT `+deliveryColumns+`
+		FROM webhook_delivery
+		WHERE id = ? AND webhook_id = ?;`,
+		id, webhookId,
+	).Scan(&delivery)
+
+	if res.Error != nil {
+		return model.WebhookDelivery{}, res.Error
+	}
+
+	if delivery.ID == 0 {
+		return model.WebhookDelivery{}, &model.NotFoundError{
+			Code:   "webhook.delivery_not_found",
+			Params: map[string]string{"deliveryId": strconv.FormatUint(id, 10)},
+		}
+	}
+
+	return delivery, nil
+}
+
+// GetDeliveries returns a page of the deliveries of a webhook, by creation date, only the ones
+// with status if it's not nil
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, webhookId uint64, status *model.DeliveryStatus, page model.PageParams) (model.Page[model.WebhookDelivery], error) {
+	ctx, done := instrument(ctx, "webhook", "GetDeliveries")
+	defer done()
+
+	after, afterArgs, orderBy, err := keyset([]string{"created_at", "id"}, page)
+	if err != nil {
+		return model.Page[model.WebhookDelivery]{}, err
+	}
+
+	args := []any{webhookId, status, status}
+	args = append(args, afterArgs...)
+	args = append(args, page.Limit+1)
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	res := r.db.WithContext(ctx).Raw(`
+		SELECT `+deliveryColumns+`
+		FROM webhook_delivery
+		WHERE webhook_id = ?
+			AND (? IS NULL OR status = ?)
+			AND `+after+`
+		ORDER BY `+orderBy+`
+		LIMIT ?;
+	`,
+		args...,
+	).Scan(&deliveries)
+
+	if res.Error != nil {
+		return model.Page[model.WebhookDelivery]{}, res.Error
+	}
+
+	return pageOf(deliveries, page, func(delivery model.WebhookDelivery) []string {
+		return []string{cursorTime(delivery.CreatedAt), strconv.FormatUint(delivery.ID, 10)}
+	}), nil
+}
+
+// RetryDelivery makes a dead delivery pending again, with its attempts reset and due at at. It
+// returns false if the delivery isn't dead, e.g. because it was retried concurrently.
+func (r *WebhookRepository) RetryDelivery(ctx context.Context, webhookId uint64, id uint64, at time.Time) (bool, error) {
+	ctx, done := instrument(ctx, "webhook", "RetryDelivery")
+	defer done()
+
+	res := r.db.WithContext(ctx).Exec(`
+		UPDATE webhook_delivery
+		SET status = ?, attempts = 0, next_attempt_at = ?
+		WHERE id = ? AND webhook_id = ? AND status = ?;
+	`,
+		model.DeliveryPending, at, id, webhookId, model.DeliveryDead,
+	)
+
+	if res.Error != nil {
+		slog.ErrorContext(ctx, "Failed to retry webhook delivery", "delivery_id", id, "error", res.Error)
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,168 @@
+package routes
+
+import (
+	"github.com/NutriPocket/ProgressService/controller"
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRoutes(router *gin.Engine) {
+	{
+		routes := router.Group("/admin")
+		/*
+			Background jobs routes
+		*/
+		routes.GET("/jobs/", getJobs)
+		routes.GET("/jobs/:jobId", getJob)
+		routes.GET("/jobs/:jobId/runs", getJobRuns)
+		/*
+			Webhook routes
+		*/
+		routes.POST("/webhooks/", postWebhook)
+		routes.GET("/webhooks/", getWebhooks)
+		routes.GET("/webhooks/:webhookId", getWebhook)
+		routes.PUT("/webhooks/:webhookId", putWebhook)
+		routes.DELETE("/webhooks/:webhookId", deleteWebhook)
+		routes.GET("/webhooks/:webhookId/deliveries", getWebhookDeliveries)
+		routes.POST("/webhooks/:webhookId/deliveries/:deliveryId/retry", retryWebhookDelivery)
+	}
+}
+
+func getJobs(c *gin.Context) {
+	controller, err := controller.NewJobController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetJobs(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func getJob(c *gin.Context) {
+	controller, err := controller.NewJobController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetJob(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func getJobRuns(c *gin.Context) {
+	controller, err := controller.NewJobController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetJobRuns(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func postWebhook(c *gin.Context) {
+	controller, err := controller.NewWebhookController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.PostWebhook(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func getWebhooks(c *gin.Context) {
+	controller, err := controller.NewWebhookController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetWebhooks(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func getWebhook(c *gin.Context) {
+	controller, err := controller.NewWebhookController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetWebhook(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func putWebhook(c *gin.Context) {
+	controller, err := controller.NewWebhookController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.PutWebhook(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func deleteWebhook(c *gin.Context) {
+	controller, err := controller.NewWebhookController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.DeleteWebhook(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func getWebhookDeliveries(c *gin.Context) {
+	controller, err := controller.NewWebhookController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetDeliveries(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func retryWebhookDelivery(c *gin.Context) {
+	controller, err := controller.NewWebhookController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.RetryDelivery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
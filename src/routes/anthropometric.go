@@ -33,3 +33,45 @@ func getAnthropometricData(c *gin.Context) {
 		return
 	}
 }
+
+func patchAnthropometricData(c *gin.Context) {
+	controller, err := controller.NewAnthropometricController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.PatchAnthropometricData(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func deleteAnthropometricData(c *gin.Context) {
+	controller, err := controller.NewAnthropometricController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.DeleteAnthropometricData(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func postAnthropometricEntry(c *gin.Context) {
+	controller, err := controller.NewAnthropometricController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.PostAnthropometricEntry(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
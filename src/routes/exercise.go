@@ -33,6 +33,20 @@ func getExercisesByUserIdAndDate(c *gin.Context) {
 	}
 }
 
+func getExercise(c *gin.Context) {
+	controller, err := controller.NewExerciseController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetExercise(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
 func putExercise(c *gin.Context) {
 	controller, err := controller.NewExerciseController(nil)
 	if err != nil {
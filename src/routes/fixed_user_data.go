@@ -34,3 +34,17 @@ func getFixedData(c *gin.Context) {
 		return
 	}
 }
+
+func getHeightHistory(c *gin.Context) {
+	controller, err := controller.NewFixedDataController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetHeightHistory(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"github.com/NutriPocket/ProgressService/controller"
+	"github.com/gin-gonic/gin"
+)
+
+func HealthRoutes(router *gin.Engine) {
+	router.GET("/healthz", getLiveness)
+	router.GET("/readyz", getReadiness)
+}
+
+func getLiveness(c *gin.Context) {
+	controller, err := controller.NewHealthController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.Liveness(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func getReadiness(c *gin.Context) {
+	controller, err := controller.NewHealthController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.Readiness(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
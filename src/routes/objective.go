@@ -7,7 +7,7 @@ import (
 )
 
 func putObjectiveData(c *gin.Context) {
-	controller, err := controller.NewObjectiveController(nil)
+	controller, err := controller.NewObjectiveController(nil, nil)
 	if err != nil {
 		c.Error(err)
 		return
@@ -21,7 +21,7 @@ func putObjectiveData(c *gin.Context) {
 }
 
 func getObjectiveData(c *gin.Context) {
-	controller, err := controller.NewObjectiveController(nil)
+	controller, err := controller.NewObjectiveController(nil, nil)
 	if err != nil {
 		c.Error(err)
 		return
@@ -33,3 +33,31 @@ func getObjectiveData(c *gin.Context) {
 		return
 	}
 }
+
+func getObjectiveHistory(c *gin.Context) {
+	controller, err := controller.NewObjectiveController(nil, nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetObjectiveHistoryByUser(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func getObjectiveMilestones(c *gin.Context) {
+	controller, err := controller.NewObjectiveController(nil, nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetMilestonesByUser(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"github.com/NutriPocket/ProgressService/controller"
+	"github.com/gin-gonic/gin"
+)
+
+func postReminder(c *gin.Context) {
+	controller, err := controller.NewReminderController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.PostReminder(c)
+
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func getReminders(c *gin.Context) {
+	controller, err := controller.NewReminderController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetReminders(c)
+
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func getReminder(c *gin.Context) {
+	controller, err := controller.NewReminderController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.GetReminder(c)
+
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func putReminder(c *gin.Context) {
+	controller, err := controller.NewReminderController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.PutReminder(c)
+
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
+
+func deleteReminder(c *gin.Context) {
+	controller, err := controller.NewReminderController(nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = controller.DeleteReminder(c)
+
+	if err != nil {
+		c.Error(err)
+		return
+	}
+}
@@ -1,38 +1,61 @@
 package routes
 
-import "github.com/gin-gonic/gin"
+import (
+	middlewareIdempotency "github.com/NutriPocket/ProgressService/middleware/idempotency_middleware"
+	"github.com/gin-gonic/gin"
+)
 
 func UsersRoutes(router *gin.Engine) {
 	{
+		// idempotent replays the response of the create requests retried with the same Idempotency-Key
+		idempotent := middlewareIdempotency.IdempotencyMiddleware(nil)
+
 		routes := router.Group("/users")
 		/*
 			Anthropometric Data routes
 		*/
 		routes.PUT("/:userId/anthropometrics/", putAnthropometricData)
+		routes.POST("/:userId/anthropometrics/", idempotent, postAnthropometricEntry)
 		routes.GET("/:userId/anthropometrics/", getAnthropometricData)
+		routes.PATCH("/:userId/anthropometrics/:date", patchAnthropometricData)
+		routes.DELETE("/:userId/anthropometrics/:date", deleteAnthropometricData)
+		routes.PATCH("/:userId/anthropometrics/:date/:id", patchAnthropometricData)
+		routes.DELETE("/:userId/anthropometrics/:date/:id", deleteAnthropometricData)
 		/*
 			Fixed User Data routes
 		*/
 		routes.PUT("/:userId/fixedData/", putFixedData)
 		routes.GET("/:userId/fixedData/", getFixedData)
+		routes.GET("/:userId/fixedData/heights/", getHeightHistory)
 		/*
 			Objectives routes
 		*/
 		routes.PUT("/:userId/objectives/", putObjectiveData)
 		routes.GET("/:userId/objectives/", getObjectiveData)
+		routes.GET("/:userId/objectives/history/", getObjectiveHistory)
+		routes.GET("/:userId/objectives/milestones/", getObjectiveMilestones)
 		/*
 			Routines routes
 		*/
-		routes.POST("/:userId/routines/", postRoutine)
+		routes.POST("/:userId/routines/", idempotent, postRoutine)
 		routes.GET("/:userId/routines/", getRoutines)
 		routes.DELETE("/:userId/routines/", deleteRoutine)
 		routes.GET("/freeSchedules/", getFreeSchedules)
 		/*
 			Exercise routes
 		*/
-		routes.POST("/:userId/exercises/", postExercise)
+		routes.POST("/:userId/exercises/", idempotent, postExercise)
 		routes.GET("/:userId/exercises/", getExercisesByUserIdAndDate)
+		routes.GET("/:userId/exercises/:id", getExercise)
 		routes.PUT("/:userId/exercises/:id", putExercise)
 		routes.DELETE("/:userId/exercises/:id", deleteExercise)
+		/*
+			Reminder routes
+		*/
+		routes.POST("/:userId/reminders/", idempotent, postReminder)
+		routes.GET("/:userId/reminders/", getReminders)
+		routes.GET("/:userId/reminders/:id", getReminder)
+		routes.PUT("/:userId/reminders/:id", putReminder)
+		routes.DELETE("/:userId/reminders/:id", deleteReminder)
 	}
 }
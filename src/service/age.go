@@ -0,0 +1,57 @@
+package service
+
+import (
+	"time"
+)
+
+// location returns the time zone of the user, UTC if the user hasn't set one or it's unknown
+func location(timeZone *string) *time.Location {
+	if timeZone == nil {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(*timeZone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// parseDate parses a calendar date, either "YYYY-MM-DD" or a timestamp as the database
+// returns DATE columns, ignoring its time
+func parseDate(date string) (time.Time, error) {
+	if len(date) > len(time.DateOnly) {
+		date = date[:len(time.DateOnly)]
+	}
+
+	return time.Parse(time.DateOnly, date)
+}
+
+// ageAt returns the whole years someone born on birthday had at the instant at, taking the
+// calendar date of at in loc. Someone born on February 29 turns a year older on March 1 in
+// common years.
+func ageAt(birthday time.Time, at time.Time, loc *time.Location) uint {
+	at = at.In(loc)
+
+	age := at.Year() - birthday.Year()
+	if at.Month() < birthday.Month() || (at.Month() == birthday.Month() && at.Day() < birthday.Day()) {
+		age--
+	}
+
+	if age < 0 {
+		return 0
+	}
+
+	return uint(age)
+}
+
+// currentAge returns the age of someone born on birthday today, in the time zone of the user
+func currentAge(birthday string, timeZone *string) (uint, error) {
+	born, err := parseDate(birthday)
+	if err != nil {
+		return 0, err
+	}
+
+	return ageAt(born, time.Now(), location(timeZone)), nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgeAt(t *testing.T) {
+	ahead := time.FixedZone("UTC+3", 3*60*60)
+	behind := time.FixedZone("UTC-3", -3*60*60)
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		birthday time.Time
+		at       time.Time
+		loc      *time.Location
+		expected uint
+	}{
+		{
+			name:     "A February 29 birthday isn't reached on February 28 of a common year",
+			birthday: date(2000, time.February, 29),
+			at:       time.Date(2023, time.February, 28, 23, 59, 0, 0, time.UTC),
+			loc:      time.UTC,
+			expected: 22,
+		},
+		{
+			name:     "A February 29 birthday is reached on March 1 of a common year",
+			birthday: date(2000, time.February, 29),
+			at:       date(2023, time.March, 1),
+			loc:      time.UTC,
+			expected: 23,
+		},
+		{
+			name:     "A February 29 birthday is reached on February 29 of a leap year",
+			birthday: date(2000, time.February, 29),
+			at:       date(2024, time.February, 29),
+			loc:      time.UTC,
+			expected: 24,
+		},
+		{
+			name:     "A January 1 birthday is reached at midnight in a time zone ahead of UTC",
+			birthday: date(1990, time.January, 1),
+			at:       time.Date(2019, time.December, 31, 22, 0, 0, 0, time.UTC),
+			loc:      ahead,
+			expected: 30,
+		},
+		{
+			name:     "A January 1 birthday isn't reached yet in a time zone behind UTC",
+			birthday: date(1990, time.January, 1),
+			at:       time.Date(2020, time.January, 1, 2, 0, 0, 0, time.UTC),
+			loc:      behind,
+			expected: 29,
+		},
+		{
+			name:     "A December 31 birthday isn't reached yet in a time zone behind UTC",
+			birthday: date(1990, time.December, 31),
+			at:       time.Date(2020, time.December, 31, 2, 0, 0, 0, time.UTC),
+			loc:      behind,
+			expected: 29,
+		},
+		{
+			name:     "A December 31 birthday is already over in a time zone ahead of UTC",
+			birthday: date(1990, time.December, 31),
+			at:       time.Date(2020, time.December, 31, 22, 0, 0, 0, time.UTC),
+			loc:      ahead,
+			expected: 30,
+		},
+		{
+			name:     "A December 31 birthday is reached at midnight in UTC",
+			birthday: date(1990, time.December, 31),
+			at:       date(2020, time.December, 31),
+			loc:      time.UTC,
+			expected: 30,
+		},
+		{
+			name:     "Someone not born yet is 0 years old",
+			birthday: date(2030, time.January, 1),
+			at:       date(2020, time.January, 1),
+			loc:      time.UTC,
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ageAt(tt.birthday, tt.at, tt.loc))
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+)
+
+// bodyProfile holds the data of a user that body indicators depend on
+type bodyProfile struct {
+	sex      *string
+	height   uint
+	heights  []model.HeightRecord
+	birthday *time.Time
+	location *time.Location
+}
+
+// heightAt returns the height of the user valid at date, an RFC 3339 timestamp.
+// Measurements taken before the first recorded height use the first one, and users without
+// history (registered before it was kept) use their current height.
+func (p *bodyProfile) heightAt(date string) uint {
+	if len(p.heights) == 0 {
+		return p.height
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return p.height
+	}
+
+	height := p.heights[0].Height
+	for _, record := range p.heights[1:] {
+		validFrom, err := time.Parse(time.RFC3339Nano, record.ValidFrom)
+		if err != nil || validFrom.After(at) {
+			break
+		}
+		height = record.Height
+	}
+
+	return height
+}
+
+// ageAt returns the age the user had at date, an RFC 3339 timestamp, in the time zone of the
+// user. ok is false if it can't be known.
+func (p *bodyProfile) ageAt(date string) (age uint, ok bool) {
+	if p.birthday == nil {
+		return 0, false
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return 0, false
+	}
+
+	return ageAt(*p.birthday, at, p.location), true
+}
+
+// indicators returns the body indicators of data and the circumferences measured along with it,
+// with the height and age the user had back then. A nil profile means the user has no fixed
+// data registered.
+func (p *bodyProfile) indicators(data *model.AnthropometricData, circumferences *model.Circumferences) *model.BodyIndicators {
+	if p == nil {
+		return bodyIndicators(circumferences, 0, nil)
+	}
+
+	height := p.heightAt(data.CreatedAt)
+	indicators := bodyIndicators(circumferences, height, p.sex)
+
+	age, ok := p.ageAt(data.CreatedAt)
+	if !ok || p.sex == nil || height == 0 || data.Weight <= 0 {
+		return indicators
+	}
+
+	if bmr := basalMetabolicRate(*p.sex, float64(data.Weight), float64(height), float64(age)); bmr != nil {
+		if indicators == nil {
+			indicators = &model.BodyIndicators{}
+		}
+		indicators.BMR = bmr
+	}
+
+	return indicators
+}
+
+// bodyIndicators returns the indicators derived from the circumferences of a user, or nil if
+// none can be derived. height is 0 if the user has no height registered, in which case only
+// the waist-to-hip ratio can be derived.
+func bodyIndicators(circumferences *model.Circumferences, height uint, sex *string) *model.BodyIndicators {
+	if circumferences == nil || circumferences.Waist == nil {
+		return nil
+	}
+
+	var indicators model.BodyIndicators
+	waist := float64(*circumferences.Waist)
+
+	if circumferences.Hip != nil {
+		indicators.WaistToHip = round(waist/float64(*circumferences.Hip), 3)
+	}
+
+	if height > 0 {
+		indicators.WaistToHeight = round(waist/float64(height), 3)
+
+		if sex != nil && circumferences.Neck != nil {
+			indicators.NavyBodyFat = navyBodyFat(*sex, waist, float64(*circumferences.Neck), circumferences.Hip, float64(height))
+		}
+	}
+
+	if indicators.WaistToHip == nil && indicators.WaistToHeight == nil && indicators.NavyBodyFat == nil {
+		return nil
+	}
+
+	return &indicators
+}
+
+// navyBodyFat estimates the body fat percentage with the US Navy formulas, with every
+// measurement in centimeters. It returns nil if the measurements can't be used by the formula.
+func navyBodyFat(sex string, waist float64, neck float64, hip *float32, height float64) *float32 {
+	var density float64
+
+	switch sex {
+	case model.SexMale:
+		if waist <= neck {
+			return nil
+		}
+		density = 1.0324 - 0.19077*math.Log10(waist-neck) + 0.15456*math.Log10(height)
+	case model.SexFemale:
+		if hip == nil || waist+float64(*hip) <= neck {
+			return nil
+		}
+		density = 1.29579 - 0.35004*math.Log10(waist+float64(*hip)-neck) + 0.22100*math.Log10(height)
+	default:
+		return nil
+	}
+
+	return round(495/density-450, 2)
+}
+
+// basalMetabolicRate estimates the kcal burned per day at rest with the Mifflin-St Jeor
+// equation, with the weight in kilograms and the height in centimeters
+func basalMetabolicRate(sex string, weight float64, height float64, age float64) *float32 {
+	bmr := 10*weight + 6.25*height - 5*age
+
+	switch sex {
+	case model.SexMale:
+		bmr += 5
+	case model.SexFemale:
+		bmr -= 161
+	default:
+		return nil
+	}
+
+	return round(bmr, 0)
+}
+
+// combineMeasurements returns the minimum or the mean of each body measurement of the entries
+func combineMeasurements(entries []model.AnthropometricEntry, aggregate model.AnthropometricAggregate) model.BodyMeasurements {
+	var ret model.BodyMeasurements
+
+	var circumferences model.Circumferences
+	for i, field := range circumferences.Fields() {
+		*field = combineField(entries, aggregate, func(e *model.AnthropometricEntry) *float32 {
+			if e.Circumferences == nil {
+				return nil
+			}
+			return *e.Circumferences.Fields()[i]
+		})
+	}
+
+	if circumferences != (model.Circumferences{}) {
+		ret.Circumferences = &circumferences
+	}
+
+	var skinfolds model.Skinfolds
+	for i, field := range skinfolds.Fields() {
+		*field = combineField(entries, aggregate, func(e *model.AnthropometricEntry) *float32 {
+			if e.Skinfolds == nil {
+				return nil
+			}
+			return *e.Skinfolds.Fields()[i]
+		})
+	}
+
+	if skinfolds != (model.Skinfolds{}) {
+		ret.Skinfolds = &skinfolds
+	}
+
+	return ret
+}
+
+func round(value float64, decimals int) *float32 {
+	precision := math.Pow(10, float64(decimals))
+	ret := float32(math.Round(value*precision) / precision)
+
+	return &ret
+}
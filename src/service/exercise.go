@@ -1,18 +1,22 @@
 package service
 
 import (
+	"context"
+	"log/slog"
 	"time"
 
+	"github.com/NutriPocket/ProgressService/metrics"
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/NutriPocket/ProgressService/repository"
 )
 
 // IExerciseService defines the interface for exercise-related operations
 type IExerciseService interface {
-	CreateExercise(data *model.ExerciseDTO) (model.ExerciseData, error)
-	GetExercisesByUserIdAndDate(userId string, date string) (model.AllExercisesInDay, error)
-	UpdateExercise(id uint64, userId string, data *model.ExerciseDTO) (model.ExerciseData, error)
-	DeleteExercise(id uint64, userId string) error
+	CreateExercise(ctx context.Context, data *model.ExerciseDTO) (model.ExerciseData, error)
+	GetExercise(ctx context.Context, id uint64, userId string) (model.ExerciseData, error)
+	GetExercisesByUserIdAndDate(ctx context.Context, userId string, date string, page model.PageParams) (model.AllExercisesInDay, *model.Cursor, error)
+	UpdateExercise(ctx context.Context, id uint64, userId string, data *model.ExerciseDTO, version *uint) (model.ExerciseData, error)
+	DeleteExercise(ctx context.Context, id uint64, userId string, version *uint) error
 }
 
 // ExerciseService implements the IExerciseService interface
@@ -37,81 +41,97 @@ func NewExerciseService(r repository.IExerciseRepository) (*ExerciseService, err
 }
 
 // CreateExercise adds a new exercise record
-func (s *ExerciseService) CreateExercise(data *model.ExerciseDTO) (model.ExerciseData, error) {
-	exercise, err := s.r.CreateExercise(data)
+func (s *ExerciseService) CreateExercise(ctx context.Context, data *model.ExerciseDTO) (model.ExerciseData, error) {
+	exercise, err := s.r.CreateExercise(ctx, data)
 	if err != nil {
-		log.Errorf("Failed to create exercise: %v", err)
+		slog.ErrorContext(ctx, "Failed to create exercise", "error", err)
 		return model.ExerciseData{}, err
 	}
 
+	metrics.ExercisesCreated.Inc()
+
+	return exercise, nil
+}
+
+// GetExercise retrieves an exercise of the user by its ID
+func (s *ExerciseService) GetExercise(ctx context.Context, id uint64, userId string) (model.ExerciseData, error) {
+	var exercise model.ExerciseData
+	if err := s.r.GetExerciseById(ctx, id, &exercise); err != nil {
+		return model.ExerciseData{}, err
+	}
+
+	if exercise.UserID != userId {
+		return model.ExerciseData{}, &model.AuthenticationError{
+			Code: "exercise.forbidden_get",
+		}
+	}
+
 	return exercise, nil
 }
 
-// GetExercisesByUserIdAndDate retrieves exercises for a user on a specific date
-func (s *ExerciseService) GetExercisesByUserIdAndDate(userId string, date string) (model.AllExercisesInDay, error) {
+// GetExercisesByUserIdAndDate retrieves a page of the exercises of a user on a specific date,
+// and the cursor of the next page if any
+func (s *ExerciseService) GetExercisesByUserIdAndDate(ctx context.Context, userId string, date string, page model.PageParams) (model.AllExercisesInDay, *model.Cursor, error) {
 	if date == "" {
 		date = time.Now().Format("2006-01-02") // Default to today if no date is provided
 	} else {
 		_, err := time.Parse("2006-01-02", date)
 		if err != nil {
-			return model.AllExercisesInDay{}, &model.ValidationError{
-				Title:  "Invalid date format",
-				Detail: "Date must be in YYYY-MM-DD format",
+			return model.AllExercisesInDay{}, nil, &model.ValidationError{
+				Code: "request.invalid_date",
 			}
 		}
 	}
 
-	exercises, err := s.r.GetExercisesByUserIdAndDate(userId, date)
+	exercises, next, err := s.r.GetExercisesByUserIdAndDate(ctx, userId, date, page)
 	if err != nil {
-		log.Errorf("Failed to get exercises for user %s on date %s: %v", userId, date, err)
-		return model.AllExercisesInDay{}, err
+		slog.ErrorContext(ctx, "Failed to get exercises", "user_id", userId, "date", date, "error", err)
+		return model.AllExercisesInDay{}, nil, err
 	}
 
-	return exercises, nil
+	return exercises, next, nil
 }
 
-// UpdateExercise updates an existing exercise
-func (s *ExerciseService) UpdateExercise(id uint64, userId string, data *model.ExerciseDTO) (model.ExerciseData, error) {
+// UpdateExercise updates an existing exercise, if version isn't nil only while it's at that version
+func (s *ExerciseService) UpdateExercise(ctx context.Context, id uint64, userId string, data *model.ExerciseDTO, version *uint) (model.ExerciseData, error) {
 	var existingExercise model.ExerciseData
-	err := s.r.GetExerciseById(id, &existingExercise)
+	err := s.r.GetExerciseById(ctx, id, &existingExercise)
 	if err != nil {
 		return model.ExerciseData{}, err
 	}
 
 	if existingExercise.UserID != userId {
 		return model.ExerciseData{}, &model.AuthenticationError{
-			Title:  "Unauthorized",
-			Detail: "You are not authorized to update this exercise",
+			Code: "exercise.forbidden_update",
 		}
 	}
 
-	exercise, err := s.r.UpdateExercise(id, data)
+	exercise, err := s.r.UpdateExercise(ctx, id, data, version)
 	if err != nil {
-		log.Errorf("Failed to update exercise with ID %d: %v", id, err)
+		slog.ErrorContext(ctx, "Failed to update exercise", "exercise_id", id, "error", err)
 		return model.ExerciseData{}, err
 	}
 
 	return exercise, nil
 }
 
-// DeleteExercise removes an exercise by its ID
-func (s *ExerciseService) DeleteExercise(id uint64, userId string) error {
+// DeleteExercise removes an exercise by its ID, if version isn't nil only while it's at that version
+func (s *ExerciseService) DeleteExercise(ctx context.Context, id uint64, userId string, version *uint) error {
 	var existingExercise model.ExerciseData
-	err := s.r.GetExerciseById(id, &existingExercise)
+	err := s.r.GetExerciseById(ctx, id, &existingExercise)
 	if err != nil {
 		return err
 	}
 
 	if existingExercise.UserID != userId {
 		return &model.AuthenticationError{
-			Title:  "Unauthorized",
-			Detail: "You are not authorized to delete this exercise",
+			Code: "exercise.forbidden_delete",
 		}
 	}
 
-	err = s.r.DeleteExercise(id)
+	err = s.r.DeleteExercise(ctx, id, version)
 	if err != nil {
-		log.Errorf("Failed to delete exercise with ID %d: %v", id, err)
+		slog.ErrorContext(ctx, "Failed to delete exercise", "exercise_id", id, "error", err)
 		return err
 	}
 
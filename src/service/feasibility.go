@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+)
+
+// Default maximum paces of body composition objectives, in kilograms per week. Objectives that
+// require a faster one are rejected.
+const (
+	defaultMaxWeightLoss = 1.0
+	defaultMaxWeightGain = 0.5
+	defaultMaxMuscleGain = 0.25
+)
+
+const (
+	// recommendedLossRatio is the fraction of the body weight it's recommended to lose per week at most
+	recommendedLossRatio = 0.01
+	// minHealthyBMI is the lowest body mass index that isn't underweight
+	minHealthyBMI = 18.5
+)
+
+// essentialFatRatio is the fraction of the body weight of essential fat, by sex
+var essentialFatRatio = map[string]float64{
+	model.SexMale:   0.03,
+	model.SexFemale: 0.12,
+}
+
+// rateEnv parses the environment variable key as a pace in kilograms per week.
+// It returns def if the variable isn't set or isn't a positive number.
+func rateEnv(key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed <= 0 {
+		slog.Warn("Invalid pace, using the default", "key", key, "value", value, "default", def)
+		return def
+	}
+
+	return parsed
+}
+
+// checkFeasibility returns the pace a body composition objective requires from the last
+// measurement of the user, or nil if the user has no measurements. It returns a validation
+// error with the earliest safe deadline if the pace exceeds OBJECTIVE_MAX_WEIGHT_LOSS,
+// OBJECTIVE_MAX_WEIGHT_GAIN or OBJECTIVE_MAX_MUSCLE_GAIN.
+func (s *ObjectiveService) checkFeasibility(ctx context.Context, objective *model.ObjectiveData, now time.Time) (*model.ObjectiveFeasibility, error) {
+	if objective.Type != model.ObjectiveBodyComposition {
+		return nil, nil
+	}
+
+	latest, err := s.ar.GetAllDataByUserId(ctx, objective.UserID, &model.GetAnthropometricParams{
+		PageParams: model.PageParams{Limit: 1, Sort: model.SortDesc},
+	})
+	if err != nil || len(latest.Items) == 0 {
+		return nil, err
+	}
+	current := latest.Items[0].AnthropometricData
+
+	var fixedData model.BaseFixedUserData
+	err = s.fdr.GetBaseFixedUserData(ctx, objective.UserID, &fixedData)
+	if _, ok := err.(*model.NotFoundError); err != nil && !ok {
+		return nil, err
+	}
+
+	deadline, err := parseDate(objective.Deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	today := localDate(now, location(fixedData.TimeZone))
+	weeks := float64(daysBetween(today, deadline)) / 7
+
+	change := float64(objective.Weight - current.Weight)
+	feasibility := &model.ObjectiveFeasibility{
+		WeeklyRate: *round(change/weeks, 2),
+		Warnings:   []model.Warning{},
+	}
+
+	// safeWeeks is the time needed to reach the objective at the maximum paces, and
+	// recommendedWeeks at the recommended ones
+	var safeWeeks, recommendedWeeks float64
+
+	if change < 0 {
+		safeWeeks = -change / rateEnv("OBJECTIVE_MAX_WEIGHT_LOSS", defaultMaxWeightLoss)
+
+		recommendedWeeks = -change / (float64(current.Weight) * recommendedLossRatio)
+		if recommendedWeeks > weeks {
+			feasibility.Warnings = append(feasibility.Warnings, model.Warning{Code: "objective.fast_weight_loss"})
+		}
+	} else {
+		safeWeeks = change / rateEnv("OBJECTIVE_MAX_WEIGHT_GAIN", defaultMaxWeightGain)
+	}
+
+	if objective.MuscleMass != nil && current.MuscleMass != nil {
+		muscleChange := float64(*objective.MuscleMass - *current.MuscleMass)
+		feasibility.MuscleWeeklyRate = round(muscleChange/weeks, 2)
+
+		if muscleChange > 0 {
+			safeWeeks = math.Max(safeWeeks, muscleChange/rateEnv("OBJECTIVE_MAX_MUSCLE_GAIN", defaultMaxMuscleGain))
+		}
+	}
+
+	if safeWeeks > weeks {
+		return nil, &model.ValidationError{
+			Code:   "objective.unsafe_rate",
+			Params: map[string]string{"deadline": deadlineAfter(today, safeWeeks)},
+		}
+	}
+
+	if recommendedWeeks > weeks {
+		suggested := deadlineAfter(today, recommendedWeeks)
+		feasibility.SuggestedDeadline = &suggested
+	}
+
+	if fixedData.Height > 0 {
+		height := float64(fixedData.Height) / 100
+		if float64(objective.Weight)/(height*height) < minHealthyBMI {
+			feasibility.Warnings = append(feasibility.Warnings, model.Warning{Code: "objective.underweight_target"})
+		}
+	}
+
+	if objective.FatMass != nil && fixedData.Sex != nil {
+		if ratio, ok := essentialFatRatio[*fixedData.Sex]; ok && float64(*objective.FatMass) < ratio*float64(objective.Weight) {
+			feasibility.Warnings = append(feasibility.Warnings, model.Warning{Code: "objective.low_fat_mass"})
+		}
+	}
+
+	return feasibility, nil
+}
+
+// localDate returns the calendar date of now in loc, as midnight UTC so dates can be subtracted
+func localDate(now time.Time, loc *time.Location) time.Time {
+	now = now.In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween returns the whole days from the date from to the later date to, at least 1
+func daysBetween(from time.Time, to time.Time) int {
+	return max(1, int(math.Round(to.Sub(from).Hours()/24)))
+}
+
+// deadlineAfter returns the date weeks after today, rounded up to whole days
+func deadlineAfter(today time.Time, weeks float64) string {
+	// The epsilon keeps float errors from adding a day to exact weeks
+	return today.AddDate(0, 0, int(math.Ceil(weeks*7-1e-6))).Format(time.DateOnly)
+}
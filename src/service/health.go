@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/database"
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/repository"
+)
+
+// requiredConfig are the environment variables that must be set for the service to be ready
+var requiredConfig = []string{"DB_USER", "DB_NAME", "JWT_SECRET_KEY"}
+
+// shuttingDown is set once the service starts shutting down, so readiness starts failing
+// and load balancers stop routing new requests to this instance.
+var shuttingDown atomic.Bool
+
+// MarkShuttingDown flips the readiness of the service to failing.
+func MarkShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+type IHealthService interface {
+	Liveness() model.HealthReport
+	Readiness(ctx context.Context) model.HealthReport
+}
+
+type HealthService struct {
+	r repository.IHealthRepository
+}
+
+// NewHealthService creates a new HealthService. Unlike the other services it doesn't
+// connect to the database when r is nil, because reporting a database outage is
+// precisely what the readiness check is for.
+func NewHealthService(r repository.IHealthRepository) (*HealthService, error) {
+	return &HealthService{
+		r: r,
+	}, nil
+}
+
+// Liveness reports that the process is up and able to serve requests.
+func (s *HealthService) Liveness() model.HealthReport {
+	return model.HealthReport{
+		Status: model.HealthStatusUp,
+	}
+}
+
+// Readiness checks every dependency of the service and reports their status and latency.
+// The report status is up only if every dependency is up and the service isn't shutting down.
+func (s *HealthService) Readiness(ctx context.Context) model.HealthReport {
+	checks := make(map[string]model.DependencyStatus)
+
+	checks["config"] = s.checkConfig()
+	checks["database"] = s.checkDatabase(ctx)
+
+	if checks["database"].Status == model.HealthStatusUp {
+		checks["migrations"] = s.checkMigrations(ctx)
+	} else {
+		checks["migrations"] = model.DependencyStatus{
+			Status: model.HealthStatusDown,
+			Error:  "database is unreachable",
+		}
+	}
+
+	if shuttingDown.Load() {
+		checks["shutdown"] = model.DependencyStatus{
+			Status: model.HealthStatusDown,
+			Error:  "the service is shutting down",
+		}
+	}
+
+	status := model.HealthStatusUp
+	for _, check := range checks {
+		if check.Status != model.HealthStatusUp {
+			status = model.HealthStatusDown
+			break
+		}
+	}
+
+	return model.HealthReport{
+		Status: status,
+		Checks: checks,
+	}
+}
+
+func (s *HealthService) checkConfig() model.DependencyStatus {
+	start := time.Now()
+
+	missing := make([]string, 0)
+	for _, key := range requiredConfig {
+		if os.Getenv(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return dependencyDown(start, fmt.Errorf("missing environment variables: %s", strings.Join(missing, ", ")))
+	}
+
+	return dependencyUp(start)
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) model.DependencyStatus {
+	start := time.Now()
+
+	if err := database.Ping(ctx); err != nil {
+		slog.WarnContext(ctx, "Readiness database check failed", "error", err)
+		return dependencyDown(start, err)
+	}
+
+	return dependencyUp(start)
+}
+
+func (s *HealthService) checkMigrations(ctx context.Context) model.DependencyStatus {
+	start := time.Now()
+
+	if s.r == nil {
+		r, err := repository.NewHealthRepository(nil)
+		if err != nil {
+			return dependencyDown(start, err)
+		}
+		s.r = r
+	}
+
+	missing, err := s.r.GetMissingTables(ctx, repository.RequiredTables)
+	if err != nil {
+		return dependencyDown(start, err)
+	}
+
+	if len(missing) > 0 {
+		return dependencyDown(start, fmt.Errorf("missing tables: %s", strings.Join(missing, ", ")))
+	}
+
+	missing, err = s.r.GetMissingColumns(ctx, repository.RequiredColumns)
+	if err != nil {
+		return dependencyDown(start, err)
+	}
+
+	if len(missing) > 0 {
+		return dependencyDown(start, fmt.Errorf("missing columns: %s, run sql/migrations.sql", strings.Join(missing, ", ")))
+	}
+
+	return dependencyUp(start)
+}
+
+func dependencyUp(start time.Time) model.DependencyStatus {
+	return model.DependencyStatus{
+		Status:    model.HealthStatusUp,
+		LatencyMs: elapsedMs(start),
+	}
+}
+
+func dependencyDown(start time.Time, err error) model.DependencyStatus {
+	return model.DependencyStatus{
+		Status:    model.HealthStatusDown,
+		LatencyMs: elapsedMs(start),
+		Error:     err.Error(),
+	}
+}
+
+func elapsedMs(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/repository"
+)
+
+// Defaults of how long the idempotency keys are kept and locked
+const (
+	defaultIdempotencyTTL  = 24 * time.Hour
+	defaultIdempotencyLock = time.Minute
+)
+
+type IIdempotencyService interface {
+	Begin(ctx context.Context, userId string, key string, fingerprint string) (*model.IdempotencyRecord, error)
+	Complete(ctx context.Context, userId string, key string, statusCode int, contentType string, response string) error
+	Release(ctx context.Context, userId string, key string) error
+	Prune(ctx context.Context, now time.Time) (int64, error)
+}
+
+type IdempotencyService struct {
+	r repository.IIdempotencyRepository
+	// ttl and lock are read from IDEMPOTENCY_KEY_TTL and IDEMPOTENCY_LOCK_TIMEOUT
+	ttl  time.Duration
+	lock time.Duration
+}
+
+func NewIdempotencyService(r repository.IIdempotencyRepository) (*IdempotencyService, error) {
+	var err error
+
+	if r == nil {
+		r, err = repository.NewIdempotencyRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &IdempotencyService{
+		r:    r,
+		ttl:  durationEnv("IDEMPOTENCY_KEY_TTL", defaultIdempotencyTTL),
+		lock: durationEnv("IDEMPOTENCY_LOCK_TIMEOUT", defaultIdempotencyLock),
+	}, nil
+}
+
+// Begin claims the key of the user for the request with the fingerprint. It returns nil if the
+// request must be processed, or the completed record whose response must be replayed. A key
+// used with another request is unprocessable, and one whose request is still being processed
+// is a conflict.
+func (s *IdempotencyService) Begin(ctx context.Context, userId string, key string, fingerprint string) (*model.IdempotencyRecord, error) {
+	now := time.Now()
+	stored, err := s.r.ClaimKey(ctx, &model.IdempotencyRecord{
+		UserID:      userId,
+		Key:         key,
+		Fingerprint: fingerprint,
+	}, now, now.Add(s.lock), now.Add(s.ttl))
+	if err != nil || stored == nil {
+		return nil, err
+	}
+
+	if stored.Fingerprint != fingerprint {
+		return nil, &model.UnprocessableError{
+			Code: "idempotency.key_reused",
+		}
+	}
+
+	if stored.StatusCode == nil {
+		return nil, &model.ConflictError{
+			Code: "idempotency.request_in_progress",
+		}
+	}
+
+	return stored, nil
+}
+
+// Complete stores the response of the request of the key, so its retries are answered with it
+func (s *IdempotencyService) Complete(ctx context.Context, userId string, key string, statusCode int, contentType string, response string) error {
+	return s.r.CompleteKey(ctx, userId, key, statusCode, contentType, response)
+}
+
+// Release frees the key of a request that failed, so it can be retried with the same key
+func (s *IdempotencyService) Release(ctx context.Context, userId string, key string) error {
+	return s.r.ReleaseKey(ctx, userId, key)
+}
+
+// Prune deletes the keys expired at now and returns how many were deleted
+func (s *IdempotencyService) Prune(ctx context.Context, now time.Time) (int64, error) {
+	return s.r.DeleteExpiredKeys(ctx, now)
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/jobs"
+	"github.com/NutriPocket/ProgressService/metrics"
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/repository"
+)
+
+// Defaults of the configuration of the jobs
+const (
+	defaultJobMaxAttempts = 5
+	defaultJobBackoff     = 30 * time.Second
+	defaultJobLease       = 5 * time.Minute
+)
+
+// maxJobError is the length the errors of the runs are truncated to, the size of their column
+const maxJobError = 512
+
+type IJobService interface {
+	Schedule(ctx context.Context, name string, schedule string, payload any) error
+	Enqueue(ctx context.Context, name string, payload any, runAt time.Time, key *string) (bool, error)
+	RunNext(ctx context.Context, worker string) (bool, error)
+	GetJob(ctx context.Context, id uint64) (model.Job, error)
+	GetJobs(ctx context.Context, page model.PageParams) (model.Page[model.Job], error)
+	GetJobRuns(ctx context.Context, jobId uint64, page model.PageParams) (model.Page[model.JobRun], error)
+}
+
+type JobService struct {
+	r repository.IJobRepository
+	// maxAttempts, backoff and lease are read from JOB_MAX_ATTEMPTS, JOB_RETRY_BACKOFF and JOB_LEASE
+	maxAttempts uint
+	backoff     time.Duration
+	lease       time.Duration
+}
+
+func NewJobService(r repository.IJobRepository) (*JobService, error) {
+	var err error
+
+	if r == nil {
+		r, err = repository.NewJobRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	maxAttempts := uint(defaultJobMaxAttempts)
+	if value := os.Getenv("JOB_MAX_ATTEMPTS"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || parsed == 0 {
+			slog.Warn("Invalid JOB_MAX_ATTEMPTS, using the default", "value", value, "default", maxAttempts)
+		} else {
+			maxAttempts = uint(parsed)
+		}
+	}
+
+	return &JobService{
+		r:           r,
+		maxAttempts: maxAttempts,
+		backoff:     durationEnv("JOB_RETRY_BACKOFF", defaultJobBackoff),
+		lease:       durationEnv("JOB_LEASE", defaultJobLease),
+	}, nil
+}
+
+// durationEnv parses the environment variable key as a positive time.Duration (e.g. "15s").
+// It returns def if the variable isn't set or isn't valid.
+func durationEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		slog.Warn("Invalid duration, using the default", "key", key, "value", value, "default", def.String())
+		return def
+	}
+
+	return parsed
+}
+
+// Schedule stores the recurring job name, run by the handler registered with that name on
+// schedule (see jobs.ParseSchedule). Every replica can schedule the same job on startup, it's
+// stored once and keeps its next run unless the schedule changes.
+func (s *JobService) Schedule(ctx context.Context, name string, schedule string, payload any) error {
+	parsed, err := jobs.ParseSchedule(schedule)
+	if err != nil {
+		return err
+	}
+
+	job, err := s.newJob(name, payload)
+	if err != nil {
+		return err
+	}
+
+	job.Key = &name
+	job.Schedule = &schedule
+
+	return s.r.ScheduleJob(ctx, job, parsed.Next(time.Now()))
+}
+
+// Enqueue stores a job that runs once at runAt. key, if not nil, keeps the same job from being
+// enqueued twice. It returns whether the job was enqueued, false if its key was already stored.
+func (s *JobService) Enqueue(ctx context.Context, name string, payload any, runAt time.Time, key *string) (bool, error) {
+	job, err := s.newJob(name, payload)
+	if err != nil {
+		return false, err
+	}
+
+	job.Key = key
+
+	return s.r.EnqueueJob(ctx, job, runAt)
+}
+
+func (s *JobService) newJob(name string, payload any) (*model.Job, error) {
+	job := &model.Job{
+		Name:        name,
+		MaxAttempts: s.maxAttempts,
+	}
+
+	if payload != nil {
+		content, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded := string(content)
+		job.Payload = &encoded
+	}
+
+	return job, nil
+}
+
+// RunNext runs the next due job as worker, if any, and returns whether it ran one. The job is
+// locked for the lease duration, and its handler is cancelled once the lease ends so another
+// worker can take it over. A failed run is retried with an exponential backoff until the job
+// reaches its max attempts.
+func (s *JobService) RunNext(ctx context.Context, worker string) (bool, error) {
+	job, run, err := s.r.ClaimJob(ctx, worker, time.Now(), s.lease)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	start := time.Now()
+	runErr := s.execute(ctx, job)
+	finishedAt := time.Now()
+
+	runAt, err := s.nextState(job, run, runErr, finishedAt)
+	if err != nil {
+		return true, err
+	}
+
+	metrics.JobRuns.WithLabelValues(job.Name, string(run.Status)).Inc()
+	metrics.JobRunDuration.WithLabelValues(job.Name).Observe(finishedAt.Sub(start).Seconds())
+
+	if runErr != nil {
+		slog.ErrorContext(ctx, "Job failed", "job_id", job.ID, "name", job.Name, "attempt", run.Attempt, "error", runErr)
+	} else {
+		slog.InfoContext(ctx, "Job succeeded", "job_id", job.ID, "name", job.Name, "attempt", run.Attempt)
+	}
+
+	finished, err := s.r.FinishJob(ctx, job, run, runAt, finishedAt)
+	if err == nil && !finished {
+		slog.WarnContext(ctx, "Job was taken over by another worker before it finished", "job_id", job.ID, "name", job.Name)
+	}
+
+	return true, err
+}
+
+// execute runs the handler of the job until the lease ends
+func (s *JobService) execute(ctx context.Context, job *model.Job) (err error) {
+	handler, ok := jobs.Lookup(job.Name)
+	if !ok {
+		return fmt.Errorf("no handler is registered for the job %s", job.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.lease)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("the job panicked: %v", r)
+		}
+	}()
+
+	var payload json.RawMessage
+	if job.Payload != nil {
+		payload = json.RawMessage(*job.Payload)
+	}
+
+	return handler(ctx, payload)
+}
+
+// nextState sets the outcome of the run and the state of the job after it, and returns the
+// next run of the job. One-off jobs keep their run_at once they succeed or run out of attempts,
+// recurring ones are scheduled again.
+func (s *JobService) nextState(job *model.Job, run *model.JobRun, runErr error, now time.Time) (time.Time, error) {
+	runAt, err := time.Parse(time.RFC3339Nano, job.RunAt)
+	if err != nil {
+		runAt = now
+	}
+
+	if runErr == nil {
+		run.Status, job.Status = model.JobSucceeded, model.JobSucceeded
+		job.LastError = nil
+	} else {
+		message := runErr.Error()
+		if len(message) > maxJobError {
+			message = message[:maxJobError]
+		}
+
+		run.Status, job.Status = model.JobFailed, model.JobFailed
+		run.Error, job.LastError = &message, &message
+
+		if job.Attempts < job.MaxAttempts {
+			job.Status = model.JobPending
+			return now.Add(jobs.Backoff(job.Attempts, s.backoff)), nil
+		}
+	}
+
+	if job.Schedule == nil {
+		return runAt, nil
+	}
+
+	schedule, err := jobs.ParseSchedule(*job.Schedule)
+	if err != nil {
+		return runAt, err
+	}
+
+	job.Status = model.JobPending
+	job.Attempts = 0
+
+	return schedule.Next(now), nil
+}
+
+func (s *JobService) GetJob(ctx context.Context, id uint64) (model.Job, error) {
+	return s.r.GetJob(ctx, id)
+}
+
+func (s *JobService) GetJobs(ctx context.Context, page model.PageParams) (model.Page[model.Job], error) {
+	return s.r.GetJobs(ctx, page)
+}
+
+// GetJobRuns returns a page of the runs of the job, or a not found error if it doesn't exist
+func (s *JobService) GetJobRuns(ctx context.Context, jobId uint64, page model.PageParams) (model.Page[model.JobRun], error) {
+	if _, err := s.r.GetJob(ctx, jobId); err != nil {
+		return model.Page[model.JobRun]{}, err
+	}
+
+	return s.r.GetJobRuns(ctx, jobId, page)
+}
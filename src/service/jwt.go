@@ -68,7 +68,7 @@ func (service *JWTService) isJWT(tokenString string) bool {
 // It returns true if the token is valid, false otherwise.
 func (service *JWTService) Verify(tokenString string) (bool, error) {
 	if !service.isJWT(tokenString) {
-		return false, &model.ValidationError{Title: "Invalid JWT", Detail: "The provided token doesn't have JWT format"}
+		return false, &model.ValidationError{Code: "auth.invalid_token"}
 	}
 
 	token, err := jwt.ParseWithClaims(tokenString, &model.JWTPayload{}, func(token *jwt.Token) (interface{}, error) {
@@ -88,8 +88,7 @@ func (service *JWTService) Verify(tokenString string) (bool, error) {
 func (service *JWTService) Decode(tokenString string) (model.JWTPayload, error) {
 	if !service.isJWT(tokenString) {
 		return model.JWTPayload{}, &model.ValidationError{
-			Title:  "Invalid JWT",
-			Detail: "The provided token doesn't have JWT format",
+			Code: "auth.invalid_token",
 		}
 	}
 
@@ -106,8 +105,7 @@ func (service *JWTService) Decode(tokenString string) (model.JWTPayload, error)
 	} else {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return model.JWTPayload{}, &model.AuthenticationError{
-				Title:  "Expired token",
-				Detail: "Your token has expired, please try logging in again",
+				Code: "auth.expired_token",
 			}
 		}
 
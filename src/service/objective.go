@@ -1,20 +1,39 @@
 package service
 
 import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/metrics"
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/NutriPocket/ProgressService/repository"
 )
 
+// maxMilestones is the number of milestones of objectives longer than that many weeks
+const maxMilestones = 10
+
+// closingBatch is the number of active objectives read at once when closing them
+const closingBatch = 100
+
 type IObjectiveService interface {
-	PutObjective(data *model.ObjectiveData) (model.ObjectiveData, error, bool)
-	GetObjectiveByUser(userId string) (model.ObjectiveData, error)
+	PutObjective(ctx context.Context, data *model.ObjectiveData, version *uint) (model.ObjectiveData, error, bool)
+	GetObjectiveByUser(ctx context.Context, userId string) (model.ObjectiveData, error)
+	GetObjectiveHistoryByUser(ctx context.Context, userId string, page model.PageParams) (model.Page[model.ObjectiveData], error)
+	GetMilestonesByUser(ctx context.Context, userId string) ([]model.Milestone, error)
+	CloseObjectives(ctx context.Context, now time.Time) (int, error)
 }
 
 type ObjectiveService struct {
-	r repository.IObjectiveRepository
+	r   repository.IObjectiveRepository
+	ar  repository.IAnthropometricRepository
+	er  repository.IExerciseRepository
+	fdr repository.IFixedDataRepository
 }
 
-func NewObjectiveService(r repository.IObjectiveRepository) (*ObjectiveService, error) {
+func NewObjectiveService(r repository.IObjectiveRepository, ar repository.IAnthropometricRepository, er repository.IExerciseRepository, fdr repository.IFixedDataRepository) (*ObjectiveService, error) {
 	var err error
 
 	if r == nil {
@@ -24,49 +43,450 @@ func NewObjectiveService(r repository.IObjectiveRepository) (*ObjectiveService,
 		}
 	}
 
+	if ar == nil {
+		ar, err = repository.NewAnthropometricRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if er == nil {
+		er, err = repository.NewExerciseRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fdr == nil {
+		fdr, err = repository.NewFixedDataRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &ObjectiveService{
-		r: r,
+		r:   r,
+		ar:  ar,
+		er:  er,
+		fdr: fdr,
 	}, nil
 }
 
-func (s *ObjectiveService) PutObjective(data *model.ObjectiveData) (ret model.ObjectiveData, err error, created bool) {
+// validateObjective checks the rules of each type of objective that don't depend on a single field
+func validateObjective(data *model.ObjectiveData) error {
+	if data.Type != model.ObjectiveExerciseFrequency && data.Type != model.ObjectiveWeightLogging {
+		return nil
+	}
+
+	target := float64(*data.Target)
+	if target != math.Trunc(target) {
+		return &model.ValidationError{
+			Code:   "objective.fractional_target",
+			Params: map[string]string{"type": string(data.Type)},
+		}
+	}
+
+	if data.Type == model.ObjectiveWeightLogging {
+		if maxDays := daysIn(*data.Period); target > float64(maxDays) {
+			return &model.ValidationError{
+				Code:   "objective.unreachable_target",
+				Params: map[string]string{"max": strconv.Itoa(maxDays), "period": string(*data.Period)},
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *ObjectiveService) PutObjective(ctx context.Context, data *model.ObjectiveData, version *uint) (ret model.ObjectiveData, err error, created bool) {
+	if data.Type == "" {
+		data.Type = model.ObjectiveBodyComposition
+	}
+
+	if data.Type == model.ObjectiveBodyComposition {
+		data.Target, data.Period = nil, nil
+	}
+
+	if err = validateObjective(data); err != nil {
+		return
+	}
+
 	var storedData *model.ObjectiveData = &model.ObjectiveData{}
-	err = s.r.GetObjectiveByUserId(data.UserID, storedData)
+	err = s.r.GetObjectiveByUserId(ctx, data.UserID, storedData)
 	if err != nil {
 		if _, ok := err.(*model.NotFoundError); ok {
-			ret, err = s.r.CreateObjective(data)
+			var feasibility *model.ObjectiveFeasibility
+			if feasibility, err = s.checkFeasibility(ctx, data, time.Now()); err != nil {
+				return
+			}
+
+			var milestones []model.Milestone
+			if milestones, err = s.milestonesOf(ctx, data, time.Now()); err != nil {
+				return
+			}
+
+			ret, err = s.r.CreateObjective(ctx, data, milestones)
 			created = true
+			if err != nil {
+				return
+			}
+
+			ret.Feasibility = feasibility
+
+			err = s.setProgress(ctx, &ret)
 			return
 		}
 
-		log.Errorf("Failed to check current anthropometric data for user %s: %v", data.UserID, err)
+		slog.ErrorContext(ctx, "Failed to check current objective", "user_id", data.UserID, "error", err)
 		return
 	}
 
-	storedData.Weight = data.Weight
-	if data.MuscleMass != nil {
-		storedData.MuscleMass = data.MuscleMass
+	// Only body composition objectives are merged with the stored one, the rest replace it as a whole
+	if storedData.Type != data.Type || data.Type != model.ObjectiveBodyComposition {
+		storedData = data
+	} else {
+		storedData.Weight = data.Weight
+		if data.MuscleMass != nil {
+			storedData.MuscleMass = data.MuscleMass
+		}
+
+		if data.FatMass != nil {
+			storedData.FatMass = data.FatMass
+		}
+
+		if data.BoneMass != nil {
+			storedData.BoneMass = data.BoneMass
+		}
+
+		if data.Deadline != "" {
+			storedData.Deadline = data.Deadline
+		}
 	}
 
-	if data.FatMass != nil {
-		storedData.FatMass = data.FatMass
+	feasibility, err := s.checkFeasibility(ctx, storedData, time.Now())
+	if err != nil {
+		return
 	}
 
-	if data.BoneMass != nil {
-		storedData.BoneMass = data.BoneMass
+	milestones, err := s.milestonesOf(ctx, storedData, time.Now())
+	if err != nil {
+		return
 	}
 
-	if data.Deadline != "" {
-		storedData.Deadline = data.Deadline
+	ret, err = s.r.ReplaceObjective(ctx, storedData, milestones, version)
+	if err != nil {
+		return
 	}
 
-	ret, err = s.r.ReplaceObjective(storedData)
+	ret.Feasibility = feasibility
+
+	err = s.setProgress(ctx, &ret)
 	return
 }
 
-func (s *ObjectiveService) GetObjectiveByUser(userId string) (model.ObjectiveData, error) {
+func (s *ObjectiveService) GetObjectiveByUser(ctx context.Context, userId string) (model.ObjectiveData, error) {
 	var ret model.ObjectiveData
-	err := s.r.GetObjectiveByUserId(userId, &ret)
+	err := s.r.GetObjectiveByUserId(ctx, userId, &ret)
+	if err != nil {
+		return ret, err
+	}
 
+	err = s.setProgress(ctx, &ret)
 	return ret, err
 }
+
+func (s *ObjectiveService) GetObjectiveHistoryByUser(ctx context.Context, userId string, page model.PageParams) (model.Page[model.ObjectiveData], error) {
+	return s.r.GetObjectiveHistoryByUserId(ctx, userId, page)
+}
+
+// GetMilestonesByUser returns the milestones of the objective of the user, empty if the
+// objective has none
+func (s *ObjectiveService) GetMilestonesByUser(ctx context.Context, userId string) ([]model.Milestone, error) {
+	return s.r.GetMilestonesByUserId(ctx, userId)
+}
+
+// CloseObjectives evaluates every active objective at now and closes the ones achieved or past
+// their deadline, recording an event for each of them. It returns how many were closed.
+func (s *ObjectiveService) CloseObjectives(ctx context.Context, now time.Time) (int, error) {
+	closed := 0
+	after := ""
+
+	for {
+		objectives, err := s.r.GetActiveObjectives(ctx, after, closingBatch)
+		if err != nil {
+			return closed, err
+		}
+
+		for i := range objectives {
+			ok, err := s.closeObjective(ctx, &objectives[i], now)
+			if err != nil {
+				// An objective that can't be evaluated doesn't keep the rest from being closed
+				slog.ErrorContext(ctx, "Failed to close objective", "user_id", objectives[i].UserID, "error", err)
+				continue
+			}
+
+			if ok {
+				closed++
+			}
+		}
+
+		if len(objectives) < closingBatch {
+			return closed, nil
+		}
+
+		after = objectives[len(objectives)-1].UserID
+	}
+}
+
+// closeObjective closes the objective if it was achieved or its deadline passed by now, and
+// returns whether it did. Body composition objectives are achieved as soon as the last weight
+// reaches the target. Activity objectives have a target per period, so they are closed when
+// the deadline passes, achieved if the period that contains the deadline reached the target.
+func (s *ObjectiveService) closeObjective(ctx context.Context, objective *model.ObjectiveData, now time.Time) (bool, error) {
+	var fixedData model.BaseFixedUserData
+	err := s.fdr.GetBaseFixedUserData(ctx, objective.UserID, &fixedData)
+	if _, ok := err.(*model.NotFoundError); err != nil && !ok {
+		return false, err
+	}
+
+	deadline, err := parseDate(objective.Deadline)
+	if err != nil {
+		return false, err
+	}
+
+	// The deadline lasts until the end of that day in the time zone of the user
+	deadlineEnd := time.Date(deadline.Year(), deadline.Month(), deadline.Day()+1, 0, 0, 0, 0, location(fixedData.TimeZone))
+	expired := !now.Before(deadlineEnd)
+
+	if !expired && objective.Type != model.ObjectiveBodyComposition {
+		return false, nil
+	}
+
+	at := now
+	if expired {
+		at = deadlineEnd.Add(-time.Nanosecond)
+	}
+
+	progress, err := s.evaluate(ctx, objective, at)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case progress != nil && progress.Achieved:
+		objective.Status = model.ObjectiveAchieved
+	case expired:
+		objective.Status = model.ObjectiveExpired
+	default:
+		return false, nil
+	}
+
+	if progress != nil {
+		objective.FinalValue = &progress.Current
+	}
+
+	// The objective is the payload of its event, stored along with the closing
+	closedAt := now.UTC().Format(time.RFC3339Nano)
+	objective.ClosedAt = &closedAt
+	objective.Progress = progress
+
+	ok, err := s.r.CloseObjective(ctx, objective, now)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if objective.Status == model.ObjectiveAchieved {
+		metrics.ObjectiveAchievements.Inc()
+	}
+
+	slog.InfoContext(ctx, "Closed objective", "user_id", objective.UserID, "status", objective.Status)
+
+	return true, nil
+}
+
+// milestonesOf splits the way from the last weight of the user to the target of a body
+// composition objective into one milestone per week until the deadline, at most
+// maxMilestones of them. Other objectives, and users without measurements, have none.
+func (s *ObjectiveService) milestonesOf(ctx context.Context, objective *model.ObjectiveData, now time.Time) ([]model.Milestone, error) {
+	if objective.Type != model.ObjectiveBodyComposition {
+		return nil, nil
+	}
+
+	start, err := s.ar.GetWeightAt(ctx, objective.UserID, now)
+	if err != nil || start == nil || *start == objective.Weight {
+		return nil, err
+	}
+
+	var fixedData model.BaseFixedUserData
+	err = s.fdr.GetBaseFixedUserData(ctx, objective.UserID, &fixedData)
+	if _, ok := err.(*model.NotFoundError); err != nil && !ok {
+		return nil, err
+	}
+
+	deadline, err := parseDate(objective.Deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	today := localDate(now, location(fixedData.TimeZone))
+	days := daysBetween(today, deadline)
+	count := min(maxMilestones, max(1, days/7))
+
+	milestones := make([]model.Milestone, count)
+	for i := range milestones {
+		fraction := float64(i+1) / float64(count)
+		milestones[i] = model.Milestone{
+			Sequence:    uint(i + 1),
+			Weight:      *round(float64(*start)+float64(objective.Weight-*start)*fraction, 2),
+			StartWeight: *start,
+			DueDate:     today.AddDate(0, 0, int(math.Round(float64(days)*fraction))).Format(time.DateOnly),
+		}
+	}
+
+	return milestones, nil
+}
+
+// setProgress evaluates the objective at the current time
+func (s *ObjectiveService) setProgress(ctx context.Context, objective *model.ObjectiveData) error {
+	progress, err := s.evaluate(ctx, objective, time.Now())
+	if err != nil {
+		return err
+	}
+
+	objective.Progress = progress
+	return nil
+}
+
+// evaluate returns the progress of the objective at the instant now, or nil if it can't be evaluated
+func (s *ObjectiveService) evaluate(ctx context.Context, objective *model.ObjectiveData, now time.Time) (*model.ObjectiveProgress, error) {
+	if objective.Type == model.ObjectiveBodyComposition {
+		return s.evaluateBodyComposition(ctx, objective, now)
+	}
+
+	var fixedData model.BaseFixedUserData
+	err := s.fdr.GetBaseFixedUserData(ctx, objective.UserID, &fixedData)
+	if _, ok := err.(*model.NotFoundError); err != nil && !ok {
+		return nil, err
+	}
+
+	from, to := periodBounds(*objective.Period, now, location(fixedData.TimeZone))
+
+	var current float32
+	switch objective.Type {
+	case model.ObjectiveCalories, model.ObjectiveExerciseFrequency:
+		totals, err := s.er.GetTotalsBetween(ctx, objective.UserID, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		current = float32(totals.Calories)
+		if objective.Type == model.ObjectiveExerciseFrequency {
+			current = float32(totals.Count)
+		}
+	case model.ObjectiveWeightLogging:
+		times, err := s.ar.GetMeasurementTimesBetween(ctx, objective.UserID, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		current = float32(distinctDays(times, from.Location()))
+	}
+
+	periodStart, periodEnd := from.Format(time.RFC3339), to.Format(time.RFC3339)
+	progress := &model.ObjectiveProgress{
+		Current:     current,
+		Target:      *objective.Target,
+		Percentage:  percentage(float64(current) / float64(*objective.Target)),
+		PeriodStart: &periodStart,
+		PeriodEnd:   &periodEnd,
+	}
+	progress.Achieved = progress.Current >= progress.Target
+
+	return progress, nil
+}
+
+// evaluateBodyComposition compares the last weight of the user with the weight the user had
+// when the objective was set
+func (s *ObjectiveService) evaluateBodyComposition(ctx context.Context, objective *model.ObjectiveData, now time.Time) (*model.ObjectiveProgress, error) {
+	current, err := s.ar.GetWeightAt(ctx, objective.UserID, now)
+	if err != nil || current == nil {
+		return nil, err
+	}
+
+	setAt, err := time.Parse(time.RFC3339Nano, objective.CreatedAt)
+	if err != nil {
+		setAt = now
+	}
+
+	start, err := s.ar.GetWeightAt(ctx, objective.UserID, setAt)
+	if err != nil {
+		return nil, err
+	}
+
+	target := objective.Weight
+	progress := &model.ObjectiveProgress{
+		Start:   start,
+		Current: *current,
+		Target:  target,
+	}
+
+	if *start == target {
+		progress.Achieved = *current == target
+	} else {
+		ratio := float64(*start-*current) / float64(*start-target)
+		progress.Achieved = ratio >= 1
+		progress.Percentage = percentage(ratio)
+	}
+
+	if progress.Achieved {
+		progress.Percentage = 100
+	}
+
+	return progress, nil
+}
+
+// percentage converts a ratio of a target into a percentage between 0 and 100
+func percentage(ratio float64) float32 {
+	return *round(math.Max(0, math.Min(1, ratio))*100, 2)
+}
+
+// periodBounds returns the start of the calendar period that contains now in loc, and the
+// start of the next one
+func periodBounds(period model.ObjectivePeriod, now time.Time, loc *time.Location) (time.Time, time.Time) {
+	now = now.In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	switch period {
+	case model.PeriodWeek:
+		// Weeks start on Monday
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7)
+	case model.PeriodMonth:
+		start = start.AddDate(0, 0, 1-start.Day())
+		return start, start.AddDate(0, 1, 0)
+	default:
+		return start, start.AddDate(0, 0, 1)
+	}
+}
+
+// daysIn returns the maximum number of days of a period
+func daysIn(period model.ObjectivePeriod) int {
+	switch period {
+	case model.PeriodWeek:
+		return 7
+	case model.PeriodMonth:
+		return 31
+	default:
+		return 1
+	}
+}
+
+// distinctDays returns the number of calendar days of loc among times
+func distinctDays(times []time.Time, loc *time.Location) int {
+	days := make(map[string]bool)
+	for _, t := range times {
+		days[t.In(loc).Format(time.DateOnly)] = true
+	}
+
+	return len(days)
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/metrics"
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/outbox"
+	"github.com/NutriPocket/ProgressService/repository"
+)
+
+// defaultOutboxRetention is how long the published events are kept if OUTBOX_RETENTION isn't set
+const defaultOutboxRetention = 7 * 24 * time.Hour
+
+// outboxBatch is how many events are relayed in each batch
+const outboxBatch = 100
+
+type IOutboxService interface {
+	Relay(ctx context.Context) (int, error)
+	Prune(ctx context.Context, now time.Time) (int64, error)
+}
+
+type OutboxService struct {
+	r          repository.IOutboxRepository
+	publishers []outbox.Publisher
+	// retention is read from OUTBOX_RETENTION
+	retention time.Duration
+}
+
+// NewOutboxService returns a service relaying the outbox to publishers, or to the ones of
+// OUTBOX_PUBLISHERS if it's nil. Besides the ones of the outbox package, "webhooks" enqueues the
+// deliveries of the events to the webhooks subscribed to them.
+func NewOutboxService(r repository.IOutboxRepository, publishers []outbox.Publisher) (*OutboxService, error) {
+	var err error
+
+	if r == nil {
+		r, err = repository.NewOutboxRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if publishers == nil {
+		webhookService, err := NewWebhookService(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		publishers, err = outbox.FromEnv(map[string]outbox.Publisher{
+			"webhooks": outbox.PublisherFunc(webhookService.EnqueueEvent),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &OutboxService{
+		r:          r,
+		publishers: publishers,
+		retention:  durationEnv("OUTBOX_RETENTION", defaultOutboxRetention),
+	}, nil
+}
+
+// Relay publishes the events of the outbox in the order they were stored until there are none
+// left, or one of them fails to be published, and returns how many were published. Each event is
+// published to every publisher, a failure makes all of them receive it again on the next relay.
+func (s *OutboxService) Relay(ctx context.Context) (int, error) {
+	published := 0
+
+	for {
+		relayed, err := s.r.RelayPending(ctx, outboxBatch, func(stored model.OutboxEvent) error {
+			event, err := outbox.EventOf(stored)
+			if err != nil {
+				return err
+			}
+
+			for _, publisher := range s.publishers {
+				if err := publisher.Publish(ctx, event); err != nil {
+					slog.WarnContext(ctx, "Failed to publish outbox event", "event_id", event.ID, "type", event.Type, "error", err)
+					return err
+				}
+			}
+
+			metrics.OutboxEventsPublished.WithLabelValues(event.Type).Inc()
+			return nil
+		})
+
+		published += relayed
+		if err != nil || relayed < outboxBatch {
+			return published, err
+		}
+	}
+}
+
+// Prune deletes the events published before the retention, counted back from now
+func (s *OutboxService) Prune(ctx context.Context, now time.Time) (int64, error) {
+	return s.r.DeletePublishedBefore(ctx, now.Add(-s.retention))
+}
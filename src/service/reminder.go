@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/metrics"
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/notifier"
+	"github.com/NutriPocket/ProgressService/repository"
+)
+
+// DeliverReminderJob is the name of the jobs that deliver a reminder, their payload is the
+// model.Notification to send
+const DeliverReminderJob = "reminders.deliver"
+
+// reminderBatch is how many reminder rules are evaluated at once
+const reminderBatch = 100
+
+// maxRoutineReminderMinutes bounds how long before a routine it can be reminded
+const maxRoutineReminderMinutes = 24 * 60
+
+type IReminderService interface {
+	CreateReminder(ctx context.Context, userId string, data *model.ReminderDTO) (model.Reminder, error)
+	GetReminder(ctx context.Context, id uint64, userId string) (model.Reminder, error)
+	GetReminders(ctx context.Context, userId string, page model.PageParams) (model.Page[model.Reminder], error)
+	UpdateReminder(ctx context.Context, id uint64, userId string, data *model.ReminderDTO) (model.Reminder, error)
+	DeleteReminder(ctx context.Context, id uint64, userId string) error
+	EvaluateReminders(ctx context.Context, now time.Time) (int, error)
+	Deliver(ctx context.Context, notification model.Notification) error
+}
+
+type ReminderService struct {
+	r   repository.IReminderRepository
+	ar  repository.IAnthropometricRepository
+	rr  repository.IRoutineRepository
+	fdr repository.IFixedDataRepository
+	js  IJobService
+	n   notifier.Notifier
+}
+
+func NewReminderService(
+	r repository.IReminderRepository,
+	ar repository.IAnthropometricRepository,
+	rr repository.IRoutineRepository,
+	fdr repository.IFixedDataRepository,
+	js IJobService,
+	n notifier.Notifier,
+) (*ReminderService, error) {
+	var err error
+
+	if r == nil {
+		r, err = repository.NewReminderRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ar == nil {
+		ar, err = repository.NewAnthropometricRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rr == nil {
+		rr, err = repository.NewRoutineRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fdr == nil {
+		fdr, err = repository.NewFixedDataRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if js == nil {
+		js, err = NewJobService(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if n == nil {
+		n, err = notifier.FromEnv()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ReminderService{
+		r:   r,
+		ar:  ar,
+		rr:  rr,
+		fdr: fdr,
+		js:  js,
+		n:   n,
+	}, nil
+}
+
+// validateReminder checks the rules of a reminder that don't depend on a single field
+func validateReminder(data *model.ReminderDTO) error {
+	if (data.QuietStart == nil) != (data.QuietEnd == nil) {
+		return &model.ValidationError{
+			Code: "reminder.incomplete_quiet_hours",
+		}
+	}
+
+	if data.Type == model.ReminderUpcomingRoutine && data.Threshold > maxRoutineReminderMinutes {
+		return &model.ValidationError{
+			Code:   "reminder.threshold_too_large",
+			Params: map[string]string{"max": strconv.Itoa(maxRoutineReminderMinutes)},
+		}
+	}
+
+	return nil
+}
+
+func (s *ReminderService) CreateReminder(ctx context.Context, userId string, data *model.ReminderDTO) (model.Reminder, error) {
+	if err := validateReminder(data); err != nil {
+		return model.Reminder{}, err
+	}
+
+	return s.r.CreateReminder(ctx, userId, data)
+}
+
+func (s *ReminderService) GetReminder(ctx context.Context, id uint64, userId string) (model.Reminder, error) {
+	return s.r.GetReminder(ctx, id, userId)
+}
+
+func (s *ReminderService) GetReminders(ctx context.Context, userId string, page model.PageParams) (model.Page[model.Reminder], error) {
+	return s.r.GetRemindersPage(ctx, userId, page)
+}
+
+// UpdateReminder replaces the reminder rule id of the user, or returns a not found error if
+// the user has none with that ID
+func (s *ReminderService) UpdateReminder(ctx context.Context, id uint64, userId string, data *model.ReminderDTO) (model.Reminder, error) {
+	if err := validateReminder(data); err != nil {
+		return model.Reminder{}, err
+	}
+
+	if _, err := s.r.GetReminder(ctx, id, userId); err != nil {
+		return model.Reminder{}, err
+	}
+
+	return s.r.UpdateReminder(ctx, id, userId, data)
+}
+
+func (s *ReminderService) DeleteReminder(ctx context.Context, id uint64, userId string) error {
+	return s.r.DeleteReminder(ctx, id, userId)
+}
+
+// EvaluateReminders checks every reminder rule at now and enqueues the delivery of the
+// reminders that are due, outside the quiet hours of their rules. It returns how many were
+// enqueued.
+//
+// Evaluations overlap, so every reminder has a key that keeps it from being enqueued twice:
+// missed weigh-ins are reminded once per Threshold days without a measurement, and upcoming
+// routines once per occurrence. A routine that starts right after the quiet hours end may not
+// be reminded.
+func (s *ReminderService) EvaluateReminders(ctx context.Context, now time.Time) (int, error) {
+	enqueued := 0
+	var after uint64
+
+	for {
+		reminders, err := s.r.GetRemindersAfter(ctx, after, reminderBatch)
+		if err != nil {
+			return enqueued, err
+		}
+
+		for i := range reminders {
+			notifications, err := s.dueNotifications(ctx, &reminders[i], now)
+			if err != nil {
+				// A rule that can't be evaluated doesn't keep the rest from being reminded
+				slog.ErrorContext(ctx, "Failed to evaluate reminder", "user_id", reminders[i].UserID, "reminder_id", reminders[i].ID, "error", err)
+				continue
+			}
+
+			for _, notification := range notifications {
+				added, err := s.js.Enqueue(ctx, DeliverReminderJob, notification, now, &notification.Key)
+				if err != nil {
+					return enqueued, err
+				}
+
+				// Reminders already enqueued by a previous evaluation aren't counted
+				if added {
+					enqueued++
+				}
+			}
+		}
+
+		if len(reminders) < reminderBatch {
+			return enqueued, nil
+		}
+
+		after = reminders[len(reminders)-1].ID
+	}
+}
+
+// dueNotifications returns the reminders of the rule due at now
+func (s *ReminderService) dueNotifications(ctx context.Context, reminder *model.Reminder, now time.Time) ([]model.Notification, error) {
+	var fixedData model.BaseFixedUserData
+	err := s.fdr.GetBaseFixedUserData(ctx, reminder.UserID, &fixedData)
+	if _, ok := err.(*model.NotFoundError); err != nil && !ok {
+		return nil, err
+	}
+
+	loc := location(fixedData.TimeZone)
+	if inQuietHours(reminder, now.In(loc)) {
+		return nil, nil
+	}
+
+	switch reminder.Type {
+	case model.ReminderMissedWeighIn:
+		return s.missedWeighIn(ctx, reminder, now)
+	case model.ReminderUpcomingRoutine:
+		return s.upcomingRoutines(ctx, reminder, now, loc)
+	default:
+		return nil, fmt.Errorf("unknown reminder type %s", reminder.Type)
+	}
+}
+
+// missedWeighIn reminds the user every Threshold days since the last measurement of the
+// weight, or since the rule was created if the user never measured it
+func (s *ReminderService) missedWeighIn(ctx context.Context, reminder *model.Reminder, now time.Time) ([]model.Notification, error) {
+	since, err := s.ar.GetLastMeasurementTime(ctx, reminder.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if since == nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, reminder.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		since = &createdAt
+	}
+
+	days := int(now.Sub(*since) / (24 * time.Hour))
+	periods := days / int(reminder.Threshold)
+	if periods == 0 {
+		return nil, nil
+	}
+
+	return []model.Notification{{
+		UserID: reminder.UserID,
+		Code:   "reminder.missed_weigh_in",
+		Params: map[string]string{"days": strconv.Itoa(days)},
+		Key:    fmt.Sprintf("reminder-%d-weigh-in-%d-%d", reminder.ID, since.Unix(), periods),
+	}}, nil
+}
+
+// upcomingRoutines reminds the user of the routines that start within the next Threshold
+// minutes. Routines repeat every week, on their day at their start hour in loc.
+func (s *ReminderService) upcomingRoutines(ctx context.Context, reminder *model.Reminder, now time.Time, loc *time.Location) ([]model.Notification, error) {
+	var routines []model.RoutineData
+	if err := s.rr.GetRoutinesByUserId(ctx, reminder.UserID, &routines); err != nil {
+		return nil, err
+	}
+
+	window := time.Duration(reminder.Threshold) * time.Minute
+	local := now.In(loc)
+
+	var notifications []model.Notification
+	for _, routine := range routines {
+		start, ok := nextStart(routine.Schedule, local)
+		if !ok || start.Sub(now) > window {
+			continue
+		}
+
+		notifications = append(notifications, model.Notification{
+			UserID: reminder.UserID,
+			Code:   "reminder.upcoming_routine",
+			Params: map[string]string{
+				"name":    routine.Name,
+				"minutes": strconv.Itoa(int(start.Sub(now).Minutes())),
+			},
+			Key: fmt.Sprintf("reminder-%d-routine-%d", reminder.ID, start.Unix()),
+		})
+	}
+
+	return notifications, nil
+}
+
+// nextStart returns the first start of the routine after now, which must be in the time zone
+// of the user. It returns false if the day of the routine isn't a weekday.
+func nextStart(schedule model.Schedule, now time.Time) (time.Time, bool) {
+	for days := range 8 {
+		start := time.Date(now.Year(), now.Month(), now.Day()+days, schedule.StartHour, 0, 0, 0, now.Location())
+		if strings.EqualFold(start.Weekday().String(), schedule.Day) && start.After(now) {
+			return start, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// inQuietHours returns whether local, in the time zone of the user, is within the quiet
+// hours of the reminder. The quiet hours end at QuietEnd, and wrap around midnight if it's
+// earlier than QuietStart.
+func inQuietHours(reminder *model.Reminder, local time.Time) bool {
+	if reminder.QuietStart == nil || reminder.QuietEnd == nil {
+		return false
+	}
+
+	start, errStart := time.Parse("15:04", *reminder.QuietStart)
+	end, errEnd := time.Parse("15:04", *reminder.QuietEnd)
+	if errStart != nil || errEnd != nil {
+		return false
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+
+	if from <= to {
+		return minute >= from && minute < to
+	}
+
+	return minute >= from || minute < to
+}
+
+// Deliver sends the notification of a reminder with the notifier of the service
+func (s *ReminderService) Deliver(ctx context.Context, notification model.Notification) error {
+	if err := s.n.Notify(ctx, notification); err != nil {
+		return err
+	}
+
+	metrics.RemindersSent.WithLabelValues(notification.Code).Inc()
+	return nil
+}
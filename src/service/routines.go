@@ -1,15 +1,18 @@
 package service
 
 import (
+	"context"
+
+	"github.com/NutriPocket/ProgressService/metrics"
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/NutriPocket/ProgressService/repository"
 )
 
 type IRoutineService interface {
-	CreateRoutine(data *model.RoutineDTO) (model.RoutineData, error)
-	GetRoutinesByUser(userId string, data *[]model.RoutineData) error
-	GetFreeSchedules(users []string) (model.FreeSchedule, error)
-	DeleteRutineBySchedule(userId string, schedule *model.Schedule) ([]model.RoutineData, error)
+	CreateRoutine(ctx context.Context, data *model.RoutineDTO) (model.RoutineData, error)
+	GetRoutinesByUser(ctx context.Context, userId string, page model.PageParams) (model.Page[model.RoutineData], error)
+	GetFreeSchedules(ctx context.Context, users []string) (model.FreeSchedule, error)
+	DeleteRutineBySchedule(ctx context.Context, userId string, schedule *model.Schedule, page model.PageParams, check func(current model.Page[model.RoutineData]) error) ([]model.RoutineData, error)
 }
 
 type RoutineService struct {
@@ -31,8 +34,9 @@ func NewRoutineService(r repository.IRoutineRepository) (*RoutineService, error)
 	}, nil
 }
 
-func (s *RoutineService) CreateRoutine(data *model.RoutineDTO) (model.RoutineData, error) {
+func (s *RoutineService) CreateRoutine(ctx context.Context, data *model.RoutineDTO) (model.RoutineData, error) {
 	existentRoutines, err := s.r.GetRoutinesByInterval(
+		ctx,
 		data.UserID,
 		&model.Schedule{
 			Day:       data.Day,
@@ -45,31 +49,29 @@ func (s *RoutineService) CreateRoutine(data *model.RoutineDTO) (model.RoutineDat
 	}
 
 	if len(existentRoutines) > 0 {
+		metrics.RoutineConflicts.Inc()
 		return model.RoutineData{}, &model.ConflictError{
-			Title:  "Routine conflict",
-			Detail: "There is already a routine scheduled in the same time interval or subinterval",
+			Code: "routine.overlap",
 		}
 	}
 
-	ret, err := s.r.CreateRoutine(data)
+	ret, err := s.r.CreateRoutine(ctx, data)
 	if err != nil {
+		if _, ok := err.(*model.ConflictError); ok {
+			metrics.RoutineConflicts.Inc()
+		}
+
 		return model.RoutineData{}, err
 	}
 
 	return ret, nil
 }
 
-func (s *RoutineService) GetRoutinesByUser(userId string, data *[]model.RoutineData) error {
-	err := s.r.GetRoutinesByUserId(userId, data)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+func (s *RoutineService) GetRoutinesByUser(ctx context.Context, userId string, page model.PageParams) (model.Page[model.RoutineData], error) {
+	return s.r.GetRoutinesPageByUserId(ctx, userId, page)
 }
 
-func (s *RoutineService) getFreeHours(users []string) (map[string][]bool, error) {
+func (s *RoutineService) getFreeHours(ctx context.Context, users []string) (map[string][]bool, error) {
 	days := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
 
 	freeSchedules := make(map[string][]bool, 0)
@@ -83,7 +85,7 @@ func (s *RoutineService) getFreeHours(users []string) (map[string][]bool, error)
 	for _, user := range users {
 		var routines []model.RoutineData
 
-		err := s.r.GetRoutinesByUserId(user, &routines)
+		err := s.r.GetRoutinesByUserId(ctx, user, &routines)
 		if err != nil {
 			return nil, err
 		}
@@ -139,14 +141,14 @@ func (s *RoutineService) freeHoursToSchedule(freeSchedules map[string][]bool) []
 	return schedules
 }
 
-func (s *RoutineService) GetFreeSchedules(users []string) (model.FreeSchedule, error) {
+func (s *RoutineService) GetFreeSchedules(ctx context.Context, users []string) (model.FreeSchedule, error) {
 	if len(users) == 0 {
 		return model.FreeSchedule{
 			Schedules: []model.Schedule{},
 		}, nil
 	}
 
-	freeSchedules, err := s.getFreeHours(users)
+	freeSchedules, err := s.getFreeHours(ctx, users)
 	if err != nil {
 		return model.FreeSchedule{}, err
 	}
@@ -157,23 +159,24 @@ func (s *RoutineService) GetFreeSchedules(users []string) (model.FreeSchedule, e
 
 	if len(data.Schedules) == 0 {
 		return model.FreeSchedule{}, &model.NotFoundError{
-			Title:  "No free schedules found",
-			Detail: "No free schedules found for the provided users",
+			Code: "routine.no_free_schedules",
 		}
 	}
 
 	return data, nil
 }
 
-func (s *RoutineService) DeleteRutineBySchedule(userId string, schedule *model.Schedule) ([]model.RoutineData, error) {
-	err := s.r.DeleteRoutineBySchedule(userId, schedule)
+// DeleteRutineBySchedule deletes the routine of the user in the schedule, only if check, when set,
+// accepts the page of the routines of the user they have right before it
+func (s *RoutineService) DeleteRutineBySchedule(ctx context.Context, userId string, schedule *model.Schedule, page model.PageParams, check func(current model.Page[model.RoutineData]) error) ([]model.RoutineData, error) {
+	err := s.r.DeleteRoutineBySchedule(ctx, userId, schedule, page, check)
 	if err != nil {
 		return nil, err
 	}
 
 	var data []model.RoutineData
 
-	s.GetRoutinesByUser(userId, &data)
+	s.r.GetRoutinesByUserId(ctx, userId, &data)
 
 	return data, nil
 }
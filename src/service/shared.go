@@ -1,5 +1 @@
 package service
-
-import "github.com/op/go-logging"
-
-var log = logging.MustGetLogger("log")
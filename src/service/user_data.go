@@ -1,25 +1,39 @@
 package service
 
 import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/metrics"
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/NutriPocket/ProgressService/repository"
 )
 
 type IUserDataService interface {
-	PutAnthropometricData(data *model.AnthropometricData) (model.AnthropometricData, error, bool)
-	GetAnthropometricDataByUserAndDay(userId string, date string) (model.AnthropometricData, error)
-	GetAllAnthropometricDataByUser(userId string, params *model.GetAnthropometricParams) ([]model.AnthropometricData, error)
-	PutFixedData(data *model.BaseFixedUserData) (model.FixedUserData, error, bool)
-	GetFixedDataByUser(userId string) (model.FixedUserData, error)
-	GetBaseFixedUserDataByUser(userId string) (model.BaseFixedUserData, error)
+	PutAnthropometricData(ctx context.Context, data *model.AnthropometricEntry) (model.AnthropometricEntry, error, bool)
+	AddAnthropometricEntry(ctx context.Context, data *model.AnthropometricEntry) (model.AnthropometricEntry, error)
+	GetAnthropometricDataByUserAndDay(ctx context.Context, userId string, date string, aggregate model.AnthropometricAggregate) (model.DailyAnthropometricData, error)
+	GetAllAnthropometricDataByUser(ctx context.Context, userId string, params *model.GetAnthropometricParams) (model.Page[model.AnthropometricEntry], error)
+	PatchAnthropometricData(ctx context.Context, userId string, date string, id *uint64, patch *model.AnthropometricPatch) (model.AnthropometricEntry, error)
+	DeleteAnthropometricData(ctx context.Context, userId string, date string, id *uint64) error
+	PutFixedData(ctx context.Context, data *model.BaseFixedUserData, version *uint) (model.FixedUserData, error, bool)
+	GetFixedDataByUser(ctx context.Context, userId string) (model.FixedUserData, error)
+	GetBaseFixedUserDataByUser(ctx context.Context, userId string) (model.BaseFixedUserData, error)
+	GetUnitsByUser(ctx context.Context, userId string) (model.UnitSystem, error)
+	GetHeightHistoryByUser(ctx context.Context, userId string) ([]model.HeightRecord, error)
 }
 
 type UserDataService struct {
 	ar  repository.IAnthropometricRepository
 	fdr repository.IFixedDataRepository
+	or  repository.IObjectiveRepository
 }
 
-func NewUserDataService(ar repository.IAnthropometricRepository, fdr repository.IFixedDataRepository) (*UserDataService, error) {
+func NewUserDataService(ar repository.IAnthropometricRepository, fdr repository.IFixedDataRepository, or repository.IObjectiveRepository) (*UserDataService, error) {
 	var err error
 
 	if ar == nil {
@@ -36,23 +50,39 @@ func NewUserDataService(ar repository.IAnthropometricRepository, fdr repository.
 		}
 	}
 
+	if or == nil {
+		or, err = repository.NewObjectiveRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &UserDataService{
 		ar:  ar,
 		fdr: fdr,
+		or:  or,
 	}, nil
 }
 
-func (s *UserDataService) PutAnthropometricData(data *model.AnthropometricData) (ret model.AnthropometricData, err error, created bool) {
-	var storedData *model.AnthropometricData = &model.AnthropometricData{}
-	err = s.ar.GetTodayDataByUserId(data.UserID, storedData)
+// PutAnthropometricData replaces the last measurement of today taken in the same context,
+// or adds a new one if there's none
+func (s *UserDataService) PutAnthropometricData(ctx context.Context, data *model.AnthropometricEntry) (ret model.AnthropometricEntry, err error, created bool) {
+	entries, err := s.ar.GetEntriesByUserIdAndDate(ctx, data.UserID, time.Now().Format("2006-01-02"))
 	if err != nil {
-		if _, ok := err.(*model.NotFoundError); ok {
-			ret, err = s.ar.CreateData(data)
-			created = true
-			return
+		slog.ErrorContext(ctx, "Failed to check current anthropometric data", "user_id", data.UserID, "error", err)
+		return
+	}
+
+	var storedData *model.AnthropometricEntry
+	for i := range entries {
+		if sameContext(entries[i].Context, data.Context) {
+			storedData = &entries[i]
 		}
+	}
 
-		log.Errorf("Failed to check current anthropometric data for user %s: %v", data.UserID, err)
+	if storedData == nil {
+		ret, err = s.AddAnthropometricEntry(ctx, data)
+		created = true
 		return
 	}
 
@@ -69,32 +99,305 @@ func (s *UserDataService) PutAnthropometricData(data *model.AnthropometricData)
 		storedData.BoneMass = data.BoneMass
 	}
 
-	ret, err = s.ar.ReplaceTodayData(storedData)
+	if data.Circumferences != nil {
+		storedData.Circumferences = data.Circumferences
+	}
+
+	if data.Skinfolds != nil {
+		storedData.Skinfolds = data.Skinfolds
+	}
+
+	ret, err = s.ar.ReplaceEntry(ctx, storedData)
+	if err != nil {
+		return
+	}
+
+	metrics.MeasurementsLogged.Inc()
+	s.achieveMilestones(ctx, &ret)
+	err = s.setIndicators(ctx, data.UserID, &ret)
 	return
 }
 
-func (s *UserDataService) GetAnthropometricDataByUserAndDay(userId string, date string) (model.AnthropometricData, error) {
-	var ret model.AnthropometricData
-	err := s.ar.GetDataByUserIdAndDate(userId, date, &ret)
+// getFixedData returns the fixed data of the user, or nil if the user hasn't registered it
+func (s *UserDataService) getFixedData(ctx context.Context, userId string) (*model.BaseFixedUserData, error) {
+	var fixedData model.BaseFixedUserData
+	err := s.fdr.GetBaseFixedUserData(ctx, userId, &fixedData)
+	if err != nil {
+		if _, ok := err.(*model.NotFoundError); ok {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &fixedData, nil
+}
+
+// getBodyProfile returns the sex, birthday and height history of the user, or nil if the user
+// hasn't registered fixed data
+func (s *UserDataService) getBodyProfile(ctx context.Context, userId string) (*bodyProfile, error) {
+	fixedData, err := s.getFixedData(ctx, userId)
+	if err != nil || fixedData == nil {
+		return nil, err
+	}
+
+	heights, err := s.fdr.GetHeightHistory(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &bodyProfile{
+		sex:      fixedData.Sex,
+		height:   fixedData.Height,
+		heights:  heights,
+		location: location(fixedData.TimeZone),
+	}
+
+	if birthday, err := parseDate(fixedData.Birthday); err == nil {
+		profile.birthday = &birthday
+	}
+
+	return profile, nil
+}
+
+// setIndicators derives the body indicators of each entry of the user, with the height and
+// age the user had when the entry was measured
+func (s *UserDataService) setIndicators(ctx context.Context, userId string, entries ...*model.AnthropometricEntry) error {
+	profile, err := s.getBodyProfile(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		entry.Indicators = profile.indicators(&entry.AnthropometricData, entry.Circumferences)
+	}
+
+	return nil
+}
+
+func sameContext(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+func (s *UserDataService) AddAnthropometricEntry(ctx context.Context, data *model.AnthropometricEntry) (model.AnthropometricEntry, error) {
+	ret, err := s.ar.CreateEntry(ctx, data)
+	if err != nil {
+		return model.AnthropometricEntry{}, err
+	}
+
+	metrics.MeasurementsLogged.Inc()
+	s.achieveMilestones(ctx, &ret)
+	err = s.setIndicators(ctx, data.UserID, &ret)
+
+	return ret, err
+}
+
+// achieveMilestones marks the milestones of the objective of the user reached by the entry.
+// The entry is already stored, so a failure is only logged by the repository instead of
+// failing the request.
+func (s *UserDataService) achieveMilestones(ctx context.Context, entry *model.AnthropometricEntry) {
+	at, err := time.Parse(time.RFC3339Nano, entry.CreatedAt)
+	if err != nil {
+		at = time.Now()
+	}
+
+	_, _ = s.or.AchieveMilestones(ctx, entry.UserID, entry.Weight, at)
+}
+
+func (s *UserDataService) GetAnthropometricDataByUserAndDay(ctx context.Context, userId string, date string, aggregate model.AnthropometricAggregate) (model.DailyAnthropometricData, error) {
+	entries, err := s.ar.GetEntriesByUserIdAndDate(ctx, userId, date)
+	if err != nil {
+		return model.DailyAnthropometricData{}, err
+	}
+
+	if len(entries) == 0 {
+		return model.DailyAnthropometricData{}, &model.NotFoundError{
+			Code:   "anthropometric.not_found",
+			Params: map[string]string{"userId": userId, "date": date},
+		}
+	}
+
+	profile, err := s.getBodyProfile(ctx, userId)
+	if err != nil {
+		return model.DailyAnthropometricData{}, err
+	}
+
+	for i := range entries {
+		entries[i].Indicators = profile.indicators(&entries[i].AnthropometricData, entries[i].Circumferences)
+	}
+
+	data, measurements := aggregateEntries(entries, aggregate)
+
+	return model.DailyAnthropometricData{
+		AnthropometricData: data,
+		BodyMeasurements:   measurements,
+		Indicators:         profile.indicators(&data, measurements.Circumferences),
+		Aggregate:          aggregate,
+		Entries:            entries,
+	}, nil
+}
+
+// aggregateEntries combines the measurements of a day, sorted from the first to the last one.
+// first and last pick one of the measurements, min and mean combine each field among the
+// measurements that have it and keep the date of the last one.
+func aggregateEntries(entries []model.AnthropometricEntry, aggregate model.AnthropometricAggregate) (model.AnthropometricData, model.BodyMeasurements) {
+	switch aggregate {
+	case model.AggregateFirst:
+		return entries[0].AnthropometricData, entries[0].BodyMeasurements
+	case model.AggregateLast:
+		last := entries[len(entries)-1]
+		return last.AnthropometricData, last.BodyMeasurements
+	}
+
+	ret := entries[len(entries)-1].AnthropometricData
+	ret.Weight = *combineField(entries, aggregate, func(e *model.AnthropometricEntry) *float32 { return &e.Weight })
+	ret.MuscleMass = combineField(entries, aggregate, func(e *model.AnthropometricEntry) *float32 { return e.MuscleMass })
+	ret.FatMass = combineField(entries, aggregate, func(e *model.AnthropometricEntry) *float32 { return e.FatMass })
+	ret.BoneMass = combineField(entries, aggregate, func(e *model.AnthropometricEntry) *float32 { return e.BoneMass })
+
+	return ret, combineMeasurements(entries, aggregate)
+}
+
+// combineField returns the minimum or the mean of a field of the entries, rounded to the
+// precision they are stored with. It returns nil if no entry has the field.
+func combineField(entries []model.AnthropometricEntry, aggregate model.AnthropometricAggregate, field func(*model.AnthropometricEntry) *float32) *float32 {
+	var values []float64
+	for i := range entries {
+		if value := field(&entries[i]); value != nil {
+			values = append(values, float64(*value))
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	combined := values[0]
+	for _, value := range values[1:] {
+		if aggregate == model.AggregateMin {
+			combined = math.Min(combined, value)
+		} else {
+			combined += value
+		}
+	}
+
+	if aggregate == model.AggregateMean {
+		return round(combined/float64(len(values)), 2)
+	}
+
+	ret := float32(combined)
+	return &ret
+}
+
+func (s *UserDataService) GetAllAnthropometricDataByUser(ctx context.Context, userId string, params *model.GetAnthropometricParams) (model.Page[model.AnthropometricEntry], error) {
+	page, err := s.ar.GetAllDataByUserId(ctx, userId, params)
+	if err != nil {
+		return model.Page[model.AnthropometricEntry]{}, err
+	}
+
+	entries := make([]*model.AnthropometricEntry, len(page.Items))
+	for i := range page.Items {
+		entries[i] = &page.Items[i]
+	}
+
+	err = s.setIndicators(ctx, userId, entries...)
+
+	return page, err
+}
+
+// getEntryOfDate returns the measurement with the id, or the last one of the date if id is nil.
+// It returns a NotFoundError if the measurement wasn't taken on the date.
+func (s *UserDataService) getEntryOfDate(ctx context.Context, userId string, date string, id *uint64) (model.AnthropometricEntry, error) {
+	if id != nil {
+		var entry model.AnthropometricEntry
+		if err := s.ar.GetEntryById(ctx, userId, *id, &entry); err != nil {
+			return model.AnthropometricEntry{}, err
+		}
+
+		if !strings.HasPrefix(entry.CreatedAt, date) {
+			return model.AnthropometricEntry{}, &model.NotFoundError{
+				Code:   "anthropometric.entry_not_found",
+				Params: map[string]string{"userId": userId, "id": strconv.FormatUint(*id, 10)},
+			}
+		}
+
+		return entry, nil
+	}
+
+	entries, err := s.ar.GetEntriesByUserIdAndDate(ctx, userId, date)
+	if err != nil {
+		return model.AnthropometricEntry{}, err
+	}
+
+	if len(entries) == 0 {
+		return model.AnthropometricEntry{}, &model.NotFoundError{
+			Code:   "anthropometric.not_found",
+			Params: map[string]string{"userId": userId, "date": date},
+		}
+	}
+
+	return entries[len(entries)-1], nil
+}
+
+// PatchAnthropometricData updates the measurement with the id, or the last one of the date if id is nil
+func (s *UserDataService) PatchAnthropometricData(ctx context.Context, userId string, date string, id *uint64, patch *model.AnthropometricPatch) (model.AnthropometricEntry, error) {
+	storedData, err := s.getEntryOfDate(ctx, userId, date, id)
+	if err != nil {
+		return model.AnthropometricEntry{}, err
+	}
+
+	if patch.Weight != nil {
+		storedData.Weight = *patch.Weight
+	}
+
+	storedData.MuscleMass = patch.MuscleMass.Apply(storedData.MuscleMass)
+	storedData.FatMass = patch.FatMass.Apply(storedData.FatMass)
+	storedData.BoneMass = patch.BoneMass.Apply(storedData.BoneMass)
+	storedData.Circumferences = patch.Circumferences.Apply(storedData.Circumferences)
+	storedData.Skinfolds = patch.Skinfolds.Apply(storedData.Skinfolds)
+
+	ret, err := s.ar.ReplaceEntry(ctx, &storedData)
+	if err != nil {
+		return model.AnthropometricEntry{}, err
+	}
+
+	s.achieveMilestones(ctx, &ret)
+	err = s.setIndicators(ctx, userId, &ret)
 
 	return ret, err
 }
 
-func (s *UserDataService) GetAllAnthropometricDataByUser(userId string, params *model.GetAnthropometricParams) ([]model.AnthropometricData, error) {
-	return s.ar.GetAllDataByUserId(userId, params)
+// DeleteAnthropometricData deletes the measurement with the id, or every measurement of the date if id is nil
+func (s *UserDataService) DeleteAnthropometricData(ctx context.Context, userId string, date string, id *uint64) error {
+	if id == nil {
+		return s.ar.DeleteDataByDate(ctx, userId, date)
+	}
+
+	if _, err := s.getEntryOfDate(ctx, userId, date, id); err != nil {
+		return err
+	}
+
+	return s.ar.DeleteEntry(ctx, userId, *id)
 }
 
-func (s *UserDataService) PutFixedData(data *model.BaseFixedUserData) (ret model.FixedUserData, err error, created bool) {
+func (s *UserDataService) PutFixedData(ctx context.Context, data *model.BaseFixedUserData, version *uint) (ret model.FixedUserData, err error, created bool) {
 	var storedData *model.BaseFixedUserData = &model.BaseFixedUserData{}
-	err = s.fdr.GetBaseFixedUserData(data.UserID, storedData)
+	err = s.fdr.GetBaseFixedUserData(ctx, data.UserID, storedData)
 	if err != nil {
 		if _, ok := err.(*model.NotFoundError); ok {
-			ret, err = s.fdr.CreateData(data)
-		created = true
-		return
+			ret, err = s.fdr.CreateData(ctx, data)
+			created = true
+			if err == nil {
+				ret.Age, err = currentAge(data.Birthday, data.TimeZone)
+			}
+			return
 		}
 
-		log.Errorf("Failed to get fixed user data for user %s: %v", data.UserID, err)
+		slog.ErrorContext(ctx, "Failed to get fixed user data", "user_id", data.UserID, "error", err)
 		return
 	}
 
@@ -106,20 +409,79 @@ func (s *UserDataService) PutFixedData(data *model.BaseFixedUserData) (ret model
 		storedData.Height = data.Height
 	}
 
-	ret, err = s.fdr.ReplaceData(storedData)
+	if data.Sex != nil {
+		storedData.Sex = data.Sex
+	}
+
+	if data.Units != nil {
+		storedData.Units = data.Units
+	}
+
+	if data.TimeZone != nil {
+		storedData.TimeZone = data.TimeZone
+	}
+
+	ret, err = s.fdr.ReplaceData(ctx, storedData, version)
+	if err != nil {
+		return
+	}
+
+	ret.Age, err = currentAge(storedData.Birthday, storedData.TimeZone)
 	return
 }
 
-func (s *UserDataService) GetFixedDataByUser(userId string) (model.FixedUserData, error) {
-	var ret model.FixedUserData
-	err := s.fdr.GetUserData(userId, &ret)
+// GetFixedDataByUser returns the fixed data of the user with the age the user has today in
+// their time zone
+func (s *UserDataService) GetFixedDataByUser(ctx context.Context, userId string) (model.FixedUserData, error) {
+	fixedData, err := s.getFixedData(ctx, userId)
+	if err != nil {
+		return model.FixedUserData{}, err
+	}
 
-	return ret, err
+	if fixedData == nil {
+		return model.FixedUserData{}, &model.NotFoundError{
+			Code:   "fixed_data.not_found",
+			Params: map[string]string{"userId": userId},
+		}
+	}
+
+	age, err := currentAge(fixedData.Birthday, fixedData.TimeZone)
+	if err != nil {
+		return model.FixedUserData{}, err
+	}
+
+	return model.FixedUserData{
+		UserID:   fixedData.UserID,
+		Height:   fixedData.Height,
+		Age:      age,
+		Sex:      fixedData.Sex,
+		Units:    fixedData.Units,
+		TimeZone: fixedData.TimeZone,
+	}, nil
 }
 
-func (s *UserDataService) GetBaseFixedUserDataByUser(userId string) (model.BaseFixedUserData, error) {
+func (s *UserDataService) GetBaseFixedUserDataByUser(ctx context.Context, userId string) (model.BaseFixedUserData, error) {
 	var ret model.BaseFixedUserData
-	err := s.fdr.GetBaseFixedUserData(userId, &ret)
+	err := s.fdr.GetBaseFixedUserData(ctx, userId, &ret)
 
 	return ret, err
 }
+
+// GetHeightHistoryByUser returns the heights the user has registered, from the oldest to the current one
+func (s *UserDataService) GetHeightHistoryByUser(ctx context.Context, userId string) ([]model.HeightRecord, error) {
+	return s.fdr.GetHeightHistory(ctx, userId)
+}
+
+// GetUnitsByUser returns the unit system the user prefers, metric if the user has no preference
+func (s *UserDataService) GetUnitsByUser(ctx context.Context, userId string) (model.UnitSystem, error) {
+	fixedData, err := s.getFixedData(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+
+	if fixedData == nil || fixedData.Units == nil {
+		return model.UnitsMetric, nil
+	}
+
+	return *fixedData.Units, nil
+}
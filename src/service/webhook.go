@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/events"
+	"github.com/NutriPocket/ProgressService/jobs"
+	"github.com/NutriPocket/ProgressService/metrics"
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/repository"
+	"github.com/NutriPocket/ProgressService/webhooks"
+)
+
+// Defaults of the configuration of the webhooks
+const (
+	defaultWebhookMaxAttempts = 8
+	defaultWebhookBackoff     = 30 * time.Second
+	defaultWebhookTimeout     = 10 * time.Second
+)
+
+// deliveryLeaseMargin is how long a claimed delivery is held after the timeout of its request,
+// before another worker may take it over
+const deliveryLeaseMargin = time.Minute
+
+type IWebhookService interface {
+	CreateWebhook(ctx context.Context, data *model.WebhookDTO) (model.Webhook, error)
+	GetWebhook(ctx context.Context, id uint64) (model.Webhook, error)
+	GetWebhooks(ctx context.Context, page model.PageParams) (model.Page[model.Webhook], error)
+	UpdateWebhook(ctx context.Context, id uint64, data *model.WebhookDTO) (model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uint64) error
+	GetDeliveries(ctx context.Context, webhookId uint64, status *model.DeliveryStatus, page model.PageParams) (model.Page[model.WebhookDelivery], error)
+	RetryDelivery(ctx context.Context, webhookId uint64, id uint64) (model.WebhookDelivery, error)
+	EnqueueEvent(ctx context.Context, event events.Event) error
+	DeliverDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type WebhookService struct {
+	r      repository.IWebhookRepository
+	sender *webhooks.Sender
+	// maxAttempts and backoff are read from WEBHOOK_MAX_ATTEMPTS and WEBHOOK_RETRY_BACKOFF
+	maxAttempts uint
+	backoff     time.Duration
+	// lease is how long a delivery is held by the worker sending it
+	lease time.Duration
+}
+
+func NewWebhookService(r repository.IWebhookRepository) (*WebhookService, error) {
+	var err error
+
+	if r == nil {
+		r, err = repository.NewWebhookRepository(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	maxAttempts := uint(defaultWebhookMaxAttempts)
+	if value := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || parsed == 0 {
+			slog.Warn("Invalid WEBHOOK_MAX_ATTEMPTS, using the default", "value", value, "default", maxAttempts)
+		} else {
+			maxAttempts = uint(parsed)
+		}
+	}
+
+	timeout := durationEnv("WEBHOOK_TIMEOUT", defaultWebhookTimeout)
+
+	return &WebhookService{
+		r:           r,
+		sender:      webhooks.NewSender(timeout),
+		maxAttempts: maxAttempts,
+		backoff:     durationEnv("WEBHOOK_RETRY_BACKOFF", defaultWebhookBackoff),
+		lease:       timeout + deliveryLeaseMargin,
+	}, nil
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, data *model.WebhookDTO) (model.Webhook, error) {
+	return s.r.CreateWebhook(ctx, data)
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, id uint64) (model.Webhook, error) {
+	return s.r.GetWebhook(ctx, id)
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, page model.PageParams) (model.Page[model.Webhook], error) {
+	return s.r.GetWebhooks(ctx, page)
+}
+
+// UpdateWebhook replaces the webhook, or returns a not found error if it doesn't exist. The
+// pending deliveries are sent to the new URL with the new secret.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id uint64, data *model.WebhookDTO) (model.Webhook, error) {
+	if _, err := s.r.GetWebhook(ctx, id); err != nil {
+		return model.Webhook{}, err
+	}
+
+	return s.r.UpdateWebhook(ctx, id, data)
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id uint64) error {
+	return s.r.DeleteWebhook(ctx, id)
+}
+
+// GetDeliveries returns a page of the deliveries of the webhook, or a not found error if it
+// doesn't exist. The dead letters of the webhook are the ones with status dead.
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookId uint64, status *model.DeliveryStatus, page model.PageParams) (model.Page[model.WebhookDelivery], error) {
+	if _, err := s.r.GetWebhook(ctx, webhookId); err != nil {
+		return model.Page[model.WebhookDelivery]{}, err
+	}
+
+	return s.r.GetDeliveries(ctx, webhookId, status, page)
+}
+
+// RetryDelivery sends a dead delivery again on the next run of the deliveries, with all of its
+// attempts. Deliveries that aren't dead can't be retried.
+func (s *WebhookService) RetryDelivery(ctx context.Context, webhookId uint64, id uint64) (model.WebhookDelivery, error) {
+	delivery, err := s.r.GetDelivery(ctx, webhookId, id)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	if delivery.Status != model.DeliveryDead {
+		return model.WebhookDelivery{}, &model.ConflictError{
+			Code:   "webhook.delivery_not_dead",
+			Params: map[string]string{"status": string(delivery.Status)},
+		}
+	}
+
+	retried, err := s.r.RetryDelivery(ctx, webhookId, id, time.Now())
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	// It's retried at the SQL level only if it's still dead, another request may have retried it
+	if !retried {
+		current, err := s.r.GetDelivery(ctx, webhookId, id)
+		if err != nil {
+			return model.WebhookDelivery{}, err
+		}
+
+		return model.WebhookDelivery{}, &model.ConflictError{
+			Code:   "webhook.delivery_not_dead",
+			Params: map[string]string{"status": string(current.Status)},
+		}
+	}
+
+	return s.r.GetDelivery(ctx, webhookId, id)
+}
+
+// EnqueueEvent stores a delivery of the event for every webhook subscribed to its type, they
+// are sent by DeliverDue. An event enqueued twice, identified by its ID, is only delivered once.
+func (s *WebhookService) EnqueueEvent(ctx context.Context, event events.Event) error {
+	body, err := json.Marshal(model.WebhookEvent{
+		ID:         event.ID,
+		Type:       event.Type,
+		UserID:     event.UserID,
+		OccurredAt: event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		Data:       event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	enqueued, err := s.r.EnqueueDeliveries(ctx, event.ID, event.Type, string(body), event.OccurredAt)
+	if err != nil {
+		return err
+	}
+
+	slog.DebugContext(ctx, "Enqueued webhook deliveries", "event_id", event.ID, "type", event.Type, "deliveries", enqueued)
+	return nil
+}
+
+// DeliverDue sends the deliveries due at now and returns how many were delivered. A failed
+// delivery is retried with an exponential backoff until it reaches the max attempts, when it's
+// moved to the dead letters. Each delivery is claimed before it's sent, so concurrent workers
+// don't send it twice.
+func (s *WebhookService) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	delivered := 0
+
+	for {
+		delivery, err := s.r.ClaimDelivery(ctx, now, s.lease)
+		if err != nil || delivery == nil {
+			return delivered, err
+		}
+
+		ok, err := s.deliver(ctx, delivery, now)
+		if err != nil {
+			return delivered, err
+		}
+
+		if ok {
+			delivered++
+		}
+	}
+}
+
+// deliver sends the delivery claimed in the run at now and records its outcome, it returns whether
+// it was delivered
+func (s *WebhookService) deliver(ctx context.Context, pending *model.PendingDelivery, now time.Time) (bool, error) {
+	delivery := &pending.WebhookDelivery
+
+	status, sendErr := s.sender.Send(ctx, webhooks.Delivery{
+		URL:       pending.URL,
+		Secret:    pending.Secret,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Body:      []byte(delivery.Payload),
+	}, time.Now())
+
+	finishedAt := time.Now()
+	// The retry is never due in the same run, so the run ends once every due delivery was sent
+	nextAttemptAt := finishedAt
+	if now.After(nextAttemptAt) {
+		nextAttemptAt = now
+	}
+	var deliveredAt *time.Time
+
+	delivery.LastStatusCode = status
+
+	if sendErr == nil {
+		delivery.Status = model.DeliveryDelivered
+		delivery.LastError = nil
+		deliveredAt = &finishedAt
+	} else {
+		message := sendErr.Error()
+		if len(message) > maxJobError {
+			message = message[:maxJobError]
+		}
+		delivery.LastError = &message
+
+		if delivery.Attempts < s.maxAttempts {
+			delivery.Status = model.DeliveryPending
+			nextAttemptAt = nextAttemptAt.Add(jobs.Backoff(delivery.Attempts, s.backoff))
+		} else {
+			delivery.Status = model.DeliveryDead
+		}
+
+		slog.WarnContext(
+			ctx, "Failed to deliver event to webhook",
+			"webhook_id", delivery.WebhookID, "event_id", delivery.EventID, "attempt", delivery.Attempts,
+			"status", delivery.Status, "error", sendErr,
+		)
+	}
+
+	metricStatus := string(delivery.Status)
+	if delivery.Status == model.DeliveryPending {
+		metricStatus = "failed"
+	}
+	metrics.WebhookDeliveries.WithLabelValues(delivery.EventType, metricStatus).Inc()
+
+	finished, err := s.r.FinishDelivery(ctx, delivery, nextAttemptAt, deliveredAt)
+	if err != nil {
+		return false, err
+	}
+
+	if !finished {
+		slog.WarnContext(
+			ctx, "The lease of the webhook delivery ended before it was sent, another worker took it over",
+			"webhook_id", delivery.WebhookID, "event_id", delivery.EventID, "attempt", delivery.Attempts,
+		)
+		return false, nil
+	}
+
+	return sendErr == nil, nil
+}
@@ -5,4 +5,5 @@ USE mydb;
 SET time_zone = '+00:00';
 
 SOURCE /src/sql/tables.sql;
+SOURCE /src/sql/migrations.sql;
 SOURCE /src/sql/default.sql;
\ No newline at end of file
@@ -0,0 +1,85 @@
+-- Brings databases created with earlier versions of tables.sql up to date, after sourcing it to create
+-- the tables they lack. Every step is skipped when already applied, so it's safe to run again.
+
+DROP PROCEDURE IF EXISTS add_column_if_missing;
+DROP PROCEDURE IF EXISTS add_index_if_missing;
+DROP PROCEDURE IF EXISTS add_anthropometric_id;
+
+DELIMITER //
+
+CREATE PROCEDURE add_column_if_missing(IN tbl VARCHAR(64), IN col VARCHAR(64), IN definition VARCHAR(255))
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = DATABASE() AND table_name = tbl AND column_name = col
+    ) THEN
+        SET @ddl = CONCAT('ALTER TABLE ', tbl, ' ADD COLUMN ', col, ' ', definition);
+        PREPARE stmt FROM @ddl;
+        EXECUTE stmt;
+        DEALLOCATE PREPARE stmt;
+    END IF;
+END //
+
+CREATE PROCEDURE add_index_if_missing(IN tbl VARCHAR(64), IN idx VARCHAR(64), IN cols VARCHAR(255))
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.statistics
+        WHERE table_schema = DATABASE() AND table_name = tbl AND index_name = idx
+    ) THEN
+        SET @ddl = CONCAT('ALTER TABLE ', tbl, ' ADD INDEX ', idx, ' (', cols, ')');
+        PREPARE stmt FROM @ddl;
+        EXECUTE stmt;
+        DEALLOCATE PREPARE stmt;
+    END IF;
+END //
+
+-- Several measurements per day: the key of a measurement was the user and its creation date
+CREATE PROCEDURE add_anthropometric_id()
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = DATABASE() AND table_name = 'anthropometric_data' AND column_name = 'id'
+    ) THEN
+        ALTER TABLE anthropometric_data
+            DROP PRIMARY KEY,
+            ADD COLUMN id SERIAL PRIMARY KEY FIRST;
+    END IF;
+END //
+
+DELIMITER ;
+
+CALL add_anthropometric_id();
+CALL add_column_if_missing('anthropometric_data', 'context', 'VARCHAR(16) AFTER bone_mass');
+CALL add_index_if_missing('anthropometric_data', 'idx_anthropometric_data_user_created', 'user_id, created_at');
+
+-- Sex, unit system, time zone and version of the fixed data
+CALL add_column_if_missing('fixed_user_data', 'sex', 'VARCHAR(6) AFTER birthday');
+CALL add_column_if_missing('fixed_user_data', 'units', 'VARCHAR(8) AFTER sex');
+CALL add_column_if_missing('fixed_user_data', 'time_zone', 'VARCHAR(64) AFTER units');
+CALL add_column_if_missing('fixed_user_data', 'version', 'INT UNSIGNED DEFAULT 1 NOT NULL AFTER time_zone');
+
+-- Activity objectives, whose target isn't a weight
+ALTER TABLE objective MODIFY weight DECIMAL(5,2);
+CALL add_column_if_missing('objective', 'type', 'VARCHAR(32) DEFAULT ''body_composition'' NOT NULL AFTER user_id');
+CALL add_column_if_missing('objective', 'target', 'DECIMAL(8,2) AFTER bone_mass');
+CALL add_column_if_missing('objective', 'period', 'VARCHAR(8) AFTER target');
+
+ALTER TABLE objective_history MODIFY weight DECIMAL(5,2);
+CALL add_column_if_missing('objective_history', 'type', 'VARCHAR(32) DEFAULT ''body_composition'' NOT NULL AFTER user_id');
+CALL add_column_if_missing('objective_history', 'target', 'DECIMAL(8,2) AFTER bone_mass');
+CALL add_column_if_missing('objective_history', 'period', 'VARCHAR(8) AFTER target');
+
+-- Closing of objectives and their version
+CALL add_column_if_missing('objective', 'status', 'VARCHAR(16) DEFAULT ''active'' NOT NULL AFTER deadline');
+CALL add_column_if_missing('objective', 'closed_at', 'DATETIME(6) AFTER status');
+CALL add_column_if_missing('objective', 'final_value', 'DECIMAL(8,2) AFTER closed_at');
+CALL add_column_if_missing('objective', 'version', 'INT UNSIGNED DEFAULT 1 NOT NULL AFTER final_value');
+CALL add_index_if_missing('objective', 'objective_status', 'status');
+
+CALL add_column_if_missing('objective_milestone', 'created_at', 'DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL');
+
+CALL add_column_if_missing('exercise_by_day', 'version', 'INT UNSIGNED DEFAULT 1 NOT NULL AFTER calories_burned');
+
+DROP PROCEDURE add_column_if_missing;
+DROP PROCEDURE add_index_if_missing;
+DROP PROCEDURE add_anthropometric_id;
@@ -2,27 +2,92 @@ CREATE TABLE IF NOT EXISTS fixed_user_data (
     user_id VARCHAR(36) PRIMARY KEY,
     height SMALLINT UNSIGNED NOT NULL,
     birthday DATE NOT NULL,
+    sex VARCHAR(6),
+    units VARCHAR(8),
+    time_zone VARCHAR(64),
+    version INT UNSIGNED DEFAULT 1 NOT NULL,
     created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
 );
 
+CREATE TABLE IF NOT EXISTS height_history (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    height SMALLINT UNSIGNED NOT NULL,
+    valid_from DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+    INDEX idx_height_history_user_valid_from (user_id, valid_from)
+);
+
 CREATE TABLE IF NOT EXISTS anthropometric_data (
+    id SERIAL PRIMARY KEY,
     user_id VARCHAR(36) NOT NULL,
     weight DECIMAL(5,2) NOT NULL,
     muscle_mass DECIMAL(5,2),
     fat_mass DECIMAL(5,2),
     bone_mass DECIMAL(5,2),
+    context VARCHAR(16),
     created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
-    PRIMARY KEY (user_id, created_at)
+    INDEX idx_anthropometric_data_user_created (user_id, created_at)
+);
+
+CREATE TABLE IF NOT EXISTS body_measurements (
+    anthropometric_id BIGINT UNSIGNED PRIMARY KEY,
+    waist DECIMAL(5,2),
+    hip DECIMAL(5,2),
+    neck DECIMAL(5,2),
+    chest DECIMAL(5,2),
+    arm DECIMAL(5,2),
+    thigh DECIMAL(5,2),
+    triceps_skinfold DECIMAL(4,1),
+    biceps_skinfold DECIMAL(4,1),
+    subscapular_skinfold DECIMAL(4,1),
+    suprailiac_skinfold DECIMAL(4,1),
+    abdominal_skinfold DECIMAL(4,1),
+    thigh_skinfold DECIMAL(4,1)
 );
 
 CREATE TABLE IF NOT EXISTS objective (
     user_id VARCHAR(36) PRIMARY KEY,
-    weight DECIMAL(5,2) NOT NULL,
+    type VARCHAR(32) DEFAULT 'body_composition' NOT NULL,
+    weight DECIMAL(5,2),
     muscle_mass DECIMAL(5,2),
     fat_mass DECIMAL(5,2),
     bone_mass DECIMAL(5,2),
+    target DECIMAL(8,2),
+    period VARCHAR(8),
     deadline DATE NOT NULL,
-    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6)
+    status VARCHAR(16) DEFAULT 'active' NOT NULL,
+    closed_at DATETIME(6),
+    final_value DECIMAL(8,2),
+    version INT UNSIGNED DEFAULT 1 NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    INDEX objective_status (status)
+);
+
+CREATE TABLE IF NOT EXISTS objective_history (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    type VARCHAR(32) DEFAULT 'body_composition' NOT NULL,
+    weight DECIMAL(5,2),
+    muscle_mass DECIMAL(5,2),
+    fat_mass DECIMAL(5,2),
+    bone_mass DECIMAL(5,2),
+    target DECIMAL(8,2),
+    period VARCHAR(8),
+    deadline DATE NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+    INDEX objective_history_user_created (user_id, created_at)
+);
+
+CREATE TABLE IF NOT EXISTS objective_milestone (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    sequence SMALLINT UNSIGNED NOT NULL,
+    weight DECIMAL(5,2) NOT NULL,
+    start_weight DECIMAL(5,2) NOT NULL,
+    due_date DATE NOT NULL,
+    achieved_at DATETIME(6),
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+    INDEX objective_milestone_user (user_id, sequence)
 );
 
 CREATE TABLE IF NOT EXISTS user_routines (
@@ -42,5 +107,100 @@ CREATE TABLE IF NOT EXISTS exercise_by_day (
     user_id VARCHAR(36) NOT NULL,
     exercise_name VARCHAR(64) NOT NULL,
     calories_burned DECIMAL(6,2) NOT NULL,
+    version INT UNSIGNED DEFAULT 1 NOT NULL,
     created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS reminder_rule (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    threshold INT UNSIGNED NOT NULL,
+    quiet_start VARCHAR(5),
+    quiet_end VARCHAR(5),
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    INDEX reminder_rule_user (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id SERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    event_type VARCHAR(64) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    occurred_at DATETIME(6) NOT NULL,
+    published_at DATETIME(6),
+    INDEX outbox_pending (published_at, id)
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_key (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    status_code INT,
+    content_type VARCHAR(255),
+    response MEDIUMTEXT,
+    locked_until DATETIME(6) NOT NULL,
+    expires_at DATETIME(6) NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (user_id, idempotency_key),
+    INDEX idempotency_key_expiration (expires_at)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_subscription (
+    id SERIAL PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types VARCHAR(512) NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+    updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id SERIAL PRIMARY KEY,
+    webhook_id BIGINT UNSIGNED NOT NULL,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    status VARCHAR(16) DEFAULT 'pending' NOT NULL,
+    attempts INT UNSIGNED DEFAULT 0 NOT NULL,
+    next_attempt_at DATETIME(6) NOT NULL,
+    last_status_code SMALLINT,
+    last_error VARCHAR(512),
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+    delivered_at DATETIME(6),
+    UNIQUE webhook_delivery_event (webhook_id, event_id),
+    INDEX webhook_delivery_due (status, next_attempt_at)
+);
+
+CREATE TABLE IF NOT EXISTS job (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    job_key VARCHAR(128) UNIQUE,
+    schedule VARCHAR(64),
+    payload TEXT,
+    status VARCHAR(16) DEFAULT 'pending' NOT NULL,
+    run_at DATETIME(6) NOT NULL,
+    attempts INT UNSIGNED DEFAULT 0 NOT NULL,
+    max_attempts INT UNSIGNED NOT NULL,
+    locked_by VARCHAR(128),
+    locked_until DATETIME(6),
+    last_run_at DATETIME(6),
+    last_error VARCHAR(512),
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+    INDEX job_due (status, run_at)
+);
+
+CREATE TABLE IF NOT EXISTS job_run (
+    id SERIAL PRIMARY KEY,
+    job_id BIGINT UNSIGNED NOT NULL,
+    worker VARCHAR(128) NOT NULL,
+    attempt INT UNSIGNED NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    error VARCHAR(512),
+    started_at DATETIME(6) NOT NULL,
+    finished_at DATETIME(6),
+    INDEX job_run_job_started (job_id, started_at)
 );
\ No newline at end of file
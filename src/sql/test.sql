@@ -4,4 +4,5 @@ USE test;
 
 SET time_zone = '+00:00';
 
-SOURCE /src/sql/tables.sql;
\ No newline at end of file
+SOURCE /src/sql/tables.sql;
+SOURCE /src/sql/migrations.sql;
\ No newline at end of file
@@ -281,15 +281,75 @@ func TestPutUserAnthropometrics(t *testing.T) {
 
 		expected := model.ErrorRfc9457{
 			Title:    "Invalid anthropometric user data",
-			Detail:   `The anthropometric user data is invalid, Key: 'AnthropometricData.Weight' Error:Field validation for 'Weight' failed on the 'required' tag`,
+			Detail:   "One or more fields of the anthropometric data are invalid",
 			Status:   http.StatusBadRequest,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeValidation,
+			Code:     "anthropometric.invalid",
 			Instance: baseURL,
+			Errors: []model.FieldError{
+				{Pointer: "/weight", Rule: "required", Message: "is required"},
+			},
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
+		assert.Equal(t, expected, response)
+	})
+
+	t.Run("PUT /users/:userId/anthropometrics - Weight with a wrong type should raise validation error", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		body := []byte(`{"weight": "seventy"}`)
+		req, _ := http.NewRequest(http.MethodPut, baseURL, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		expected := model.ErrorRfc9457{
+			Title:    "Invalid anthropometric user data",
+			Detail:   "One or more fields of the anthropometric data are invalid",
+			Status:   http.StatusBadRequest,
+			Type:     model.ProblemTypeValidation,
+			Code:     "anthropometric.invalid",
+			Instance: baseURL,
+			Errors: []model.FieldError{
+				{Pointer: "/weight", Rule: "type", Message: "has an invalid type, expected float32"},
+			},
+		}
+
+		response := readProblem(t, w)
+		assert.Equal(t, expected, response)
+	})
+
+	t.Run("PUT /users/:userId/anthropometrics - Validation errors are localized with Accept-Language", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		body := []byte(`{}`)
+		req, _ := http.NewRequest(http.MethodPut, baseURL, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "es-AR,es;q=0.9,en;q=0.8")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+		assert.Equal(t, "es", w.Header().Get("Content-Language"), "Content-Language should be es")
+
+		expected := model.ErrorRfc9457{
+			Title:    "Datos antropométricos inválidos",
+			Detail:   "Uno o más campos de los datos antropométricos son inválidos",
+			Status:   http.StatusBadRequest,
+			Type:     model.ProblemTypeValidation,
+			Code:     "anthropometric.invalid",
+			Instance: baseURL,
+			Errors: []model.FieldError{
+				{Pointer: "/weight", Rule: "required", Message: "es obligatorio"},
+			},
+		}
+
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 
@@ -312,13 +372,12 @@ func TestPutUserAnthropometrics(t *testing.T) {
 			Title:    "Unauthorized user",
 			Detail:   `The user isn't authorized because no Authorization header is provided`,
 			Status:   http.StatusUnauthorized,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeAuthentication,
+			Code:     "auth.missing_header",
 			Instance: baseURL,
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 }
@@ -393,17 +452,16 @@ func TestGetUserAnthropometrics(t *testing.T) {
 
 		expected := model.ErrorRfc9457{
 			Title:    "Anthropometric data not found",
-			Detail:   "No anthropometric data not found for user " + userId + " on date " + date,
+			Detail:   "No anthropometric data found for user " + userId + " on date " + date,
 			Status:   http.StatusNotFound,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeNotFound,
+			Code:     "anthropometric.not_found",
 			Instance: url,
 		}
 
 		assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 
@@ -418,13 +476,512 @@ func TestGetUserAnthropometrics(t *testing.T) {
 			Title:    "Unauthorized user",
 			Detail:   `The user isn't authorized because no Authorization header is provided`,
 			Status:   http.StatusUnauthorized,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeAuthentication,
+			Code:     "auth.missing_header",
 			Instance: baseURL,
 		}
 
-		var response model.ErrorRfc9457
+		response := readProblem(t, w)
+		assert.Equal(t, expected, response)
+	})
+}
+
+func TestPatchUserAnthropometrics(t *testing.T) {
+	userId := testUser.ID
+	date := time.Now().Format("2006-01-02")
+	url := fmt.Sprintf("/users/%s/anthropometrics/%s", userId, date)
+
+	putData := func(t *testing.T, payload model.AnthropometricData) {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%s/anthropometrics/", userId), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+	}
+
+	t.Run("PATCH /users/:userId/anthropometrics/:date - Update weight and clear muscle mass", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		muscleMass := float32(30.2)
+		fatMass := float32(20.1)
+		putData(t, model.AnthropometricData{
+			Weight:     70.5,
+			MuscleMass: &muscleMass,
+			FatMass:    &fatMass,
+		})
+
+		req, _ := http.NewRequest(http.MethodPatch, url, bytes.NewBufferString(`{"weight": 71, "muscle_mass": null}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		actual := unmarshallAnthropometricData(t, w.Body.Bytes())
+		actual.CreatedAt = ""
+
+		expected := model.AnthropometricData{
+			UserID:  userId,
+			Weight:  71,
+			FatMass: &fatMass,
+		}
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("PATCH /users/:userId/anthropometrics/:date - Data not found", func(t *testing.T) {
+		notFoundURL := fmt.Sprintf("/users/%s/anthropometrics/2023-01-01", userId)
+		req, _ := http.NewRequest(http.MethodPatch, notFoundURL, bytes.NewBufferString(`{"weight": 71}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
+
+		response := readProblem(t, w)
+		assert.Equal(t, "anthropometric.not_found", response.Code)
+	})
+
+	t.Run("PATCH /users/:userId/anthropometrics/:date - Non-positive masses should raise Validation Error", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		putData(t, model.AnthropometricData{Weight: 70.5})
+
+		for _, payload := range []string{`{"weight": -5}`, `{"weight": 0}`, `{"muscle_mass": 0}`, `{"fat_mass": -1}`, `{"bone_mass": -1}`} {
+			req, _ := http.NewRequest(http.MethodPatch, url, bytes.NewBufferString(payload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Add("Authorization", bearerToken)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400 for %s", payload)
+
+			response := readProblem(t, w)
+			assert.Equal(t, "anthropometric.invalid", response.Code)
+		}
+	})
+
+	t.Run("PATCH /users/:userId/anthropometrics/:date - Invalid date should raise Validation Error", func(t *testing.T) {
+		invalidURL := fmt.Sprintf("/users/%s/anthropometrics/yesterday", userId)
+		req, _ := http.NewRequest(http.MethodPatch, invalidURL, bytes.NewBufferString(`{"weight": 71}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		response := readProblem(t, w)
+		assert.Equal(t, "request.invalid_date", response.Code)
+	})
+}
+
+func TestDeleteUserAnthropometrics(t *testing.T) {
+	userId := testUser.ID
+	date := time.Now().Format("2006-01-02")
+	url := fmt.Sprintf("/users/%s/anthropometrics/%s", userId, date)
+
+	t.Run("DELETE /users/:userId/anthropometrics/:date - Delete the data of the day", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		body, _ := json.Marshal(model.AnthropometricData{Weight: 70.5})
+		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%s/anthropometrics/", userId), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		req, _ = http.NewRequest(http.MethodDelete, url, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code, "Status code should be 204")
+
+		req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/users/%s/anthropometrics/?date=%s", userId, date), nil)
+		req.Header.Add("Authorization", bearerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
+	})
+
+	t.Run("DELETE /users/:userId/anthropometrics/:date - Data not found", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, url, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
+
+		response := readProblem(t, w)
+		assert.Equal(t, "anthropometric.not_found", response.Code)
+	})
+}
+
+func TestDailyUserAnthropometrics(t *testing.T) {
+	userId := testUser.ID
+	baseURL := fmt.Sprintf("/users/%s/anthropometrics/", userId)
+	date := time.Now().Format("2006-01-02")
+
+	sendEntry := func(t *testing.T, method string, payload string) model.AnthropometricEntry {
+		req, _ := http.NewRequest(method, baseURL, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data model.AnthropometricEntry `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		return response.Data
+	}
+
+	getDay := func(t *testing.T, aggregate string) model.DailyAnthropometricData {
+		url := fmt.Sprintf("%s?date=%s&aggregate=%s", baseURL, date, aggregate)
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var response struct {
+			Data model.DailyAnthropometricData `json:"data"`
+		}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err, "Response should be valid JSON")
-		assert.Equal(t, expected, response)
+
+		return response.Data
+	}
+
+	t.Run("POST /users/:userId/anthropometrics - Aggregate the measurements of the day", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		fasted := sendEntry(t, http.MethodPost, `{"weight": 70, "fat_mass": 20, "context": "fasted"}`)
+		postWorkout := sendEntry(t, http.MethodPost, `{"weight": 71.5, "context": "post_workout"}`)
+
+		assert.Equal(t, model.ContextFasted, *fasted.Context)
+		assert.NotEqual(t, fasted.ID, postWorkout.ID)
+
+		first := getDay(t, "first")
+		assert.Equal(t, float32(70), first.Weight)
+		assert.Len(t, first.Entries, 2)
+
+		last := getDay(t, "last")
+		assert.Equal(t, float32(71.5), last.Weight)
+		assert.Nil(t, last.FatMass)
+
+		minimum := getDay(t, "min")
+		assert.Equal(t, float32(70), minimum.Weight)
+		assert.Equal(t, float32(20), *minimum.FatMass)
+
+		mean := getDay(t, "mean")
+		assert.Equal(t, model.AggregateMean, mean.Aggregate)
+		assert.Equal(t, float32(70.75), mean.Weight)
+		assert.Equal(t, float32(20), *mean.FatMass)
+	})
+
+	t.Run("PUT /users/:userId/anthropometrics - Replace the measurement of the same context only", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		fasted := sendEntry(t, http.MethodPut, `{"weight": 70, "context": "fasted"}`)
+		sendEntry(t, http.MethodPut, `{"weight": 71.5, "context": "post_workout"}`)
+		replaced := sendEntry(t, http.MethodPut, `{"weight": 69.8, "context": "fasted"}`)
+
+		assert.Equal(t, fasted.ID, replaced.ID)
+
+		day := getDay(t, "first")
+		assert.Len(t, day.Entries, 2)
+		assert.Equal(t, float32(69.8), day.Weight)
+	})
+
+	t.Run("DELETE /users/:userId/anthropometrics/:date/:id - Delete one measurement of the day", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		fasted := sendEntry(t, http.MethodPost, `{"weight": 70, "context": "fasted"}`)
+		sendEntry(t, http.MethodPost, `{"weight": 71.5, "context": "post_workout"}`)
+
+		url := fmt.Sprintf("%s%s/%d", baseURL, date, fasted.ID)
+		req, _ := http.NewRequest(http.MethodDelete, url, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code, "Status code should be 204")
+
+		day := getDay(t, "first")
+		assert.Len(t, day.Entries, 1)
+		assert.Equal(t, float32(71.5), day.Weight)
+	})
+
+	t.Run("POST /users/:userId/anthropometrics - Unknown context should raise Validation Error", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, baseURL, bytes.NewBufferString(`{"weight": 70, "context": "sleepy"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		response := readProblem(t, w)
+		assert.Equal(t, "anthropometric.invalid", response.Code)
+		assert.Equal(t, []model.FieldError{{
+			Pointer: "/context",
+			Rule:    "oneof",
+			Message: "must be one of: fasted pre_workout post_workout",
+		}}, response.Errors)
+	})
+
+	t.Run("GET /users/:userId/anthropometrics?aggregate=<aggregate> - Unknown aggregate should raise Validation Error", func(t *testing.T) {
+		url := fmt.Sprintf("%s?date=%s&aggregate=max", baseURL, date)
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		response := readProblem(t, w)
+		assert.Equal(t, "anthropometric.invalid_aggregate", response.Code)
+	})
+}
+
+func TestUserBodyMeasurements(t *testing.T) {
+	userId := testUser.ID
+	baseURL := fmt.Sprintf("/users/%s/anthropometrics/", userId)
+	date := time.Now().Format("2006-01-02")
+
+	putFixedData := func(t *testing.T, payload string) {
+		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%s/fixedData/", userId), bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+	}
+
+	postEntry := func(t *testing.T, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, baseURL, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	t.Run("POST /users/:userId/anthropometrics - Derive the indicators of the circumferences", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		putFixedData(t, `{"height": 180, "birthday": "1990-01-01", "sex": "male"}`)
+
+		w := postEntry(t, `{
+			"weight": 80,
+			"circumferences": {"waist": 85, "hip": 95, "neck": 38},
+			"skinfolds": {"triceps": 12.5}
+		}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		var response struct {
+			Data model.AnthropometricEntry `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		waist, hip, neck, triceps := float32(85), float32(95), float32(38), float32(12.5)
+		assert.Equal(t, &model.Circumferences{Waist: &waist, Hip: &hip, Neck: &neck}, response.Data.Circumferences)
+		assert.Equal(t, &model.Skinfolds{Triceps: &triceps}, response.Data.Skinfolds)
+
+		// Mifflin-St Jeor: 10 * 80 + 6.25 * 180 - 5 * age + 5
+		age := time.Now().UTC().Year() - 1990
+		waistToHip, waistToHeight, bodyFat, bmr := float32(0.895), float32(0.472), float32(16.11), float32(1930-5*age)
+		expected := &model.BodyIndicators{
+			WaistToHip:    &waistToHip,
+			WaistToHeight: &waistToHeight,
+			NavyBodyFat:   &bodyFat,
+			BMR:           &bmr,
+		}
+		assert.Equal(t, expected, response.Data.Indicators)
+	})
+
+	t.Run("GET /users/:userId/anthropometrics - Include the measurements in the day and the range", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		postEntry(t, `{"weight": 80, "circumferences": {"waist": 84, "hip": 96}}`)
+		postEntry(t, `{"weight": 81, "circumferences": {"waist": 86}}`)
+
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s?date=%s&aggregate=mean", baseURL, date), nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var day struct {
+			Data model.DailyAnthropometricData `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &day)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		assert.Equal(t, float32(85), *day.Data.Circumferences.Waist)
+		assert.Equal(t, float32(96), *day.Data.Circumferences.Hip)
+		// Without height only the waist-to-hip ratio can be derived
+		assert.Nil(t, day.Data.Indicators.WaistToHeight)
+		assert.Equal(t, float32(0.885), *day.Data.Indicators.WaistToHip)
+
+		req, _ = http.NewRequest(http.MethodGet, baseURL+"?sort=asc", nil)
+		req.Header.Add("Authorization", bearerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var list struct {
+			Data []model.AnthropometricEntry `json:"data"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &list)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		assert.Len(t, list.Data, 2)
+		assert.Equal(t, float32(84), *list.Data[0].Circumferences.Waist)
+		assert.Nil(t, list.Data[1].Circumferences.Hip)
+	})
+
+	t.Run("PATCH /users/:userId/anthropometrics/:date - Clear the skinfolds", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		postEntry(t, `{"weight": 80, "circumferences": {"waist": 84}, "skinfolds": {"triceps": 12}}`)
+
+		url := fmt.Sprintf("%s%s", baseURL, date)
+		req, _ := http.NewRequest(http.MethodPatch, url, bytes.NewBufferString(`{"skinfolds": null}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var response struct {
+			Data model.AnthropometricEntry `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		assert.Nil(t, response.Data.Skinfolds)
+		assert.Equal(t, float32(84), *response.Data.Circumferences.Waist)
+	})
+
+	t.Run("GET /users/:userId/anthropometrics - Use the height valid at each measurement", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		putFixedData(t, `{"height": 170, "birthday": "1990-01-01"}`)
+		postEntry(t, `{"weight": 80, "circumferences": {"waist": 85}}`)
+
+		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%s/fixedData/", userId), bytes.NewBufferString(`{"height": 180, "birthday": "1990-01-01"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		postEntry(t, `{"weight": 80, "circumferences": {"waist": 90}}`)
+
+		req, _ = http.NewRequest(http.MethodGet, baseURL+"?sort=asc", nil)
+		req.Header.Add("Authorization", bearerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var list struct {
+			Data []model.AnthropometricEntry `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &list)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		assert.Len(t, list.Data, 2)
+		assert.Equal(t, float32(0.5), *list.Data[0].Indicators.WaistToHeight)
+		assert.Equal(t, float32(0.5), *list.Data[1].Indicators.WaistToHeight)
+
+		req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/users/%s/fixedData/heights/", userId), nil)
+		req.Header.Add("Authorization", bearerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var history struct {
+			Data []model.HeightRecord `json:"data"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &history)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		assert.Len(t, history.Data, 2)
+		assert.Equal(t, uint(170), history.Data[0].Height)
+		assert.Equal(t, uint(180), history.Data[1].Height)
+	})
+
+	t.Run("GET /users/:userId/anthropometrics - Keep the previous height of users without height history", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		putFixedData(t, `{"height": 170, "birthday": "1990-01-01"}`)
+		test.ForgetHeightHistory(userId)
+		postEntry(t, `{"weight": 80, "circumferences": {"waist": 85}}`)
+
+		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%s/fixedData/", userId), bytes.NewBufferString(`{"height": 180, "birthday": "1990-01-01"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		req, _ = http.NewRequest(http.MethodGet, baseURL, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var list struct {
+			Data []model.AnthropometricEntry `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &list)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		assert.Len(t, list.Data, 1)
+		assert.Equal(t, float32(0.5), *list.Data[0].Indicators.WaistToHeight, "the measurement should use the height it was taken with")
+
+		req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/users/%s/fixedData/heights/", userId), nil)
+		req.Header.Add("Authorization", bearerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var history struct {
+			Data []model.HeightRecord `json:"data"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &history)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		assert.Len(t, history.Data, 2)
+		assert.Equal(t, uint(170), history.Data[0].Height)
+		assert.Equal(t, uint(180), history.Data[1].Height)
+	})
+
+	t.Run("POST /users/:userId/anthropometrics - Negative circumference should raise Validation Error", func(t *testing.T) {
+		w := postEntry(t, `{"weight": 80, "circumferences": {"waist": -1}}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		response := readProblem(t, w)
+		assert.Equal(t, []model.FieldError{{
+			Pointer: "/circumferences/waist",
+			Rule:    "gt",
+			Message: "must be greater than 0",
+		}}, response.Errors)
 	})
 }
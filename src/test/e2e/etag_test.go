@@ -0,0 +1,229 @@
+package e2e_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/repository"
+	"github.com/NutriPocket/ProgressService/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	userId := testUser.ID
+
+	send := func(t *testing.T, method string, url string, headers map[string]string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	exercisesURL := fmt.Sprintf("/users/%s/exercises/", userId)
+	exercise := fmt.Sprintf(`{"userId": "%s", "exerciseName": "Running", "caloriesBurned": 300}`, userId)
+
+	createExercise := func(t *testing.T) string {
+		w := send(t, http.MethodPost, exercisesURL, nil, exercise)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		return fmt.Sprintf("%s%d", exercisesURL, unmarshallExerciseData(t, w.Body.Bytes()).ID)
+	}
+
+	t.Run("GET an exercise returns its ETag and 304 if it didn't change", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		url := createExercise(t)
+
+		w := send(t, http.MethodGet, url, nil, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag, "the response should have an ETag")
+
+		w = send(t, http.MethodGet, url, map[string]string{"If-None-Match": etag}, "")
+		assert.Equal(t, http.StatusNotModified, w.Code, "Status code should be 304")
+		assert.Empty(t, w.Body.String(), "a 304 response has no body")
+
+		w = send(t, http.MethodGet, url, map[string]string{"If-None-Match": `"stale"`}, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+	})
+
+	t.Run("PUT and DELETE an exercise with a stale If-Match fail", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		url := createExercise(t)
+		etag := send(t, http.MethodGet, url, nil, "").Header().Get("ETag")
+
+		w := send(t, http.MethodPut, url, map[string]string{"If-Match": etag}, fmt.Sprintf(`{"userId": "%s", "exerciseName": "Swimming", "caloriesBurned": 200}`, userId))
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		w = send(t, http.MethodPut, url, map[string]string{"If-Match": etag}, fmt.Sprintf(`{"userId": "%s", "exerciseName": "Cycling", "caloriesBurned": 100}`, userId))
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code, "Status code should be 412")
+		assert.Equal(t, "request.precondition_failed", readProblem(t, w).Code)
+
+		w = send(t, http.MethodDelete, url, map[string]string{"If-Match": etag}, "")
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code, "Status code should be 412")
+
+		etag = send(t, http.MethodGet, url, nil, "").Header().Get("ETag")
+		w = send(t, http.MethodDelete, url, map[string]string{"If-Match": etag}, "")
+		assert.Equal(t, http.StatusNoContent, w.Code, "Status code should be 204")
+	})
+
+	t.Run("PUT fixed data only succeeds if it didn't change since it was read", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		url := fmt.Sprintf("/users/%s/fixedData/", userId)
+
+		w := send(t, http.MethodPut, url, map[string]string{"If-Match": "*"}, `{"height": 180, "birthday": "1990-01-01", "sex": "male"}`)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code, "If-Match * should fail while there is no fixed data")
+
+		w = send(t, http.MethodPut, url, nil, `{"height": 180, "birthday": "1990-01-01", "sex": "male"}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		w = send(t, http.MethodGet, url, nil, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag, "the response should have an ETag")
+
+		w = send(t, http.MethodGet, url, map[string]string{"If-None-Match": etag}, "")
+		assert.Equal(t, http.StatusNotModified, w.Code, "Status code should be 304")
+
+		w = send(t, http.MethodPut, url, map[string]string{"If-Match": etag}, `{"height": 181, "birthday": "1990-01-01", "sex": "male"}`)
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		w = send(t, http.MethodPut, url, map[string]string{"If-Match": etag}, `{"height": 182, "birthday": "1990-01-01", "sex": "male"}`)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code, "Status code should be 412")
+	})
+
+	t.Run("PUT an objective with If-Match * fails if there is none", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		url := fmt.Sprintf("/users/%s/objectives/", userId)
+
+		body, _ := json.Marshal(model.ObjectiveData{
+			Type: model.ObjectiveBodyComposition,
+			AnthropometricData: model.AnthropometricData{
+				Weight:     70.0,
+				MuscleMass: floatPtr(30.0),
+				FatMass:    floatPtr(20.0),
+			},
+			Deadline: "2099-12-31",
+		})
+
+		w := send(t, http.MethodPut, url, map[string]string{"If-Match": "*"}, string(body))
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code, "Status code should be 412")
+		assert.Equal(t, "request.precondition_failed", readProblem(t, w).Code)
+	})
+
+	t.Run("GET the routines returns 304 while they don't change", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		url := fmt.Sprintf("/users/%s/routines/", userId)
+
+		w := send(t, http.MethodPost, url, nil, `{"name": "Gym", "day": "Monday", "start_hour": 8, "end_hour": 10}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		etag := send(t, http.MethodGet, url, nil, "").Header().Get("ETag")
+		assert.NotEmpty(t, etag, "the response should have an ETag")
+
+		w = send(t, http.MethodGet, url, map[string]string{"If-None-Match": etag}, "")
+		assert.Equal(t, http.StatusNotModified, w.Code, "Status code should be 304")
+
+		w = send(t, http.MethodPost, url, nil, `{"name": "Run", "day": "Tuesday", "start_hour": 8, "end_hour": 10}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		w = send(t, http.MethodGet, url, map[string]string{"If-None-Match": etag}, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200 after a routine is added")
+	})
+	t.Run("DELETE a routine with a stale If-Match fails", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		url := fmt.Sprintf("/users/%s/routines/", userId)
+		monday := `{"day": "Monday", "start_hour": 8, "end_hour": 10}`
+
+		w := send(t, http.MethodPost, url, nil, `{"name": "Gym", "day": "Monday", "start_hour": 8, "end_hour": 10}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		etag := send(t, http.MethodGet, url, nil, "").Header().Get("ETag")
+
+		w = send(t, http.MethodPost, url, nil, `{"name": "Run", "day": "Tuesday", "start_hour": 8, "end_hour": 10}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		w = send(t, http.MethodDelete, url, map[string]string{"If-Match": etag}, monday)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code, "Status code should be 412")
+		assert.Equal(t, "request.precondition_failed", readProblem(t, w).Code)
+
+		etag = send(t, http.MethodGet, url, nil, "").Header().Get("ETag")
+		w = send(t, http.MethodDelete, url, map[string]string{"If-Match": etag}, monday)
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+	})
+	t.Run("A write conditioned on a version that changed since it was checked fails", func(t *testing.T) {
+		defer test.ClearAllData()
+		ctx := context.Background()
+
+		exercises, err := repository.NewExerciseRepository(nil)
+		assert.NoError(t, err)
+
+		url := createExercise(t)
+		var exercise model.ExerciseData
+		id := unmarshallExerciseData(t, send(t, http.MethodGet, url, nil, "").Body.Bytes()).ID
+		assert.NoError(t, exercises.GetExerciseById(ctx, id, &exercise))
+		checked := exercise.Version
+
+		w := send(t, http.MethodPut, url, nil, fmt.Sprintf(`{"userId": "%s", "exerciseName": "Swimming", "caloriesBurned": 200}`, userId))
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		_, err = exercises.UpdateExercise(ctx, id, &exercise.ExerciseDTO, &checked)
+		assert.IsType(t, &model.PreconditionFailedError{}, err)
+		assert.IsType(t, &model.PreconditionFailedError{}, exercises.DeleteExercise(ctx, id, &checked))
+
+		fixedData, err := repository.NewFixedDataRepository(nil)
+		assert.NoError(t, err)
+
+		w = send(t, http.MethodPut, fmt.Sprintf("/users/%s/fixedData/", userId), nil, `{"height": 180, "birthday": "1990-01-01", "sex": "male"}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		var base model.BaseFixedUserData
+		assert.NoError(t, fixedData.GetBaseFixedUserData(ctx, userId, &base))
+		checked = base.Version
+
+		_, err = fixedData.ReplaceData(ctx, &base, &checked)
+		assert.NoError(t, err)
+
+		_, err = fixedData.ReplaceData(ctx, &base, &checked)
+		assert.IsType(t, &model.PreconditionFailedError{}, err)
+
+		objectives, err := repository.NewObjectiveRepository(nil)
+		assert.NoError(t, err)
+
+		body, _ := json.Marshal(model.ObjectiveData{
+			Type:               model.ObjectiveBodyComposition,
+			AnthropometricData: model.AnthropometricData{Weight: 70.0},
+			Deadline:           "2099-12-31",
+		})
+		w = send(t, http.MethodPut, fmt.Sprintf("/users/%s/objectives/", userId), nil, string(body))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		var objective model.ObjectiveData
+		assert.NoError(t, objectives.GetObjectiveByUserId(ctx, userId, &objective))
+		checked = objective.Version
+
+		_, err = objectives.ReplaceObjective(ctx, &objective, nil, &checked)
+		assert.NoError(t, err)
+
+		_, err = objectives.ReplaceObjective(ctx, &objective, nil, &checked)
+		assert.IsType(t, &model.PreconditionFailedError{}, err)
+	})
+}
@@ -95,9 +95,7 @@ func TestCreateExercise(t *testing.T) {
 
         assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
+        response := readProblem(t, w)
         assert.Equal(t, "Invalid exercise data", response.Title)
     })
 
@@ -120,9 +118,7 @@ func TestCreateExercise(t *testing.T) {
 
         assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
+        response := readProblem(t, w)
         assert.Equal(t, "Invalid exercise data", response.Title)
     })
 
@@ -148,13 +144,12 @@ func TestCreateExercise(t *testing.T) {
             Title:    "Unauthorized user",
             Detail:   `The user isn't authorized because no Authorization header is provided`,
             Status:   http.StatusUnauthorized,
-            Type:     "about:blank",
+            Type:     model.ProblemTypeAuthentication,
+            Code:     "auth.missing_header",
             Instance: baseURL,
         }
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
+        response := readProblem(t, w)
         assert.Equal(t, expected, response)
     })
 }
@@ -281,10 +276,8 @@ func TestGetExercises(t *testing.T) {
 
         assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
-        assert.Equal(t, "Invalid date format", response.Title)
+        response := readProblem(t, w)
+        assert.Equal(t, "Invalid date", response.Title)
     })
 
     t.Run("GET /users/:userId/exercises/ - Unauthorized", func(t *testing.T) {
@@ -301,13 +294,12 @@ func TestGetExercises(t *testing.T) {
             Title:    "Unauthorized user",
             Detail:   `The user isn't authorized because no Authorization header is provided`,
             Status:   http.StatusUnauthorized,
-            Type:     "about:blank",
+            Type:     model.ProblemTypeAuthentication,
+            Code:     "auth.missing_header",
             Instance: baseURL,
         }
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
+        response := readProblem(t, w)
         assert.Equal(t, expected, response)
     })
 }
@@ -385,9 +377,7 @@ func TestUpdateExercise(t *testing.T) {
 
         assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
+        response := readProblem(t, w)
         assert.Equal(t, "Exercise not found", response.Title)
     })
 
@@ -431,9 +421,7 @@ func TestUpdateExercise(t *testing.T) {
 
         assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
+        response := readProblem(t, w)
         assert.Equal(t, "Invalid exercise data", response.Title)
     })
 
@@ -453,13 +441,12 @@ func TestUpdateExercise(t *testing.T) {
             Title:    "Unauthorized user",
             Detail:   `The user isn't authorized because no Authorization header is provided`,
             Status:   http.StatusUnauthorized,
-            Type:     "about:blank",
+            Type:     model.ProblemTypeAuthentication,
+            Code:     "auth.missing_header",
             Instance: updateURL,
         }
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
+        response := readProblem(t, w)
         assert.Equal(t, expected, response)
     })
 }
@@ -528,9 +515,7 @@ func TestDeleteExercise(t *testing.T) {
 
         assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
+        response := readProblem(t, w)
         assert.Equal(t, "Exercise not found", response.Title)
     })
 
@@ -546,9 +531,7 @@ func TestDeleteExercise(t *testing.T) {
 
         assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
+        response := readProblem(t, w)
         assert.Equal(t, "Invalid exercise ID", response.Title)
     })
 
@@ -567,13 +550,12 @@ func TestDeleteExercise(t *testing.T) {
             Title:    "Unauthorized user",
             Detail:   `The user isn't authorized because no Authorization header is provided`,
             Status:   http.StatusUnauthorized,
-            Type:     "about:blank",
+            Type:     model.ProblemTypeAuthentication,
+            Code:     "auth.missing_header",
             Instance: deleteURL,
         }
 
-        var response model.ErrorRfc9457
-        err := json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, "Response should be valid JSON")
+        response := readProblem(t, w)
         assert.Equal(t, expected, response)
     })
 }
\ No newline at end of file
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/NutriPocket/ProgressService/test"
@@ -127,13 +128,12 @@ func TestPutUserFixedData(t *testing.T) {
 			Title:    "Unauthorized user",
 			Detail:   `The user isn't authorized because no Authorization header is provided`,
 			Status:   http.StatusUnauthorized,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeAuthentication,
+			Code:     "auth.missing_header",
 			Instance: baseURL,
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 }
@@ -210,6 +210,88 @@ func TestGetUserFixedData(t *testing.T) {
 		assert.Equal(t, payload, actual)
 	})
 
+	t.Run("GET /users/:userId/fixedData - User without fixed data should raise Not Found Error", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, baseURL, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		expected := model.ErrorRfc9457{
+			Title:    "Fixed data not found",
+			Detail:   "No fixed data found for user " + userId,
+			Status:   http.StatusNotFound,
+			Type:     model.ProblemTypeNotFound,
+			Code:     "fixed_data.not_found",
+			Instance: baseURL,
+		}
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
+
+		response := readProblem(t, w)
+		assert.Equal(t, expected, response)
+	})
+
+	t.Run("GET /users/:userId/fixedData - Calculate the age with the calendar of the user's time zone", func(t *testing.T) {
+		timeZone := "Pacific/Kiritimati"
+		loc, err := time.LoadLocation(timeZone)
+		assert.NoError(t, err)
+		today := time.Now().In(loc)
+
+		cases := []struct {
+			birthday time.Time
+			age      uint
+		}{
+			{birthday: today.AddDate(-30, 0, 0), age: 30},
+			{birthday: today.AddDate(-30, 0, 1), age: 29},
+		}
+
+		for _, tc := range cases {
+			payload := model.BaseFixedUserData{
+				Height:   180,
+				Birthday: tc.birthday.Format(time.DateOnly),
+				TimeZone: &timeZone,
+			}
+
+			body, _ := json.Marshal(payload)
+			req, _ := http.NewRequest(http.MethodPut, baseURL, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Add("Authorization", bearerToken)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			req, _ = http.NewRequest(http.MethodGet, baseURL, nil)
+			req.Header.Add("Authorization", bearerToken)
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+			actual := unmarshallFixedUserData(t, w.Body.Bytes())
+			assert.Equal(t, tc.age, actual.Age, "Birthday %s", payload.Birthday)
+			assert.Equal(t, &timeZone, actual.TimeZone)
+		}
+
+		test.ClearAllData()
+	})
+
+	t.Run("PUT /users/:userId/fixedData - Unknown time zone should raise Validation Error", func(t *testing.T) {
+		payload := `{"height": 180, "birthday": "1990-01-01", "time_zone": "Mars/Olympus_Mons"}`
+		req, _ := http.NewRequest(http.MethodPut, baseURL, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		response := readProblem(t, w)
+		assert.Equal(t, []model.FieldError{{
+			Pointer: "/time_zone",
+			Rule:    "timezone",
+			Message: "must be an IANA time zone, e.g. America/Argentina/Buenos_Aires",
+		}}, response.Errors)
+	})
+
 	t.Run("GET /users/:userId/fixedData - No token should raise Authentication Error", func(t *testing.T) {
 		req, _ := http.NewRequest(http.MethodGet, baseURL, nil)
 		w := httptest.NewRecorder()
@@ -221,13 +303,12 @@ func TestGetUserFixedData(t *testing.T) {
 			Title:    "Unauthorized user",
 			Detail:   `The user isn't authorized because no Authorization header is provided`,
 			Status:   http.StatusUnauthorized,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeAuthentication,
+			Code:     "auth.missing_header",
 			Instance: baseURL,
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 }
@@ -0,0 +1,49 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func unmarshallHealthReport(t *testing.T, body []byte) model.HealthReport {
+	var actual model.HealthReport
+	err := json.Unmarshal(body, &actual)
+	assert.NoError(t, err, "Response should be valid JSON")
+
+	return actual
+}
+
+func TestHealth(t *testing.T) {
+	t.Run("GET /healthz - Liveness without token", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		actual := unmarshallHealthReport(t, w.Body.Bytes())
+		assert.Equal(t, model.HealthStatusUp, actual.Status)
+	})
+
+	t.Run("GET /readyz - Readiness without token reports every dependency", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		actual := unmarshallHealthReport(t, w.Body.Bytes())
+		assert.Equal(t, model.HealthStatusUp, actual.Status)
+
+		for _, dependency := range []string{"config", "database", "migrations"} {
+			check, ok := actual.Checks[dependency]
+			assert.True(t, ok, "Readiness should report the %s dependency", dependency)
+			assert.Equal(t, model.HealthStatusUp, check.Status, "%s should be up", dependency)
+		}
+	})
+}
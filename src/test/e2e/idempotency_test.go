@@ -0,0 +1,153 @@
+package e2e_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	userId := testUser.ID
+
+	send := func(t *testing.T, method string, url string, key string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	exercisesURL := fmt.Sprintf("/users/%s/exercises/", userId)
+	exercise := fmt.Sprintf(`{"userId": "%s", "exerciseName": "Running", "caloriesBurned": 300}`, userId)
+
+	countExercises := func(t *testing.T) int {
+		w := send(t, http.MethodGet, exercisesURL, "", "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var response struct {
+			Data model.AllExercisesInDay `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		return len(response.Data.Exercises)
+	}
+
+	t.Run("A retried exercise is only created once", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		first := send(t, http.MethodPost, exercisesURL, "exercise-1", exercise)
+		assert.Equal(t, http.StatusCreated, first.Code, "Status code should be 201")
+
+		retry := send(t, http.MethodPost, exercisesURL, "exercise-1", exercise)
+		assert.Equal(t, http.StatusCreated, retry.Code, "Status code should be 201")
+		assert.Equal(t, first.Body.String(), retry.Body.String(), "the retry should replay the first response")
+		assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+
+		assert.Equal(t, 1, countExercises(t))
+
+		w := send(t, http.MethodPost, exercisesURL, "exercise-2", exercise)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+		assert.Equal(t, 2, countExercises(t), "another key should create another exercise")
+	})
+
+	t.Run("A key reused with a different body is rejected", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPost, exercisesURL, "exercise-1", exercise)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		w = send(t, http.MethodPost, exercisesURL, "exercise-1", fmt.Sprintf(`{"userId": "%s", "exerciseName": "Swimming", "caloriesBurned": 300}`, userId))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Status code should be 422")
+		assert.Equal(t, "idempotency.key_reused", readProblem(t, w).Code)
+
+		assert.Equal(t, 1, countExercises(t))
+	})
+
+	t.Run("A retried routine replays its creation instead of conflicting", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		routinesURL := fmt.Sprintf("/users/%s/routines/", userId)
+		routine := `{"name": "Gym", "day": "Monday", "start_hour": 8, "end_hour": 10}`
+
+		w := send(t, http.MethodPost, routinesURL, "routine-1", routine)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		w = send(t, http.MethodPost, routinesURL, "routine-1", routine)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		w = send(t, http.MethodPost, routinesURL, "", routine)
+		assert.Equal(t, http.StatusConflict, w.Code, "Status code should be 409 without a key")
+	})
+
+	t.Run("A retried measurement is only stored once", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		anthropometricsURL := fmt.Sprintf("/users/%s/anthropometrics/", userId)
+		entry := `{"weight": 70, "context": "fasted"}`
+
+		first := send(t, http.MethodPost, anthropometricsURL, "entry-1", entry)
+		assert.Equal(t, http.StatusCreated, first.Code, "Status code should be 201")
+
+		retry := send(t, http.MethodPost, anthropometricsURL, "entry-1", entry)
+		assert.Equal(t, http.StatusCreated, retry.Code, "Status code should be 201")
+		assert.Equal(t, first.Body.String(), retry.Body.String(), "the retry should replay the first response")
+		assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+
+		w := send(t, http.MethodGet, fmt.Sprintf("%s?date=%s", anthropometricsURL, time.Now().Format("2006-01-02")), "", "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var response struct {
+			Data model.DailyAnthropometricData `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Data.Entries, 1)
+	})
+
+	t.Run("A retried reminder rule is only created once", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		remindersURL := fmt.Sprintf("/users/%s/reminders/", userId)
+		reminder := `{"type": "missed_weigh_in", "threshold": 3}`
+
+		first := send(t, http.MethodPost, remindersURL, "reminder-1", reminder)
+		assert.Equal(t, http.StatusCreated, first.Code, "Status code should be 201")
+
+		retry := send(t, http.MethodPost, remindersURL, "reminder-1", reminder)
+		assert.Equal(t, http.StatusCreated, retry.Code, "Status code should be 201")
+		assert.Equal(t, first.Body.String(), retry.Body.String(), "the retry should replay the first response")
+		assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+
+		w := send(t, http.MethodGet, remindersURL, "", "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var response struct {
+			Data []model.Reminder `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 1)
+	})
+
+	t.Run("A failed request can be retried with the same key", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPost, exercisesURL, "exercise-1", `{"exerciseName": "Running"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		w = send(t, http.MethodPost, exercisesURL, "exercise-1", `{"exerciseName": "Running"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "the failed request should run again")
+	})
+}
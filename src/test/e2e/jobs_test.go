@@ -0,0 +1,298 @@
+package e2e_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/jobs"
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/repository"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/NutriPocket/ProgressService/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// enqueueJob enqueues a one-off job with s and returns whether it was enqueued
+func enqueueJob(t *testing.T, s service.IJobService, name string, payload any, runAt time.Time, key *string) bool {
+	t.Helper()
+
+	enqueued, err := s.Enqueue(context.Background(), name, payload, runAt, key)
+	assert.NoError(t, err)
+
+	return enqueued
+}
+
+func TestJobs(t *testing.T) {
+	ctx := context.Background()
+
+	var received []string
+	jobs.Register("test.succeed", func(ctx context.Context, payload json.RawMessage) error {
+		received = append(received, string(payload))
+		return nil
+	})
+	jobs.Register("test.fail", func(ctx context.Context, payload json.RawMessage) error {
+		return errors.New("the job failed")
+	})
+	jobs.Register("test.panic", func(ctx context.Context, payload json.RawMessage) error {
+		panic("the job panicked")
+	})
+
+	newService := func(t *testing.T) *service.JobService {
+		s, err := service.NewJobService(nil)
+		assert.NoError(t, err)
+
+		return s
+	}
+
+	getJobs := func(t *testing.T) []model.Job {
+		r, err := repository.NewJobRepository(nil)
+		assert.NoError(t, err)
+
+		page, err := r.GetJobs(ctx, model.PageParams{Limit: model.MaxPageLimit, Sort: model.SortAsc})
+		assert.NoError(t, err)
+
+		return page.Items
+	}
+
+	getRuns := func(t *testing.T, jobId uint64) []model.JobRun {
+		page, err := newService(t).GetJobRuns(ctx, jobId, model.PageParams{Limit: model.MaxPageLimit, Sort: model.SortAsc})
+		assert.NoError(t, err)
+
+		return page.Items
+	}
+
+	t.Run("A one-off job runs once it's due", func(t *testing.T) {
+		defer test.ClearAllData()
+		received = nil
+		s := newService(t)
+
+		enqueueJob(t, s, "test.succeed", map[string]string{"user_id": "1"}, time.Now().Add(time.Hour), nil)
+		ran, err := s.RunNext(ctx, "worker")
+		assert.NoError(t, err)
+		assert.False(t, ran, "a job that isn't due shouldn't run")
+
+		enqueueJob(t, s, "test.succeed", map[string]string{"user_id": "2"}, time.Now(), nil)
+		ran, err = s.RunNext(ctx, "worker")
+		assert.NoError(t, err)
+		assert.True(t, ran)
+		assert.Equal(t, []string{`{"user_id":"2"}`}, received)
+
+		stored := getJobs(t)
+		assert.Len(t, stored, 2)
+		assert.Equal(t, model.JobPending, stored[0].Status)
+		assert.Equal(t, model.JobSucceeded, stored[1].Status)
+		assert.Nil(t, stored[1].LockedBy)
+
+		runs := getRuns(t, stored[1].ID)
+		assert.Len(t, runs, 1)
+		assert.Equal(t, model.JobSucceeded, runs[0].Status)
+		assert.Equal(t, "worker", runs[0].Worker)
+		assert.NotNil(t, runs[0].FinishedAt)
+	})
+
+	t.Run("A job with a key is only enqueued once", func(t *testing.T) {
+		defer test.ClearAllData()
+		s := newService(t)
+
+		key := "reminder-1"
+		assert.True(t, enqueueJob(t, s, "test.succeed", nil, time.Now(), &key))
+		assert.False(t, enqueueJob(t, s, "test.succeed", nil, time.Now(), &key), "a job with the same key shouldn't be enqueued again")
+
+		assert.Len(t, getJobs(t), 1)
+	})
+
+	t.Run("A failed job is retried until it runs out of attempts", func(t *testing.T) {
+		defer test.ClearAllData()
+		t.Setenv("JOB_MAX_ATTEMPTS", "2")
+		t.Setenv("JOB_RETRY_BACKOFF", "50ms")
+		s := newService(t)
+
+		enqueueJob(t, s, "test.fail", nil, time.Now(), nil)
+
+		ran, err := s.RunNext(ctx, "worker")
+		assert.NoError(t, err)
+		assert.True(t, ran)
+
+		stored := getJobs(t)[0]
+		assert.Equal(t, model.JobPending, stored.Status)
+		assert.Equal(t, uint(1), stored.Attempts)
+		assert.Equal(t, "the job failed", *stored.LastError)
+
+		ran, _ = s.RunNext(ctx, "worker")
+		assert.False(t, ran, "the retry should wait for the backoff")
+
+		time.Sleep(100 * time.Millisecond)
+		ran, _ = s.RunNext(ctx, "worker")
+		assert.True(t, ran)
+
+		stored = getJobs(t)[0]
+		assert.Equal(t, model.JobFailed, stored.Status)
+		assert.Equal(t, uint(2), stored.Attempts)
+
+		runs := getRuns(t, stored.ID)
+		assert.Len(t, runs, 2)
+		assert.Equal(t, uint(2), runs[1].Attempt)
+		assert.Equal(t, model.JobFailed, runs[1].Status)
+
+		ran, _ = s.RunNext(ctx, "worker")
+		assert.False(t, ran, "a failed job shouldn't run again")
+	})
+
+	t.Run("A job that panics fails", func(t *testing.T) {
+		defer test.ClearAllData()
+		t.Setenv("JOB_MAX_ATTEMPTS", "1")
+		s := newService(t)
+
+		enqueueJob(t, s, "test.panic", nil, time.Now(), nil)
+		ran, err := s.RunNext(ctx, "worker")
+		assert.NoError(t, err)
+		assert.True(t, ran)
+
+		stored := getJobs(t)[0]
+		assert.Equal(t, model.JobFailed, stored.Status)
+		assert.Equal(t, "the job panicked: the job panicked", *stored.LastError)
+	})
+
+	t.Run("A recurring job is stored once and runs again on its schedule", func(t *testing.T) {
+		defer test.ClearAllData()
+		received = nil
+		s := newService(t)
+
+		assert.NoError(t, s.Schedule(ctx, "test.succeed", "@every 1s", nil))
+		assert.NoError(t, s.Schedule(ctx, "test.succeed", "@every 1s", nil))
+		assert.Len(t, getJobs(t), 1)
+
+		ran, _ := s.RunNext(ctx, "worker")
+		assert.False(t, ran, "a recurring job should first run on its schedule")
+
+		time.Sleep(1100 * time.Millisecond)
+		ran, _ = s.RunNext(ctx, "worker")
+		assert.True(t, ran)
+
+		stored := getJobs(t)[0]
+		assert.Equal(t, model.JobPending, stored.Status)
+		assert.Equal(t, uint(0), stored.Attempts)
+
+		ran, _ = s.RunNext(ctx, "worker")
+		assert.False(t, ran, "the job should wait for its next run")
+		assert.Len(t, received, 1)
+
+		assert.Error(t, s.Schedule(ctx, "test.succeed", "every minute", nil), "invalid schedules should be rejected")
+	})
+
+	t.Run("A locked job can't be claimed until its lease ends", func(t *testing.T) {
+		defer test.ClearAllData()
+		r, err := repository.NewJobRepository(nil)
+		assert.NoError(t, err)
+
+		enqueueJob(t, newService(t), "test.succeed", nil, time.Now(), nil)
+
+		now := time.Now()
+		job, run, err := r.ClaimJob(ctx, "first", now, time.Minute)
+		assert.NoError(t, err)
+		assert.NotNil(t, job)
+		assert.Equal(t, "first", *job.LockedBy)
+
+		other, _, err := r.ClaimJob(ctx, "second", now, time.Minute)
+		assert.NoError(t, err)
+		assert.Nil(t, other, "a locked job shouldn't be claimed by another worker")
+
+		other, _, err = r.ClaimJob(ctx, "second", now.Add(2*time.Minute), time.Minute)
+		assert.NoError(t, err)
+		assert.NotNil(t, other, "a job whose lease ended should be taken over")
+
+		job.Status, run.Status = model.JobSucceeded, model.JobSucceeded
+		finished, err := r.FinishJob(ctx, job, run, now, time.Now())
+		assert.NoError(t, err)
+		assert.False(t, finished, "a worker whose job was taken over shouldn't record its outcome")
+
+		runs := getRuns(t, job.ID)
+		assert.Len(t, runs, 2)
+		assert.Equal(t, model.JobFailed, runs[0].Status)
+		assert.Equal(t, model.JobRunning, runs[1].Status)
+	})
+}
+
+func TestAdminJobs(t *testing.T) {
+	send := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	enqueue := func(t *testing.T) model.Job {
+		s, err := service.NewJobService(nil)
+		assert.NoError(t, err)
+		enqueueJob(t, s, "test.succeed", nil, time.Now(), nil)
+
+		_, err = s.RunNext(context.Background(), "worker")
+		assert.NoError(t, err)
+
+		page, err := s.GetJobs(context.Background(), model.PageParams{Limit: 1, Sort: model.SortDesc})
+		assert.NoError(t, err)
+
+		return page.Items[0]
+	}
+
+	t.Run("GET /admin/jobs - List the jobs and their runs", func(t *testing.T) {
+		defer test.ClearAllData()
+		t.Setenv("ADMIN_USER_IDS", "0, "+testUser.ID)
+		job := enqueue(t)
+
+		w := send(t, "/admin/jobs/")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var list struct {
+			Data []model.Job `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Len(t, list.Data, 1)
+		assert.Equal(t, "test.succeed", list.Data[0].Name)
+		assert.NotNil(t, list.Data[0].LastRunAt)
+
+		w = send(t, fmt.Sprintf("/admin/jobs/%d", job.ID))
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		w = send(t, fmt.Sprintf("/admin/jobs/%d/runs", job.ID))
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var runs struct {
+			Data []model.JobRun `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &runs))
+		assert.Len(t, runs.Data, 1)
+		assert.Equal(t, model.JobSucceeded, runs.Data[0].Status)
+	})
+
+	t.Run("GET /admin/jobs/:jobId - Unknown and invalid jobs", func(t *testing.T) {
+		t.Setenv("ADMIN_USER_IDS", testUser.ID)
+
+		w := send(t, "/admin/jobs/999")
+		assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
+		assert.Equal(t, "job.not_found", readProblem(t, w).Code)
+
+		w = send(t, "/admin/jobs/999/runs")
+		assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
+
+		w = send(t, "/admin/jobs/abc")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+		assert.Equal(t, "job.invalid_id", readProblem(t, w).Code)
+	})
+
+	t.Run("GET /admin/jobs - Only administrators can list the jobs", func(t *testing.T) {
+		t.Setenv("ADMIN_USER_IDS", "")
+
+		w := send(t, "/admin/jobs/")
+		assert.Equal(t, http.StatusForbidden, w.Code, "Status code should be 403")
+		assert.Equal(t, "auth.not_admin", readProblem(t, w).Code)
+	})
+}
@@ -1,6 +1,8 @@
 package e2e_test
 
 import (
+	"encoding/json"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -8,10 +10,9 @@ import (
 	"github.com/NutriPocket/ProgressService/test"
 	"github.com/NutriPocket/ProgressService/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
 )
 
-var log = logging.MustGetLogger("log")
 var router *gin.Engine
 var bearerToken string
 
@@ -26,9 +27,23 @@ func TestMain(m *testing.M) {
 	router = utils.SetupRouter()
 	bearerToken = test.GetBearerToken(&testUser)
 
-	log.Infof("Running e2e tests with token: %s\n", bearerToken)
-
 	code := m.Run()
 	test.TearDown("e2e")
 	os.Exit(code)
 }
+
+// readProblem decodes the RFC 9457 problem returned in w, asserting its content type and that it
+// carries a trace ID. The trace ID is cleared, so the problem can be compared with the expected one.
+func readProblem(t *testing.T, w *httptest.ResponseRecorder) model.ErrorRfc9457 {
+	t.Helper()
+
+	assert.Equal(t, model.ProblemContentType, w.Header().Get("Content-Type"), "Content-Type should be a problem")
+
+	var response model.ErrorRfc9457
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err, "Response should be valid JSON")
+	assert.NotEmpty(t, response.TraceID, "Problem should have a trace ID")
+
+	response.TraceID = ""
+	return response
+}
@@ -0,0 +1,31 @@
+package e2e_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("GET /metrics - Prometheus metrics without token", func(t *testing.T) {
+		// Make sure at least one request has been recorded
+		{
+			req, _ := http.NewRequest(http.MethodGet, "/healthz", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		body := w.Body.String()
+		assert.Contains(t, body, `progress_http_requests_total{method="GET",route="/healthz",status="200"}`)
+		assert.Contains(t, body, "progress_http_request_duration_seconds_bucket")
+		assert.Contains(t, body, "go_sql_open_connections")
+	})
+}
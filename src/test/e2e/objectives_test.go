@@ -2,14 +2,19 @@ package e2e_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/NutriPocket/ProgressService/events"
 	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/outbox"
+	"github.com/NutriPocket/ProgressService/service"
 	"github.com/NutriPocket/ProgressService/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -37,6 +42,7 @@ func TestPutUserObjective(t *testing.T) {
 		defer test.ClearAllData()
 
 		payload := model.ObjectiveData{
+			Type: model.ObjectiveBodyComposition,
 			AnthropometricData: model.AnthropometricData{
 				UserID:     userId,
 				Weight:     70.0,
@@ -44,6 +50,7 @@ func TestPutUserObjective(t *testing.T) {
 				FatMass:    floatPtr(20.0),
 			},
 			Deadline: "2025-12-31",
+			Status:   model.ObjectiveActive,
 		}
 
 		body, _ := json.Marshal(payload)
@@ -89,13 +96,12 @@ func TestPutUserObjective(t *testing.T) {
 			Title:    "Invalid deadline",
 			Detail:   `The deadline must be in the future`,
 			Status:   http.StatusBadRequest,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeValidation,
+			Code:     "objective.past_deadline",
 			Instance: baseURL,
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 
@@ -122,15 +128,17 @@ func TestPutUserObjective(t *testing.T) {
 
 		expected := model.ErrorRfc9457{
 			Title:    "Invalid user objective data",
-			Detail:   `The user objective data is invalid, Key: 'ObjectiveData.Deadline' Error:Field validation for 'Deadline' failed on the 'required' tag`,
+			Detail:   "One or more fields of the objective are invalid",
 			Status:   http.StatusBadRequest,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeValidation,
+			Code:     "objective.invalid",
 			Instance: baseURL,
+			Errors: []model.FieldError{
+				{Pointer: "/deadline", Rule: "required", Message: "is required"},
+			},
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 
@@ -144,6 +152,7 @@ func TestPutUserObjective(t *testing.T) {
 				Weight: 70.0,
 			},
 			Deadline: "2025-12-31",
+			Status:   model.ObjectiveActive,
 		}
 
 		{
@@ -157,6 +166,7 @@ func TestPutUserObjective(t *testing.T) {
 
 		// Updated data
 		updatedPayload := model.ObjectiveData{
+			Type: model.ObjectiveBodyComposition,
 			AnthropometricData: model.AnthropometricData{
 				UserID:     userId,
 				Weight:     75.0,
@@ -164,6 +174,7 @@ func TestPutUserObjective(t *testing.T) {
 				FatMass:    floatPtr(18.0),
 			},
 			Deadline: "2026-01-01",
+			Status:   model.ObjectiveActive,
 		}
 
 		body, _ := json.Marshal(updatedPayload)
@@ -193,6 +204,7 @@ func TestGetUserObjective(t *testing.T) {
 
 		// Create objective
 		payload := model.ObjectiveData{
+			Type: model.ObjectiveBodyComposition,
 			AnthropometricData: model.AnthropometricData{
 				UserID:     userId,
 				Weight:     70.0,
@@ -200,6 +212,7 @@ func TestGetUserObjective(t *testing.T) {
 				FatMass:    floatPtr(20.0),
 			},
 			Deadline: "2025-12-31",
+			Status:   model.ObjectiveActive,
 		}
 
 		{
@@ -238,15 +251,14 @@ func TestGetUserObjective(t *testing.T) {
 
 		expected := model.ErrorRfc9457{
 			Title:    "Objective data not found",
-			Detail:   "No objective data not found for user " + userId,
+			Detail:   "No objective data found for user " + userId,
 			Status:   http.StatusNotFound,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeNotFound,
+			Code:     "objective.not_found",
 			Instance: baseURL,
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 
@@ -261,15 +273,593 @@ func TestGetUserObjective(t *testing.T) {
 			Title:    "Unauthorized user",
 			Detail:   `The user isn't authorized because no Authorization header is provided`,
 			Status:   http.StatusUnauthorized,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeAuthentication,
+			Code:     "auth.missing_header",
 			Instance: baseURL,
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
+		response := readProblem(t, w)
+		assert.Equal(t, expected, response)
+	})
+}
+
+func TestTypedObjectives(t *testing.T) {
+	userId := testUser.ID
+	baseURL := fmt.Sprintf("/users/%s/objectives/", userId)
+	deadline := time.Now().AddDate(1, 0, 0).Format(time.DateOnly)
+
+	send := func(t *testing.T, method string, url string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	getProgress := func(t *testing.T) *model.ObjectiveProgress {
+		w := send(t, http.MethodGet, baseURL, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		return unmarshallObjectiveData(t, w.Body.Bytes()).Progress
+	}
+
+	exercise := func(t *testing.T, calories float64) {
+		payload := fmt.Sprintf(`{"userId": "%s", "exerciseName": "Running", "caloriesBurned": %v}`, userId, calories)
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/exercises/", userId), payload)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+	}
+
+	t.Run("PUT /users/:userId/objectives - Evaluate a calories objective in the current week", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"type": "calories", "target": 2500, "period": "week", "deadline": "%s"}`, deadline))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		actual := unmarshallObjectiveData(t, w.Body.Bytes())
+		assert.Equal(t, model.ObjectiveCalories, actual.Type)
+		assert.Equal(t, float32(2500), *actual.Target)
+		assert.Equal(t, model.PeriodWeek, *actual.Period)
+		assert.Equal(t, float32(0), actual.Progress.Current)
+
+		exercise(t, 300)
+		exercise(t, 200)
+
+		progress := getProgress(t)
+		assert.Equal(t, float32(500), progress.Current)
+		assert.Equal(t, float32(2500), progress.Target)
+		assert.Equal(t, float32(20), progress.Percentage)
+		assert.False(t, progress.Achieved)
+
+		start, err := time.Parse(time.RFC3339, *progress.PeriodStart)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Monday, start.Weekday())
+	})
+
+	t.Run("PUT /users/:userId/objectives - Evaluate an exercise frequency objective", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"type": "exercise_frequency", "target": 2, "period": "month", "deadline": "%s"}`, deadline))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		exercise(t, 300)
+		exercise(t, 200)
+
+		progress := getProgress(t)
+		assert.Equal(t, float32(2), progress.Current)
+		assert.Equal(t, float32(100), progress.Percentage)
+		assert.True(t, progress.Achieved)
+	})
+
+	t.Run("PUT /users/:userId/objectives - Evaluate a weight logging objective", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"type": "weight_logging", "target": 1, "period": "day", "deadline": "%s"}`, deadline))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+		assert.False(t, unmarshallObjectiveData(t, w.Body.Bytes()).Progress.Achieved)
+
+		send(t, http.MethodPost, fmt.Sprintf("/users/%s/anthropometrics/", userId), `{"weight": 80}`)
+		send(t, http.MethodPost, fmt.Sprintf("/users/%s/anthropometrics/", userId), `{"weight": 79}`)
+
+		progress := getProgress(t)
+		assert.Equal(t, float32(1), progress.Current)
+		assert.True(t, progress.Achieved)
+	})
+
+	t.Run("PUT /users/:userId/objectives - Evaluate a body composition objective from the weight when it was set", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		send(t, http.MethodPost, fmt.Sprintf("/users/%s/anthropometrics/", userId), `{"weight": 80}`)
+
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 70, "deadline": "%s"}`, deadline))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		send(t, http.MethodPost, fmt.Sprintf("/users/%s/anthropometrics/", userId), `{"weight": 75}`)
+
+		progress := getProgress(t)
+		assert.Equal(t, float32(80), *progress.Start)
+		assert.Equal(t, float32(75), progress.Current)
+		assert.Equal(t, float32(70), progress.Target)
+		assert.Equal(t, float32(50), progress.Percentage)
+		assert.False(t, progress.Achieved)
+		assert.Nil(t, progress.PeriodStart)
+	})
+
+	t.Run("PUT /users/:userId/objectives - Replace an objective with one of another type", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 70, "muscle_mass": 30, "deadline": "%s"}`, deadline))
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"type": "calories", "target": 500, "period": "day", "deadline": "%s"}`, deadline))
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		actual := unmarshallObjectiveData(t, w.Body.Bytes())
+		assert.Equal(t, model.ObjectiveCalories, actual.Type)
+		assert.Nil(t, actual.MuscleMass)
+
+		w = send(t, http.MethodGet, fmt.Sprintf("/users/%s/objectives/history/", userId), "")
+
+		var history struct {
+			Data []model.ObjectiveData `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &history)
 		assert.NoError(t, err, "Response should be valid JSON")
+
+		assert.Len(t, history.Data, 2)
+		assert.Equal(t, model.ObjectiveCalories, history.Data[0].Type)
+		assert.Equal(t, model.ObjectiveBodyComposition, history.Data[1].Type)
+		assert.Equal(t, float32(70), history.Data[1].Weight)
+	})
+
+	t.Run("GET /users/:userId/objectives/history?fields=<fields> - Select fields omitted when empty", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"type": "calories", "target": 500, "period": "day", "deadline": "%s"}`, deadline))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		w = send(t, http.MethodGet, fmt.Sprintf("/users/%s/objectives/history/?fields=type,target,period,closed_at", userId), "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var history struct {
+			Data []map[string]any `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &history)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		assert.Equal(t, []map[string]any{
+			{"type": "calories", "target": float64(500), "period": "day", "closed_at": nil},
+		}, history.Data)
+	})
+
+	t.Run("PUT /users/:userId/objectives - Activity objective without target should raise Validation Error", func(t *testing.T) {
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"type": "calories", "deadline": "%s"}`, deadline))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		response := readProblem(t, w)
+		assert.Equal(t, "objective.invalid", response.Code)
+		assert.Equal(t, []model.FieldError{
+			{Pointer: "/target", Rule: "required", Message: "is required"},
+			{Pointer: "/period", Rule: "required", Message: "is required"},
+		}, response.Errors)
+	})
+
+	t.Run("PUT /users/:userId/objectives - Unknown type should raise Validation Error", func(t *testing.T) {
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"type": "sleep", "deadline": "%s"}`, deadline))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		response := readProblem(t, w)
+		assert.Equal(t, []model.FieldError{{
+			Pointer: "/type",
+			Rule:    "oneof",
+			Message: "must be one of: body_composition calories exercise_frequency weight_logging",
+		}}, response.Errors)
+	})
+
+	invalidTargets := []struct {
+		name    string
+		payload string
+		code    string
+		detail  string
+	}{
+		{
+			name:    "Fractional exercise frequency",
+			payload: `{"type": "exercise_frequency", "target": 2.5, "period": "week", "deadline": "%s"}`,
+			code:    "objective.fractional_target",
+			detail:  "The target of exercise_frequency objectives must be a whole number",
+		},
+		{
+			name:    "Unreachable weight logging",
+			payload: `{"type": "weight_logging", "target": 2, "period": "day", "deadline": "%s"}`,
+			code:    "objective.unreachable_target",
+			detail:  "The target must be at most 1 for a day period",
+		},
+	}
+
+	for _, tc := range invalidTargets {
+		t.Run("PUT /users/:userId/objectives - "+tc.name+" should raise Validation Error", func(t *testing.T) {
+			w := send(t, http.MethodPut, baseURL, fmt.Sprintf(tc.payload, deadline))
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+			response := readProblem(t, w)
+			assert.Equal(t, tc.code, response.Code)
+			assert.Equal(t, tc.detail, response.Detail)
+		})
+	}
+}
+
+func TestObjectiveFeasibility(t *testing.T) {
+	userId := testUser.ID
+	baseURL := fmt.Sprintf("/users/%s/objectives/", userId)
+	today := time.Now().UTC()
+	inDays := func(days int) string {
+		return today.AddDate(0, 0, days).Format(time.DateOnly)
+	}
+
+	send := func(t *testing.T, method string, url string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	measure := func(t *testing.T, payload string) {
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/anthropometrics/", userId), payload)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+	}
+
+	t.Run("PUT /users/:userId/objectives - Unsafe weight loss should raise Validation Error", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		measure(t, `{"weight": 80}`)
+
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 60, "deadline": "%s"}`, inDays(14)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		// 20 kg at 1 kg per week
+		expected := model.ErrorRfc9457{
+			Title:    "Unsafe objective",
+			Detail:   "Reaching the objective by the deadline requires an unsafe pace, the earliest safe deadline is " + inDays(140),
+			Status:   http.StatusBadRequest,
+			Type:     model.ProblemTypeValidation,
+			Code:     "objective.unsafe_rate",
+			Instance: baseURL,
+		}
+
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
+
+	t.Run("PUT /users/:userId/objectives - Unsafe muscle gain should raise Validation Error", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		measure(t, `{"weight": 80, "muscle_mass": 35}`)
+
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 80, "muscle_mass": 37, "deadline": "%s"}`, inDays(28)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		// 2 kg at 0.25 kg per week
+		response := readProblem(t, w)
+		assert.Equal(t, "objective.unsafe_rate", response.Code)
+		assert.Contains(t, response.Detail, inDays(56))
+	})
+
+	t.Run("PUT /users/:userId/objectives - Warn about a fast weight loss and suggest a deadline", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		measure(t, `{"weight": 80}`)
+
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 78.2, "deadline": "%s"}`, inDays(14)))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		actual := unmarshallObjectiveData(t, w.Body.Bytes())
+		assert.Equal(t, float32(-0.9), actual.Feasibility.WeeklyRate)
+		assert.Equal(t, []model.Warning{{
+			Code:    "objective.fast_weight_loss",
+			Message: "Losing more than 1% of the body weight per week is faster than recommended",
+		}}, actual.Feasibility.Warnings)
+		// 1.8 kg at 0.8 kg per week
+		assert.Equal(t, inDays(16), *actual.Feasibility.SuggestedDeadline)
+	})
+
+	t.Run("PUT /users/:userId/objectives - Warn about targets below the healthy ranges", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPut, fmt.Sprintf("/users/%s/fixedData/", userId), `{"height": 170, "birthday": "1990-01-01", "sex": "female"}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		measure(t, `{"weight": 60}`)
+
+		req, _ := http.NewRequest(http.MethodPut, baseURL, bytes.NewBufferString(fmt.Sprintf(`{"weight": 52, "fat_mass": 5, "deadline": "%s"}`, inDays(365))))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "es")
+		req.Header.Add("Authorization", bearerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		actual := unmarshallObjectiveData(t, w.Body.Bytes())
+		assert.Equal(t, []model.Warning{
+			{Code: "objective.underweight_target", Message: "El peso objetivo está por debajo de un IMC saludable de 18,5"},
+			{Code: "objective.low_fat_mass", Message: "La masa grasa objetivo está por debajo de la grasa corporal esencial"},
+		}, actual.Feasibility.Warnings)
+		assert.Nil(t, actual.Feasibility.SuggestedDeadline)
+	})
+
+	t.Run("PUT /users/:userId/objectives - Objectives without measurements have no feasibility", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 60, "deadline": "%s"}`, inDays(14)))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+		assert.Nil(t, unmarshallObjectiveData(t, w.Body.Bytes()).Feasibility)
+	})
+}
+
+func TestObjectiveMilestones(t *testing.T) {
+	userId := testUser.ID
+	baseURL := fmt.Sprintf("/users/%s/objectives/", userId)
+	milestonesURL := fmt.Sprintf("/users/%s/objectives/milestones/", userId)
+	today := time.Now().UTC()
+	inDays := func(days int) string {
+		return today.AddDate(0, 0, days).Format(time.DateOnly)
+	}
+
+	send := func(t *testing.T, method string, url string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	measure := func(t *testing.T, weight float32) {
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/anthropometrics/", userId), fmt.Sprintf(`{"weight": %v}`, weight))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+	}
+
+	getMilestones := func(t *testing.T) []model.Milestone {
+		w := send(t, http.MethodGet, milestonesURL, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var response struct {
+			Data []model.Milestone `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, "Failed to unmarshal response body")
+
+		return response.Data
+	}
+
+	t.Run("GET /users/:userId/objectives/milestones - One milestone per week until the deadline", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		measure(t, 80)
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 76, "deadline": "%s"}`, inDays(28)))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		milestones := getMilestones(t)
+		assert.Len(t, milestones, 4)
+
+		for i, milestone := range milestones {
+			assert.Equal(t, uint(i+1), milestone.Sequence)
+			assert.Equal(t, float32(79-i), milestone.Weight)
+			assert.True(t, strings.HasPrefix(milestone.DueDate, inDays(7*(i+1))), "milestone %d should be due at %s, got %s", i+1, inDays(7*(i+1)), milestone.DueDate)
+			assert.Nil(t, milestone.AchievedAt)
+		}
+	})
+
+	t.Run("GET /users/:userId/objectives/milestones - Measurements achieve the milestones they reach", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		measure(t, 80)
+		send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 76, "deadline": "%s"}`, inDays(28)))
+		measure(t, 77.5)
+
+		milestones := getMilestones(t)
+		assert.Len(t, milestones, 4)
+		assert.NotNil(t, milestones[0].AchievedAt)
+		assert.NotNil(t, milestones[1].AchievedAt)
+		assert.Nil(t, milestones[2].AchievedAt)
+		assert.Nil(t, milestones[3].AchievedAt)
+	})
+
+	t.Run("GET /users/:userId/objectives/milestones - Measurements taken before the objective don't achieve them", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/anthropometrics/", userId), `{"weight": 80}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		var response struct {
+			Data model.AnthropometricEntry `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, "Failed to unmarshal response body")
+
+		send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 76, "deadline": "%s"}`, inDays(28)))
+
+		w = send(t, http.MethodPatch, fmt.Sprintf("/users/%s/anthropometrics/%s/%d", userId, inDays(0), response.Data.ID), `{"weight": 77.5}`)
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		for _, milestone := range getMilestones(t) {
+			assert.Nil(t, milestone.AchievedAt)
+		}
+	})
+
+	t.Run("GET /users/:userId/objectives/milestones - Milestones of weight gain objectives", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		measure(t, 60)
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 61, "deadline": "%s"}`, inDays(28)))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+		measure(t, 60.6)
+
+		milestones := getMilestones(t)
+		assert.Len(t, milestones, 4)
+		assert.Equal(t, float32(60.25), milestones[0].Weight)
+		assert.NotNil(t, milestones[0].AchievedAt)
+		assert.NotNil(t, milestones[1].AchievedAt)
+		assert.Nil(t, milestones[2].AchievedAt)
+	})
+
+	t.Run("GET /users/:userId/objectives/milestones - A new objective replaces the milestones", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		measure(t, 80)
+		send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 76, "deadline": "%s"}`, inDays(28)))
+		send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 78, "deadline": "%s"}`, inDays(14)))
+
+		milestones := getMilestones(t)
+		assert.Len(t, milestones, 2)
+		assert.Equal(t, float32(78), milestones[1].Weight)
+	})
+
+	t.Run("GET /users/:userId/objectives/milestones - Activity objectives have no milestones", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		measure(t, 80)
+		send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"type": "calories", "target": 2500, "period": "week", "deadline": "%s"}`, inDays(28)))
+
+		assert.Empty(t, getMilestones(t))
+	})
+}
+
+func TestCloseObjectives(t *testing.T) {
+	userId := testUser.ID
+	baseURL := fmt.Sprintf("/users/%s/objectives/", userId)
+	today := time.Now().UTC()
+	inDays := func(days int) string {
+		return today.AddDate(0, 0, days).Format(time.DateOnly)
+	}
+
+	send := func(t *testing.T, method string, url string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	measure := func(t *testing.T, weight float32) {
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/anthropometrics/", userId), fmt.Sprintf(`{"weight": %v}`, weight))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+	}
+
+	getObjective := func(t *testing.T) model.ObjectiveData {
+		w := send(t, http.MethodGet, baseURL, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		return unmarshallObjectiveData(t, w.Body.Bytes())
+	}
+
+	closeObjectives := func(t *testing.T, now time.Time) int {
+		s, err := service.NewObjectiveService(nil, nil, nil, nil)
+		assert.NoError(t, err)
+
+		closed, err := s.CloseObjectives(context.Background(), now)
+		assert.NoError(t, err)
+
+		// The events of the closed objectives are published once they are relayed from the outbox
+		relay, err := service.NewOutboxService(nil, []outbox.Publisher{&outbox.BusPublisher{}})
+		assert.NoError(t, err)
+
+		_, err = relay.Relay(context.Background())
+		assert.NoError(t, err)
+
+		return closed
+	}
+
+	var published []events.Event
+	collect := func(ctx context.Context, event events.Event) {
+		published = append(published, event)
+	}
+	events.Subscribe(events.ObjectiveAchieved, collect)
+	events.Subscribe(events.ObjectiveExpired, collect)
+
+	t.Run("A body composition objective is achieved when the last weight reaches the target", func(t *testing.T) {
+		defer test.ClearAllData()
+		published = nil
+
+		measure(t, 80)
+		send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 78, "deadline": "%s"}`, inDays(28)))
+		assert.Equal(t, 0, closeObjectives(t, time.Now()))
+		assert.Equal(t, model.ObjectiveActive, getObjective(t).Status)
+
+		measure(t, 77.9)
+		assert.Equal(t, 1, closeObjectives(t, time.Now()))
+
+		actual := getObjective(t)
+		assert.Equal(t, model.ObjectiveAchieved, actual.Status)
+		assert.Equal(t, float32(77.9), *actual.FinalValue)
+		assert.NotNil(t, actual.ClosedAt)
+
+		assert.Len(t, published, 1)
+		assert.Equal(t, events.ObjectiveAchieved, published[0].Type)
+		assert.Equal(t, userId, published[0].UserID)
+
+		var payload model.ObjectiveData
+		assert.NoError(t, json.Unmarshal(published[0].Payload.(json.RawMessage), &payload))
+		assert.Equal(t, model.ObjectiveAchieved, payload.Status)
+
+		assert.Equal(t, 0, closeObjectives(t, time.Now()), "closed objectives shouldn't be closed again")
+		assert.Len(t, published, 1)
+	})
+
+	t.Run("An objective expires when its deadline passes", func(t *testing.T) {
+		defer test.ClearAllData()
+		published = nil
+
+		measure(t, 80)
+		send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 78, "deadline": "%s"}`, inDays(28)))
+		assert.Equal(t, 1, closeObjectives(t, today.AddDate(0, 0, 30)))
+
+		actual := getObjective(t)
+		assert.Equal(t, model.ObjectiveExpired, actual.Status)
+		assert.Equal(t, float32(80), *actual.FinalValue)
+
+		assert.Len(t, published, 1)
+		assert.Equal(t, events.ObjectiveExpired, published[0].Type)
+	})
+
+	t.Run("Activity objectives are only closed when the deadline passes", func(t *testing.T) {
+		defer test.ClearAllData()
+		published = nil
+
+		send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"type": "exercise_frequency", "target": 1, "period": "day", "deadline": "%s"}`, inDays(7)))
+		payload := fmt.Sprintf(`{"userId": "%s", "exerciseName": "Running", "caloriesBurned": 300}`, userId)
+		send(t, http.MethodPost, fmt.Sprintf("/users/%s/exercises/", userId), payload)
+
+		assert.Equal(t, 0, closeObjectives(t, time.Now()))
+		assert.Equal(t, model.ObjectiveActive, getObjective(t).Status)
+
+		assert.Equal(t, 1, closeObjectives(t, today.AddDate(0, 0, 8)))
+
+		actual := getObjective(t)
+		assert.Equal(t, model.ObjectiveExpired, actual.Status)
+		assert.Equal(t, float32(0), *actual.FinalValue)
+	})
+
+	t.Run("A new objective replaces a closed one as active", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		measure(t, 80)
+		send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 78, "deadline": "%s"}`, inDays(28)))
+		closeObjectives(t, today.AddDate(0, 0, 30))
+
+		w := send(t, http.MethodPut, baseURL, fmt.Sprintf(`{"weight": 79, "deadline": "%s"}`, inDays(28)))
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		actual := unmarshallObjectiveData(t, w.Body.Bytes())
+		assert.Equal(t, model.ObjectiveActive, actual.Status)
+		assert.Nil(t, actual.ClosedAt)
+		assert.Nil(t, actual.FinalValue)
+	})
 }
 
 func floatPtr(f float32) *float32 {
@@ -0,0 +1,124 @@
+package e2e_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/events"
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/outbox"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/NutriPocket/ProgressService/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutbox(t *testing.T) {
+	send := func(t *testing.T, method string, url string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// relay publishes the outbox to a publisher that records the events, or fails with fail
+	relay := func(t *testing.T, published *[]events.Event, fail error) (int, error) {
+		s, err := service.NewOutboxService(nil, []outbox.Publisher{
+			outbox.PublisherFunc(func(ctx context.Context, event events.Event) error {
+				if fail != nil {
+					return fail
+				}
+
+				*published = append(*published, event)
+				return nil
+			}),
+		})
+		assert.NoError(t, err)
+
+		return s.Relay(context.Background())
+	}
+
+	createExercise := func(t *testing.T) {
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/exercises/", testUser.ID), fmt.Sprintf(`{"userId": "%s", "exerciseName": "Running", "caloriesBurned": 300}`, testUser.ID))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+	}
+
+	t.Run("The events of the changes are published in the order they were stored", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		createExercise(t)
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/anthropometrics/", testUser.ID), `{"weight": 80}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+		w = send(t, http.MethodPost, fmt.Sprintf("/users/%s/routines/", testUser.ID), `{"name": "Gym", "day": "Monday", "start_hour": 8, "end_hour": 10}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		var published []events.Event
+		relayed, err := relay(t, &published, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, relayed)
+
+		assert.Len(t, published, 3)
+		assert.Equal(t, events.ExerciseCreated, published[0].Type)
+		assert.Equal(t, events.AnthropometricRecorded, published[1].Type)
+		assert.Equal(t, events.RoutineCreated, published[2].Type)
+		assert.Equal(t, testUser.ID, published[0].UserID)
+
+		var exercise model.ExerciseData
+		assert.NoError(t, json.Unmarshal(published[0].Payload.(json.RawMessage), &exercise))
+		assert.Equal(t, "Running", exercise.ExerciseName)
+
+		relayed, err = relay(t, &published, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, relayed, "published events shouldn't be published again")
+	})
+
+	t.Run("An event that fails to be published is published again with the same ID", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		createExercise(t)
+
+		var published []events.Event
+		relayed, err := relay(t, &published, errors.New("the broker is down"))
+		assert.Error(t, err)
+		assert.Equal(t, 0, relayed)
+
+		createExercise(t)
+
+		relayed, err = relay(t, &published, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, relayed)
+		assert.Len(t, published, 2)
+		assert.NotEqual(t, published[0].ID, published[1].ID)
+	})
+
+	t.Run("Published events are pruned after the retention", func(t *testing.T) {
+		defer test.ClearAllData()
+		t.Setenv("OUTBOX_RETENTION", "1h")
+
+		createExercise(t)
+
+		var published []events.Event
+		_, err := relay(t, &published, nil)
+		assert.NoError(t, err)
+
+		s, err := service.NewOutboxService(nil, []outbox.Publisher{})
+		assert.NoError(t, err)
+
+		deleted, err := s.Prune(context.Background(), time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), deleted)
+
+		deleted, err = s.Prune(context.Background(), time.Now().Add(2*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
@@ -0,0 +1,241 @@
+package e2e_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func unmarshallNextCursor(t *testing.T, body []byte) any {
+	var response map[string]any
+	err := json.Unmarshal(body, &response)
+	assert.NoError(t, err, "Response should be valid JSON")
+
+	next, ok := response["next"]
+	assert.True(t, ok, "Response should have a next field")
+
+	return next
+}
+
+func createRoutines(t *testing.T, userId string, startHours ...int) {
+	for _, startHour := range startHours {
+		payload := model.RoutineDTO{
+			UserID: userId,
+			Name:   fmt.Sprintf("Workout at %d", startHour),
+			Schedule: model.Schedule{
+				Day:       "Monday",
+				StartHour: startHour,
+				EndHour:   startHour + 1,
+			},
+		}
+
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/users/%s/routines/", userId), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	userId := testUser.ID
+	baseURL := fmt.Sprintf("/users/%s/routines/", userId)
+
+	t.Run("GET /users/:userId/routines - Return the whole list without a limit", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		startHours := make([]int, 0, 22)
+		for hour := 1; hour <= 22; hour++ {
+			startHours = append(startHours, hour)
+		}
+		createRoutines(t, userId, startHours...)
+
+		req, _ := http.NewRequest(http.MethodGet, baseURL, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+		assert.Nil(t, unmarshallNextCursor(t, w.Body.Bytes()), "The whole list should be a single page")
+		assert.Empty(t, w.Header().Get("Link"))
+
+		var response struct {
+			Data []model.RoutineData `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Data, len(startHours))
+	})
+
+	t.Run("GET /users/:userId/routines?limit=<limit> - Follow the next cursor until the last page", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		createRoutines(t, userId, 8, 10, 12)
+
+		url := baseURL + "?limit=2"
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		routines := unmarshallRoutinesData(t, w.Body.Bytes())
+		assert.Len(t, routines, 2)
+		assert.Equal(t, 8, routines[0].StartHour)
+		assert.Equal(t, 10, routines[1].StartHour)
+
+		next, ok := unmarshallNextCursor(t, w.Body.Bytes()).(string)
+		assert.True(t, ok, "Next should be a cursor")
+		assert.NotEmpty(t, next)
+
+		link := w.Header().Get("Link")
+		assert.Equal(t, fmt.Sprintf(`<%s?cursor=%s&limit=2>; rel="next"`, baseURL, next), link)
+
+		req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("%s?cursor=%s&limit=2", baseURL, next), nil)
+		req.Header.Add("Authorization", bearerToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		routines = unmarshallRoutinesData(t, w.Body.Bytes())
+		assert.Len(t, routines, 1)
+		assert.Equal(t, 12, routines[0].StartHour)
+
+		assert.Nil(t, unmarshallNextCursor(t, w.Body.Bytes()), "Last page shouldn't have a next cursor")
+		assert.Empty(t, w.Header().Get("Link"))
+	})
+
+	t.Run("GET /users/:userId/routines?sort=desc - Sort the list descending", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		createRoutines(t, userId, 8, 10, 12)
+
+		req, _ := http.NewRequest(http.MethodGet, baseURL+"?sort=desc", nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		routines := unmarshallRoutinesData(t, w.Body.Bytes())
+		assert.Len(t, routines, 3)
+		assert.Equal(t, 12, routines[0].StartHour)
+		assert.Equal(t, 8, routines[2].StartHour)
+	})
+
+	t.Run("GET /users/:userId/routines?fields=<fields> - Select the fields of the items", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		createRoutines(t, userId, 8)
+
+		req, _ := http.NewRequest(http.MethodGet, baseURL+"?fields=name,start_hour", nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var response map[string]any
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, "Response should be valid JSON")
+
+		expected := []any{
+			map[string]any{"name": "Workout at 8", "start_hour": float64(8)},
+		}
+		assert.Equal(t, expected, response["data"])
+	})
+
+	invalidCases := []struct {
+		name   string
+		query  string
+		title  string
+		detail string
+		code   string
+	}{
+		{
+			name:   "Invalid limit",
+			query:  "?limit=0",
+			title:  "Invalid limit",
+			detail: "The limit must be a number between 1 and 100",
+			code:   "pagination.invalid_limit",
+		},
+		{
+			name:   "Invalid sort",
+			query:  "?sort=up",
+			title:  "Invalid sort",
+			detail: "The sort must be asc or desc",
+			code:   "pagination.invalid_sort",
+		},
+		{
+			name:   "Invalid cursor",
+			query:  "?cursor=not-a-cursor",
+			title:  "Invalid cursor",
+			detail: "The cursor provided isn't valid, use the next cursor of a previous page",
+			code:   "pagination.invalid_cursor",
+		},
+		{
+			name:   "Cursor of another list",
+			query:  "?cursor=eyJzIjoiZGVzYyIsImsiOlsiMjAyNC0wMS0wMSAwMDowMDowMCJdfQ",
+			title:  "Invalid cursor",
+			detail: "The cursor provided isn't valid, use the next cursor of a previous page",
+			code:   "pagination.invalid_cursor",
+		},
+		{
+			name:   "Invalid fields",
+			query:  "?fields=weight,password",
+			title:  "Invalid fields",
+			detail: "The field password can't be selected, it isn't a field of the listed items",
+			code:   "request.invalid_fields",
+		},
+	}
+
+	for _, tc := range invalidCases {
+		t.Run("GET /users/:userId/anthropometrics - "+tc.name+" should raise Validation Error", func(t *testing.T) {
+			url := fmt.Sprintf("/users/%s/anthropometrics/%s", userId, tc.query)
+			req, _ := http.NewRequest(http.MethodGet, url, nil)
+			req.Header.Add("Authorization", bearerToken)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+			expected := model.ErrorRfc9457{
+				Title:    tc.title,
+				Detail:   tc.detail,
+				Status:   http.StatusBadRequest,
+				Type:     model.ProblemTypeValidation,
+				Code:     tc.code,
+				Instance: url,
+			}
+
+			response := readProblem(t, w)
+			assert.Equal(t, expected, response)
+		})
+	}
+
+	t.Run("GET /users/:userId/objectives/history - List the previous objectives", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/users/%s/objectives/history/", userId), nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var response map[string]any
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, "Response should be valid JSON")
+		assert.Equal(t, []any{}, response["data"])
+		assert.Nil(t, response["next"])
+	})
+}
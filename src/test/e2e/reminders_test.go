@@ -0,0 +1,194 @@
+package e2e_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/repository"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/NutriPocket/ProgressService/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReminders(t *testing.T) {
+	userId := testUser.ID
+	baseURL := fmt.Sprintf("/users/%s/reminders/", userId)
+
+	send := func(t *testing.T, method string, url string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	readReminder := func(t *testing.T, w *httptest.ResponseRecorder) model.Reminder {
+		var response struct {
+			Data model.Reminder `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Response should be valid JSON")
+
+		return response.Data
+	}
+
+	create := func(t *testing.T, payload string) model.Reminder {
+		w := send(t, http.MethodPost, baseURL, payload)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		return readReminder(t, w)
+	}
+
+	t.Run("A reminder rule is created, listed, replaced and deleted", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		created := create(t, `{"type": "missed_weigh_in", "threshold": 3, "quiet_start": "22:00", "quiet_end": "07:30"}`)
+		assert.NotZero(t, created.ID)
+		assert.Equal(t, userId, created.UserID)
+		assert.Equal(t, model.ReminderMissedWeighIn, created.Type)
+		assert.Equal(t, uint(3), created.Threshold)
+		assert.Equal(t, "22:00", *created.QuietStart)
+		assert.Equal(t, "07:30", *created.QuietEnd)
+
+		w := send(t, http.MethodGet, baseURL, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var list struct {
+			Data []model.Reminder `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Len(t, list.Data, 1)
+		assert.Equal(t, created.ID, list.Data[0].ID)
+
+		url := fmt.Sprintf("%s%d", baseURL, created.ID)
+		w = send(t, http.MethodPut, url, `{"type": "upcoming_routine", "threshold": 30}`)
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		updated := readReminder(t, w)
+		assert.Equal(t, model.ReminderUpcomingRoutine, updated.Type)
+		assert.Equal(t, uint(30), updated.Threshold)
+		assert.Nil(t, updated.QuietStart)
+
+		w = send(t, http.MethodGet, url, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+		assert.Equal(t, updated, readReminder(t, w))
+
+		w = send(t, http.MethodDelete, url, "")
+		assert.Equal(t, http.StatusNoContent, w.Code, "Status code should be 204")
+
+		w = send(t, http.MethodGet, url, "")
+		assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
+		assert.Equal(t, "reminder.not_found", readProblem(t, w).Code)
+	})
+
+	t.Run("Invalid reminder rules are rejected", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPost, baseURL, `{"type": "birthday", "threshold": 3}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+		assert.Equal(t, "reminder.invalid", readProblem(t, w).Code)
+
+		w = send(t, http.MethodPost, baseURL, `{"type": "missed_weigh_in", "threshold": 3, "quiet_start": "25:00", "quiet_end": "07:00"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+		assert.Equal(t, "reminder.invalid", readProblem(t, w).Code)
+
+		w = send(t, http.MethodPost, baseURL, `{"type": "missed_weigh_in", "threshold": 3, "quiet_start": "22:00"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+		assert.Equal(t, "reminder.incomplete_quiet_hours", readProblem(t, w).Code)
+
+		w = send(t, http.MethodPost, baseURL, `{"type": "upcoming_routine", "threshold": 2000}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+		assert.Equal(t, "reminder.threshold_too_large", readProblem(t, w).Code)
+
+		w = send(t, http.MethodDelete, baseURL+"abc", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+		assert.Equal(t, "reminder.invalid_id", readProblem(t, w).Code)
+	})
+
+	evaluate := func(t *testing.T, now time.Time) []model.Notification {
+		s, err := service.NewReminderService(nil, nil, nil, nil, nil, nil)
+		assert.NoError(t, err)
+
+		_, err = s.EvaluateReminders(context.Background(), now)
+		assert.NoError(t, err)
+
+		r, err := repository.NewJobRepository(nil)
+		assert.NoError(t, err)
+
+		page, err := r.GetJobs(context.Background(), model.PageParams{Limit: model.MaxPageLimit, Sort: model.SortAsc})
+		assert.NoError(t, err)
+
+		notifications := make([]model.Notification, 0)
+		for _, job := range page.Items {
+			if job.Name != service.DeliverReminderJob {
+				continue
+			}
+
+			var notification model.Notification
+			assert.NoError(t, json.Unmarshal([]byte(*job.Payload), &notification))
+			notifications = append(notifications, notification)
+		}
+
+		return notifications
+	}
+
+	t.Run("Missed weigh-ins are reminded once every threshold days", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		create(t, `{"type": "missed_weigh_in", "threshold": 3}`)
+		now := time.Now()
+
+		assert.Empty(t, evaluate(t, now.Add(2*24*time.Hour)))
+
+		notifications := evaluate(t, now.Add(4*24*time.Hour))
+		assert.Len(t, notifications, 1)
+		assert.Equal(t, "reminder.missed_weigh_in", notifications[0].Code)
+		assert.Equal(t, userId, notifications[0].UserID)
+
+		assert.Len(t, evaluate(t, now.Add(5*24*time.Hour)), 1, "the same period shouldn't be reminded twice")
+		assert.Len(t, evaluate(t, now.Add(7*24*time.Hour)), 2)
+	})
+
+	t.Run("A weigh-in resets the missed weigh-in reminders", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		create(t, `{"type": "missed_weigh_in", "threshold": 3}`)
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/anthropometrics/", userId), `{"weight": 80}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		assert.Empty(t, evaluate(t, time.Now().Add(2*24*time.Hour)))
+	})
+
+	t.Run("Upcoming routines are reminded before they start, outside the quiet hours", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/routines/", userId), `{"name": "Gym", "day": "Monday", "start_hour": 8, "end_hour": 10}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		reminder := create(t, `{"type": "upcoming_routine", "threshold": 30, "quiet_start": "07:50", "quiet_end": "07:55"}`)
+
+		// 2030-01-07 is a Monday, the user has no time zone so the routine starts at 8:00 UTC
+		assert.Empty(t, evaluate(t, time.Date(2030, 1, 7, 7, 0, 0, 0, time.UTC)))
+		assert.Empty(t, evaluate(t, time.Date(2030, 1, 7, 7, 52, 0, 0, time.UTC)), "quiet hours shouldn't be reminded")
+
+		notifications := evaluate(t, time.Date(2030, 1, 7, 7, 40, 0, 0, time.UTC))
+		assert.Len(t, notifications, 1)
+		assert.Equal(t, "reminder.upcoming_routine", notifications[0].Code)
+		assert.Equal(t, map[string]string{"name": "Gym", "minutes": "20"}, notifications[0].Params)
+
+		assert.Len(t, evaluate(t, time.Date(2030, 1, 7, 7, 45, 0, 0, time.UTC)), 1, "the same routine shouldn't be reminded twice")
+		assert.Len(t, evaluate(t, time.Date(2030, 1, 14, 7, 45, 0, 0, time.UTC)), 2, "the routine of the next week should be reminded")
+
+		w = send(t, http.MethodDelete, fmt.Sprintf("%s%d", baseURL, reminder.ID), "")
+		assert.Equal(t, http.StatusNoContent, w.Code, "Status code should be 204")
+		assert.Len(t, evaluate(t, time.Date(2030, 1, 21, 7, 45, 0, 0, time.UTC)), 2, "deleted rules shouldn't be reminded")
+	})
+}
@@ -141,9 +141,7 @@ func TestPostUserRoutine(t *testing.T) {
 
 		assert.Equal(t, http.StatusConflict, w.Code, "Status code should be 409")
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, "Routine conflict", response.Title)
 		assert.Equal(t, "There is already a routine scheduled in the same time interval or subinterval", response.Detail)
 	})
@@ -283,13 +281,12 @@ func TestPostUserRoutine(t *testing.T) {
 			Title:    "Unauthorized user",
 			Detail:   `The user isn't authorized because no Authorization header is provided`,
 			Status:   http.StatusUnauthorized,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeAuthentication,
+			Code:     "auth.missing_header",
 			Instance: baseURL,
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 
@@ -311,9 +308,7 @@ func TestPostUserRoutine(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, "Invalid routine data", response.Title)
 	})
 }
@@ -415,13 +410,12 @@ func TestGetUserRoutines(t *testing.T) {
 			Title:    "Unauthorized user",
 			Detail:   `The user isn't authorized because no Authorization header is provided`,
 			Status:   http.StatusUnauthorized,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeAuthentication,
+			Code:     "auth.missing_header",
 			Instance: baseURL,
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 }
@@ -505,13 +499,12 @@ func TestDeleteUserRoutine(t *testing.T) {
 			Title:    "Unauthorized user",
 			Detail:   `The user isn't authorized because no Authorization header is provided`,
 			Status:   http.StatusUnauthorized,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeAuthentication,
+			Code:     "auth.missing_header",
 			Instance: baseURL,
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 
@@ -533,9 +526,7 @@ func TestDeleteUserRoutine(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, "Invalid routine data", response.Title)
 	})
 }
@@ -675,13 +666,12 @@ func TestGetFreeSchedules(t *testing.T) {
 			Title:    "Unauthorized user",
 			Detail:   `The user isn't authorized because no Authorization header is provided`,
 			Status:   http.StatusUnauthorized,
-			Type:     "about:blank",
+			Type:     model.ProblemTypeAuthentication,
+			Code:     "auth.missing_header",
 			Instance: url,
 		}
 
-		var response model.ErrorRfc9457
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err, "Response should be valid JSON")
+		response := readProblem(t, w)
 		assert.Equal(t, expected, response)
 	})
 }
@@ -0,0 +1,53 @@
+package e2e_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	userId := testUser.ID
+	baseURL := fmt.Sprintf("/users/%s/routines/", userId)
+
+	t.Run("GET /users/:userId/routines - Exceeded route deadline should raise gateway timeout", func(t *testing.T) {
+		t.Setenv("ROUTE_TIMEOUTS", "GET /users/:userId/routines/=1ns")
+		timeoutRouter := utils.SetupRouter()
+
+		req, _ := http.NewRequest(http.MethodGet, baseURL, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		timeoutRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code, "Status code should be 504")
+
+		expected := model.ErrorRfc9457{
+			Title:    "Request timeout",
+			Detail:   "The request couldn't be completed before its deadline",
+			Status:   http.StatusGatewayTimeout,
+			Type:     model.ProblemTypeTimeout,
+			Code:     "request.timeout",
+			Instance: baseURL,
+		}
+
+		response := readProblem(t, w)
+		assert.Equal(t, expected, response)
+	})
+
+	t.Run("GET /users/:userId/routines - Other routes keep the default deadline", func(t *testing.T) {
+		t.Setenv("ROUTE_TIMEOUTS", "GET /users/:userId/exercises/=1ns")
+		timeoutRouter := utils.SetupRouter()
+
+		req, _ := http.NewRequest(http.MethodGet, baseURL, nil)
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		timeoutRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+	})
+}
@@ -0,0 +1,92 @@
+package e2e_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitConversion(t *testing.T) {
+	userId := testUser.ID
+	fixedDataURL := fmt.Sprintf("/users/%s/fixedData/", userId)
+	anthropometricsURL := fmt.Sprintf("/users/%s/anthropometrics/", userId)
+
+	send := func(t *testing.T, method string, url string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	t.Run("PUT /users/:userId/fixedData - Store the preference and convert the height", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		w := send(t, http.MethodPut, fixedDataURL, `{"height": 70, "birthday": "1990-01-01", "units": "imperial"}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		var imperial struct {
+			Data model.FixedUserData `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &imperial)
+		assert.NoError(t, err, "Response should be valid JSON")
+		assert.Equal(t, uint(70), imperial.Data.Height)
+		assert.Equal(t, model.UnitsImperial, *imperial.Data.Units)
+
+		w = send(t, http.MethodGet, fixedDataURL+"?units=metric", "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var metric struct {
+			Data model.FixedUserData `json:"data"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &metric)
+		assert.NoError(t, err, "Response should be valid JSON")
+		assert.Equal(t, uint(178), metric.Data.Height)
+	})
+
+	t.Run("POST /users/:userId/anthropometrics - Convert the values with the preference of the user", func(t *testing.T) {
+		defer test.ClearAllData()
+
+		send(t, http.MethodPut, fixedDataURL, `{"height": 70, "birthday": "1990-01-01", "units": "imperial"}`)
+
+		w := send(t, http.MethodPost, anthropometricsURL, `{"weight": 154.32, "circumferences": {"waist": 33}}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		var imperial struct {
+			Data model.AnthropometricEntry `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &imperial)
+		assert.NoError(t, err, "Response should be valid JSON")
+		assert.Equal(t, float32(154.32), imperial.Data.Weight)
+		assert.Equal(t, float32(33), *imperial.Data.Circumferences.Waist)
+
+		w = send(t, http.MethodGet, anthropometricsURL+"?units=metric", "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var metric struct {
+			Data []model.AnthropometricEntry `json:"data"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &metric)
+		assert.NoError(t, err, "Response should be valid JSON")
+		assert.Len(t, metric.Data, 1)
+		assert.Equal(t, float32(70), metric.Data[0].Weight)
+		assert.Equal(t, float32(83.82), *metric.Data[0].Circumferences.Waist)
+	})
+
+	t.Run("GET /users/:userId/anthropometrics?units=<units> - Unknown units should raise Validation Error", func(t *testing.T) {
+		w := send(t, http.MethodGet, anthropometricsURL+"?units=nautical", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+
+		response := readProblem(t, w)
+		assert.Equal(t, "request.invalid_units", response.Code)
+	})
+}
@@ -0,0 +1,217 @@
+package e2e_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/service"
+	"github.com/NutriPocket/ProgressService/test"
+	"github.com/NutriPocket/ProgressService/webhooks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhooks(t *testing.T) {
+	secret := "0123456789abcdef"
+
+	send := func(t *testing.T, method string, url string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("Authorization", bearerToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// receiver is a webhook that answers with status and records the events it receives
+	type receiver struct {
+		mu       sync.Mutex
+		status   int
+		received []model.WebhookEvent
+		server   *httptest.Server
+	}
+
+	newReceiver := func(t *testing.T, status int) *receiver {
+		r := &receiver{status: status}
+		r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			timestamp, _ := strconv.ParseInt(req.Header.Get(webhooks.TimestampHeader), 10, 64)
+			assert.True(t, webhooks.Verify(secret, timestamp, body, req.Header.Get(webhooks.SignatureHeader)), "the delivery should be signed")
+
+			var event model.WebhookEvent
+			assert.NoError(t, json.Unmarshal(body, &event))
+
+			r.mu.Lock()
+			r.received = append(r.received, event)
+			status := r.status
+			r.mu.Unlock()
+
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(r.server.Close)
+
+		return r
+	}
+
+	subscribe := func(t *testing.T, url string, eventTypes string) model.Webhook {
+		w := send(t, http.MethodPost, "/admin/webhooks/", fmt.Sprintf(`{"url": "%s", "secret": "%s", "event_types": %s}`, url, secret, eventTypes))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		var response struct {
+			Data model.Webhook `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		return response.Data
+	}
+
+	deliver := func(t *testing.T, now time.Time) int {
+		relay, err := service.NewOutboxService(nil, nil)
+		assert.NoError(t, err)
+
+		_, err = relay.Relay(context.Background())
+		assert.NoError(t, err)
+
+		s, err := service.NewWebhookService(nil)
+		assert.NoError(t, err)
+
+		delivered, err := s.DeliverDue(context.Background(), now)
+		assert.NoError(t, err)
+
+		return delivered
+	}
+
+	getDeliveries := func(t *testing.T, webhookId uint64, query string) []model.WebhookDelivery {
+		w := send(t, http.MethodGet, fmt.Sprintf("/admin/webhooks/%d/deliveries?%s", webhookId, query), "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		var response struct {
+			Data []model.WebhookDelivery `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		return response.Data
+	}
+
+	t.Run("Webhooks are managed by the administrators", func(t *testing.T) {
+		defer test.ClearAllData()
+		t.Setenv("ADMIN_USER_IDS", testUser.ID)
+
+		webhook := subscribe(t, "http://gamification/events", `["exercise.created", "objective.achieved"]`)
+		assert.NotZero(t, webhook.ID)
+		assert.Equal(t, []string{"exercise.created", "objective.achieved"}, webhook.EventTypes)
+
+		w := send(t, http.MethodGet, fmt.Sprintf("/admin/webhooks/%d", webhook.ID), "")
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+		assert.NotContains(t, w.Body.String(), secret, "the secret should never be returned")
+
+		w = send(t, http.MethodPut, fmt.Sprintf("/admin/webhooks/%d", webhook.ID), fmt.Sprintf(`{"url": "http://gamification/v2/events", "secret": "%s", "event_types": ["routine.created"]}`, secret))
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+		assert.Contains(t, w.Body.String(), "http://gamification/v2/events")
+
+		w = send(t, http.MethodPost, "/admin/webhooks/", fmt.Sprintf(`{"url": "http://gamification/events", "secret": "%s", "event_types": ["exercise.exploded"]}`, secret))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Status code should be 400")
+		assert.Equal(t, "webhook.invalid", readProblem(t, w).Code)
+
+		w = send(t, http.MethodDelete, fmt.Sprintf("/admin/webhooks/%d", webhook.ID), "")
+		assert.Equal(t, http.StatusNoContent, w.Code, "Status code should be 204")
+
+		w = send(t, http.MethodGet, fmt.Sprintf("/admin/webhooks/%d", webhook.ID), "")
+		assert.Equal(t, http.StatusNotFound, w.Code, "Status code should be 404")
+
+		t.Setenv("ADMIN_USER_IDS", "")
+		w = send(t, http.MethodGet, "/admin/webhooks/", "")
+		assert.Equal(t, http.StatusForbidden, w.Code, "Status code should be 403")
+	})
+
+	t.Run("Events are delivered signed to the webhooks subscribed to their type", func(t *testing.T) {
+		defer test.ClearAllData()
+		t.Setenv("ADMIN_USER_IDS", testUser.ID)
+
+		exercises := newReceiver(t, http.StatusOK)
+		routines := newReceiver(t, http.StatusNoContent)
+		subscribe(t, exercises.server.URL, `["exercise.created", "exercise.deleted"]`)
+		subscribe(t, routines.server.URL, `["routine.created"]`)
+
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/exercises/", testUser.ID), fmt.Sprintf(`{"userId": "%s", "exerciseName": "Running", "caloriesBurned": 300}`, testUser.ID))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		var created struct {
+			Data model.ExerciseData `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+		w = send(t, http.MethodPut, fmt.Sprintf("/users/%s/exercises/%d", testUser.ID, created.Data.ID), fmt.Sprintf(`{"userId": "%s", "exerciseName": "Running", "caloriesBurned": 350}`, testUser.ID))
+		assert.Equal(t, http.StatusOK, w.Code, "Status code should be 200")
+
+		w = send(t, http.MethodDelete, fmt.Sprintf("/users/%s/exercises/%d", testUser.ID, created.Data.ID), "")
+		assert.Equal(t, http.StatusNoContent, w.Code, "Status code should be 204")
+
+		w = send(t, http.MethodPost, fmt.Sprintf("/users/%s/routines/", testUser.ID), `{"name": "Gym", "day": "Monday", "start_hour": 8, "end_hour": 10}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		assert.Equal(t, 3, deliver(t, time.Now()))
+		assert.Equal(t, 0, deliver(t, time.Now()), "delivered events shouldn't be sent again")
+
+		assert.Len(t, exercises.received, 2)
+		assert.Equal(t, "exercise.created", exercises.received[0].Type)
+		assert.Equal(t, "exercise.deleted", exercises.received[1].Type)
+		assert.Equal(t, testUser.ID, exercises.received[0].UserID)
+		assert.NotEqual(t, exercises.received[0].ID, exercises.received[1].ID)
+
+		assert.Len(t, routines.received, 1)
+		assert.Equal(t, "routine.created", routines.received[0].Type)
+	})
+
+	t.Run("Failed deliveries are retried with backoff and then moved to the dead letters", func(t *testing.T) {
+		defer test.ClearAllData()
+		t.Setenv("ADMIN_USER_IDS", testUser.ID)
+		t.Setenv("WEBHOOK_MAX_ATTEMPTS", "2")
+		t.Setenv("WEBHOOK_RETRY_BACKOFF", "1h")
+
+		down := newReceiver(t, http.StatusServiceUnavailable)
+		webhook := subscribe(t, down.server.URL, `["exercise.created"]`)
+
+		w := send(t, http.MethodPost, fmt.Sprintf("/users/%s/exercises/", testUser.ID), fmt.Sprintf(`{"userId": "%s", "exerciseName": "Running", "caloriesBurned": 300}`, testUser.ID))
+		assert.Equal(t, http.StatusCreated, w.Code, "Status code should be 201")
+
+		assert.Equal(t, 0, deliver(t, time.Now()))
+		assert.Equal(t, 0, deliver(t, time.Now()))
+		assert.Len(t, down.received, 1, "the retry should wait for the backoff")
+
+		pending := getDeliveries(t, webhook.ID, "status=pending")
+		assert.Len(t, pending, 1)
+		assert.Equal(t, uint(1), pending[0].Attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, *pending[0].LastStatusCode)
+
+		assert.Equal(t, 0, deliver(t, time.Now().Add(2*time.Hour)))
+		assert.Len(t, down.received, 2)
+
+		dead := getDeliveries(t, webhook.ID, "status=dead")
+		assert.Len(t, dead, 1)
+		assert.Empty(t, getDeliveries(t, webhook.ID, "status=pending"))
+
+		down.mu.Lock()
+		down.status = http.StatusOK
+		down.mu.Unlock()
+		w = send(t, http.MethodPost, fmt.Sprintf("/admin/webhooks/%d/deliveries/%d/retry", webhook.ID, dead[0].ID), "")
+		assert.Equal(t, http.StatusAccepted, w.Code, "Status code should be 202")
+
+		assert.Equal(t, 1, deliver(t, time.Now()))
+		assert.Len(t, getDeliveries(t, webhook.ID, "status=delivered"), 1)
+
+		w = send(t, http.MethodPost, fmt.Sprintf("/admin/webhooks/%d/deliveries/%d/retry", webhook.ID, dead[0].ID), "")
+		assert.Equal(t, http.StatusConflict, w.Code, "Status code should be 409")
+		assert.Equal(t, "webhook.delivery_not_dead", readProblem(t, w).Code)
+	})
+}
@@ -2,17 +2,17 @@ package test
 
 import (
 	"fmt"
+	"log"
+	"log/slog"
 	"os"
 
 	"github.com/NutriPocket/ProgressService/database"
 	"github.com/NutriPocket/ProgressService/model"
 	"github.com/NutriPocket/ProgressService/service"
 	"github.com/joho/godotenv"
-	"github.com/op/go-logging"
 	"gorm.io/gorm"
 )
 
-var log = logging.MustGetLogger("log")
 var gormDB *gorm.DB
 
 func loadEnv() {
@@ -42,18 +42,42 @@ func ClearAllData() {
 		log.Fatal(err)
 	}
 
+	if err := gormDB.Exec(`
+		DELETE FROM height_history;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+
 	if err := gormDB.Exec(`
 		DELETE FROM anthropometric_data;
 	`).Error; err != nil {
 		log.Fatal(err)
 	}
 
+	if err := gormDB.Exec(`
+		DELETE FROM body_measurements;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+
 	if err := gormDB.Exec(`
 		DELETE FROM objective;
 	`).Error; err != nil {
 		log.Fatal(err)
 	}
 
+	if err := gormDB.Exec(`
+		DELETE FROM objective_history;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+
+	if err := gormDB.Exec(`
+		DELETE FROM objective_milestone;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+
 	if err := gormDB.Exec(`
 		DELETE FROM user_routines;
 	`).Error; err != nil {
@@ -65,17 +89,69 @@ func ClearAllData() {
 	`).Error; err != nil {
 		log.Fatal(err)
 	}
+
+	if err := gormDB.Exec(`
+		DELETE FROM reminder_rule;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+
+	if err := gormDB.Exec(`
+		DELETE FROM idempotency_key;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+
+	if err := gormDB.Exec(`
+		DELETE FROM outbox;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+
+	if err := gormDB.Exec(`
+		DELETE FROM webhook_subscription;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+
+	if err := gormDB.Exec(`
+		DELETE FROM webhook_delivery;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+
+	if err := gormDB.Exec(`
+		DELETE FROM job;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+
+	if err := gormDB.Exec(`
+		DELETE FROM job_run;
+	`).Error; err != nil {
+		log.Fatal(err)
+	}
+}
+
+// ForgetHeightHistory deletes the height history of the user, like the ones registered before
+// it was kept
+func ForgetHeightHistory(userId string) {
+	if err := gormDB.Exec(`
+		DELETE FROM height_history WHERE user_id = ?;
+	`, userId).Error; err != nil {
+		log.Fatal(err)
+	}
 }
 
 func Setup(testType string) {
-	log.Infof("Setup %s tests!\n", testType)
+	slog.Info("Setup tests", "type", testType)
 	loadEnv()
-	log.Info(".env.test loaded")
+	slog.Info(".env.test loaded")
 	setupDB()
 }
 
 func TearDown(testType string) {
-	log.Infof("Tear down %s tests!\n", testType)
+	slog.Info("Tear down tests", "type", testType)
 	database.Close()
 }
 
@@ -0,0 +1,92 @@
+// Package tracing configures OpenTelemetry tracing for the service.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName  = "github.com/NutriPocket/ProgressService"
+	serviceName = "progress-service"
+)
+
+// Init configures the global tracer provider and the W3C trace context propagator.
+// The exporter is selected with OTEL_TRACES_EXPORTER:
+//   - "otlp": exports over OTLP/HTTP, configured with the standard OTEL_EXPORTER_OTLP_* variables
+//   - "stdout": pretty prints the spans, useful for local runs
+//   - "none" or empty: spans are created, with real trace IDs, and propagated but not exported
+//
+// It returns a function that flushes and stops the tracer provider, meant to be called on shutdown.
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch kind := os.Getenv("OTEL_TRACES_EXPORTER"); kind {
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "", "none":
+	default:
+		err = fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q", kind)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without an exporter the spans are still recorded, so the trace IDs of the logs and
+	// problems are real, but they are dropped once ended
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if exporter != nil {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+
+	if exporter == nil {
+		slog.Info("Tracing exporter disabled")
+	} else {
+		slog.Info("Tracing enabled", "exporter", os.Getenv("OTEL_TRACES_EXPORTER"))
+	}
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of the service, backed by the global tracer provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// StartSpan starts a span named name as a child of the span in ctx, if any.
+// It returns the context holding the new span, which must be ended by the caller.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
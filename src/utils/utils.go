@@ -7,16 +7,28 @@ import (
 
 	middlewareAuth "github.com/NutriPocket/ProgressService/middleware/auth_middleware"
 	middlewareErr "github.com/NutriPocket/ProgressService/middleware/error_handler"
+	middlewareLogging "github.com/NutriPocket/ProgressService/middleware/logging_middleware"
+	middlewareMetrics "github.com/NutriPocket/ProgressService/middleware/metrics_middleware"
+	middlewareTimeout "github.com/NutriPocket/ProgressService/middleware/timeout_middleware"
+	middlewareTracing "github.com/NutriPocket/ProgressService/middleware/tracing_middleware"
 )
 
 // SetupRouter sets up the routes for the application.
 // It returns a router with the middlewares and routes set up.
 func SetupRouter() *gin.Engine {
-	router := gin.Default()
+	router := gin.New()
 
+	router.Use(gin.Recovery())
+	router.Use(middlewareTracing.TracingMiddleware())
+	router.Use(middlewareLogging.LoggingMiddleware())
+	router.Use(middlewareMetrics.MetricsMiddleware())
 	router.Use(middlewareErr.ErrorHandler())
+	router.Use(middlewareTimeout.TimeoutMiddleware())
 	router.Use(middlewareAuth.AuthMiddleware())
+	routes.HealthRoutes(router)
+	routes.MetricsRoutes(router)
 	routes.UsersRoutes(router)
+	routes.AdminRoutes(router)
 
 	return router
 }
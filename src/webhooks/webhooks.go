@@ -0,0 +1,94 @@
+// Package webhooks signs and posts the deliveries of the domain events to the webhooks other
+// services subscribed. Deliveries are stored and retried by the webhook service, see
+// service.WebhookService.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of the deliveries
+const (
+	// EventIDHeader is the ID of the event, the same event may be delivered more than once
+	EventIDHeader = "X-Webhook-Event-Id"
+	// EventTypeHeader is the type of the event, e.g. exercise.created
+	EventTypeHeader = "X-Webhook-Event-Type"
+	// TimestampHeader is when the delivery was sent, in Unix seconds
+	TimestampHeader = "X-Webhook-Timestamp"
+	// SignatureHeader is "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>"
+	// keyed with the secret of the webhook
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Sign returns the signature of a delivery of body sent at timestamp, the value of the SignatureHeader
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns whether signature is the one of body sent at timestamp. Receivers should also
+// reject timestamps too far from their clock, so deliveries can't be replayed.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Delivery is an event to post to a webhook
+type Delivery struct {
+	URL       string
+	Secret    string
+	EventID   string
+	EventType string
+	Body      []byte
+}
+
+// Sender posts the deliveries
+type Sender struct {
+	client *http.Client
+}
+
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send posts the delivery signed at now. It returns the status the webhook answered with, nil
+// if it didn't answer, and an error unless it's a 2xx status.
+func (s *Sender) Send(ctx context.Context, delivery Delivery, now time.Time) (*int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, delivery.EventID)
+	req.Header.Set(EventTypeHeader, delivery.EventType)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	status := res.StatusCode
+	if status < 200 || status >= 300 {
+		return &status, fmt.Errorf("the webhook answered with status %d", status)
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,87 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"exercise.created"}`)
+
+	t.Run("The signature depends on the secret, the timestamp and the body", func(t *testing.T) {
+		signature := Sign("secret", 1700000000, body)
+
+		assert.True(t, Verify("secret", 1700000000, body, signature))
+		assert.False(t, Verify("other", 1700000000, body, signature))
+		assert.False(t, Verify("secret", 1700000001, body, signature))
+		assert.False(t, Verify("secret", 1700000000, []byte(`{}`), signature))
+	})
+
+	t.Run("The signature is a hex HMAC-SHA256", func(t *testing.T) {
+		signature := Sign("secret", 1700000000, body)
+
+		assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	})
+}
+
+func TestSender(t *testing.T) {
+	delivery := Delivery{
+		Secret:    "secret",
+		EventID:   "abc",
+		EventType: "exercise.created",
+		Body:      []byte(`{"type":"exercise.created"}`),
+	}
+	now := time.Unix(1700000000, 0)
+
+	t.Run("Posts the signed body", func(t *testing.T) {
+		var header http.Header
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			body, _ = io.ReadAll(r.Body)
+		}))
+		defer server.Close()
+
+		d := delivery
+		d.URL = server.URL
+		status, err := NewSender(time.Second).Send(context.Background(), d, now)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, *status)
+
+		assert.Equal(t, delivery.Body, body)
+		assert.Equal(t, "abc", header.Get(EventIDHeader))
+		assert.Equal(t, "exercise.created", header.Get(EventTypeHeader))
+
+		timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+		assert.NoError(t, err)
+		assert.True(t, Verify("secret", timestamp, body, header.Get(SignatureHeader)))
+	})
+
+	t.Run("Fails with the status of an unsuccessful answer", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		d := delivery
+		d.URL = server.URL
+		status, err := NewSender(time.Second).Send(context.Background(), d, now)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, *status)
+	})
+
+	t.Run("Fails without a status if the webhook doesn't answer", func(t *testing.T) {
+		d := delivery
+		d.URL = "http://127.0.0.1:1"
+		status, err := NewSender(time.Second).Send(context.Background(), d, now)
+		assert.Error(t, err)
+		assert.Nil(t, status)
+	})
+}
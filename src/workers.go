@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NutriPocket/ProgressService/jobs"
+	"github.com/NutriPocket/ProgressService/model"
+	"github.com/NutriPocket/ProgressService/service"
+)
+
+// Names of the background jobs
+const (
+	closeObjectivesJob   = "objectives.close"
+	evaluateRemindersJob = "reminders.evaluate"
+	deliverWebhooksJob   = "webhooks.deliver"
+	pruneOutboxJob       = "outbox.prune"
+	pruneIdempotencyJob  = "idempotency.prune"
+)
+
+// registerJobs sets the handlers of the background jobs
+func registerJobs() {
+	jobs.Register(closeObjectivesJob, func(ctx context.Context, payload json.RawMessage) error {
+		s, err := service.NewObjectiveService(nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		closed, err := s.CloseObjectives(ctx, time.Now())
+		slog.DebugContext(ctx, "Evaluated active objectives", "closed", closed)
+
+		return err
+	})
+
+	jobs.Register(evaluateRemindersJob, func(ctx context.Context, payload json.RawMessage) error {
+		s, err := service.NewReminderService(nil, nil, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		enqueued, err := s.EvaluateReminders(ctx, time.Now())
+		slog.DebugContext(ctx, "Evaluated reminder rules", "enqueued", enqueued)
+
+		return err
+	})
+
+	jobs.Register(deliverWebhooksJob, func(ctx context.Context, payload json.RawMessage) error {
+		s, err := service.NewWebhookService(nil)
+		if err != nil {
+			return err
+		}
+
+		delivered, err := s.DeliverDue(ctx, time.Now())
+		slog.DebugContext(ctx, "Sent due webhook deliveries", "delivered", delivered)
+
+		return err
+	})
+
+	jobs.Register(pruneOutboxJob, func(ctx context.Context, payload json.RawMessage) error {
+		s, err := service.NewOutboxService(nil, nil)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := s.Prune(ctx, time.Now())
+		slog.DebugContext(ctx, "Pruned published outbox events", "deleted", deleted)
+
+		return err
+	})
+
+	jobs.Register(pruneIdempotencyJob, func(ctx context.Context, payload json.RawMessage) error {
+		s, err := service.NewIdempotencyService(nil)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := s.Prune(ctx, time.Now())
+		slog.DebugContext(ctx, "Pruned expired idempotency keys", "deleted", deleted)
+
+		return err
+	})
+
+	jobs.Register(service.DeliverReminderJob, func(ctx context.Context, payload json.RawMessage) error {
+		var notification model.Notification
+		if err := json.Unmarshal(payload, &notification); err != nil {
+			return err
+		}
+
+		s, err := service.NewReminderService(nil, nil, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		return s.Deliver(ctx, notification)
+	})
+}
+
+// scheduleJobs stores the recurring jobs, with the schedules of their environment variables
+func scheduleJobs(ctx context.Context, s service.IJobService) error {
+	schedules := []struct {
+		name string
+		env  string
+		def  string
+	}{
+		{closeObjectivesJob, "OBJECTIVE_EVALUATION_SCHEDULE", "@every 5m"},
+		{evaluateRemindersJob, "REMINDER_EVALUATION_SCHEDULE", "@every 1m"},
+		{deliverWebhooksJob, "WEBHOOK_DELIVERY_SCHEDULE", "@every 10s"},
+		{pruneOutboxJob, "OUTBOX_PRUNE_SCHEDULE", "@daily"},
+		{pruneIdempotencyJob, "IDEMPOTENCY_PRUNE_SCHEDULE", "@hourly"},
+	}
+
+	var errs []error
+	for _, job := range schedules {
+		schedule := os.Getenv(job.env)
+		if schedule == "" {
+			schedule = job.def
+		}
+
+		if err := s.Schedule(ctx, job.name, schedule, nil); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", job.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// runJobs runs the due background jobs with JOB_WORKERS workers until ctx is cancelled. Each
+// worker looks for a due job every JOB_POLL_INTERVAL while there are none. Jobs that are
+// running when ctx is cancelled are allowed to finish until the shutdown deadline.
+func runJobs(ctx context.Context) {
+	registerJobs()
+
+	s, err := service.NewJobService(nil)
+	if err != nil {
+		slog.Error("Failed to create job service, background jobs won't run", "error", err)
+		return
+	}
+
+	if err := scheduleJobs(ctx, s); err != nil {
+		slog.Error("Failed to schedule the recurring jobs", "error", err)
+	}
+
+	count := 2
+	if value := os.Getenv("JOB_WORKERS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			count = parsed
+		} else {
+			slog.Warn("Invalid JOB_WORKERS, using the default", "value", value, "default", count)
+		}
+	}
+
+	pollInterval := getDurationEnv("JOB_POLL_INTERVAL", 5*time.Second)
+
+	host, _ := os.Hostname()
+	slog.Info("Starting job workers", "workers", count)
+
+	var wg sync.WaitGroup
+	for i := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runJobWorker(ctx, s, fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i), pollInterval)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// runJobWorker runs the due jobs one at a time as worker, waiting pollInterval whenever there
+// are none or they can't be fetched
+func runJobWorker(ctx context.Context, s service.IJobService, worker string, pollInterval time.Duration) {
+	for ctx.Err() == nil {
+		// The job isn't cancelled along with the workers, it runs until its lease ends or the
+		// shutdown deadline passes
+		jobCtx, release := untilShutdownDeadline(ctx)
+		ran, err := s.RunNext(jobCtx, worker)
+		release()
+		if err != nil {
+			slog.Error("Failed to run the next job", "worker", worker, "error", err)
+		}
+
+		if ran && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// runOutboxRelay publishes the events of the outbox until ctx is cancelled, looking for new ones
+// every OUTBOX_POLL_INTERVAL. A relay that is running when ctx is cancelled is allowed to finish
+// until the shutdown deadline passes.
+func runOutboxRelay(ctx context.Context) {
+	s, err := service.NewOutboxService(nil, nil)
+	if err != nil {
+		slog.Error("Failed to create outbox service, domain events won't be published", "error", err)
+		return
+	}
+
+	pollInterval := getDurationEnv("OUTBOX_POLL_INTERVAL", time.Second)
+
+	for ctx.Err() == nil {
+		relayCtx, release := untilShutdownDeadline(ctx)
+		published, err := s.Relay(relayCtx)
+		release()
+		if err != nil {
+			slog.Error("Failed to relay the outbox", "error", err)
+		} else if published > 0 {
+			slog.Debug("Relayed outbox events", "published", published)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(pollInterval):
+		}
+	}
+}